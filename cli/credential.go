@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"czloapi/common/credential"
+	"czloapi/common/logger"
+	"czloapi/model"
+	"fmt"
+)
+
+func GenerateMasterKey() {
+	key, err := credential.GenerateMasterKey()
+	if err != nil {
+		fmt.Println("Failed to generate master key: " + err.Error())
+		return
+	}
+	fmt.Println(key)
+}
+
+// ReEncryptCredentials 使用当前主密钥加密未加密的凭据，并将旧主密钥加密的凭据重新加密
+func ReEncryptCredentials() {
+	channels, proxies, err := model.ReEncryptCredentials()
+	if err != nil {
+		logger.FatalLog("failed to re-encrypt credentials: " + err.Error())
+	}
+	logger.SysLog(fmt.Sprintf("re-encrypted %d channels and %d ip proxies", channels, proxies))
+}
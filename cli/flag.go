@@ -17,6 +17,8 @@ var (
 	logDir       = flag.String("log-dir", "", "specify the log directory")
 	Config       = flag.String("config", "config.yaml", "specify the config.yaml path")
 	export       = flag.Bool("export", false, "Exports prices to a JSON file.")
+	generateKey  = flag.Bool("generate-master-key", false, "generate a credential master key and exit")
+	ReEncrypt    = flag.Bool("reencrypt", false, "re-encrypt channel keys and proxy credentials with the current master key and exit")
 )
 
 func InitCli() {
@@ -45,6 +47,11 @@ func InitCli() {
 		os.Exit(0)
 	}
 
+	if *generateKey {
+		GenerateMasterKey()
+		os.Exit(0)
+	}
+
 	if !utils.IsFileExist(*Config) {
 		return
 	}
@@ -59,5 +66,5 @@ func InitCli() {
 func help() {
 	fmt.Println("CZLOapi " + config.Version + " - All in CZLOapi service for OpenAI API.")
 	fmt.Println("Copyright (C) 2024 CZLOapi All rights reserved.")
-	fmt.Println("Usage: czloapi [--port <port>] [--log-dir <log directory>] [--config <config.yaml path>] [--version] [--help]")
+	fmt.Println("Usage: czloapi [--port <port>] [--log-dir <log directory>] [--config <config.yaml path>] [--generate-master-key] [--reencrypt] [--version] [--help]")
 }
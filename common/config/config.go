@@ -47,4 +47,8 @@ func defaultConfig() {
 	viper.SetDefault("uptime_kuma.enable", false)
 	viper.SetDefault("uptime_kuma.domain", "")
 	viper.SetDefault("uptime_kuma.status_page_name", "")
+	viper.SetDefault("credential.secret_refresh_interval", 300)
+	viper.SetDefault("channel.authenticity_threshold", 60)
+	viper.SetDefault("channel.authenticity_action", "notify")
+	viper.SetDefault("channel.authenticity_lowered_priority", -1)
 }
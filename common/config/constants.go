@@ -192,6 +192,15 @@ var RetryTimeOut = 10
 var DefaultChannelWeight = uint(1)
 var RetryCooldownSeconds = 5
 
+var CircuitBreakerEnabled = false
+var CircuitBreakerFailureThreshold = 5
+var CircuitBreakerWindowSeconds = 60
+var CircuitBreakerOpenSeconds = 30
+var CircuitBreakerHalfOpenProbes = 1
+
+var ChannelQueueSize = 100
+var ChannelQueueTimeout = 3000 // 毫秒
+
 var CFWorkerImageUrl = ""
 var CFWorkerImageKey = ""
 
@@ -252,23 +261,31 @@ const (
 	ChannelTypeAnthropic       = 14
 	ChannelTypeZhipu           = 16
 	ChannelTypeAli             = 17
+	ChannelTypeOpenRouter      = 20
+	ChannelTypeHunyuan         = 23
 	ChannelTypeGemini          = 25
 	ChannelTypeMiniMax         = 27
 	ChannelTypeDeepseek        = 28
 	ChannelTypeMoonshot        = 29
+	ChannelTypeMistral         = 30
 	ChannelTypeGroq            = 31
 	ChannelTypeBedrock         = 32
 	ChannelTypeCloudflareAI    = 35
 	ChannelTypeCohere          = 36
 	ChannelTypeOllama          = 39
+	ChannelTypeVolcengine      = 40
 	ChannelTypeVertexAI        = 42
 	ChannelTypeLLAMA           = 43
 	ChannelTypeIdeogram        = 44
 	ChannelTypeFlux            = 46
+	ChannelTypeJina            = 47
 	ChannelTypeRerank          = 48
+	ChannelTypeVoyage          = 49
 	ChannelTypeAzureDatabricks = 54
 	ChannelTypeAzureV1         = 55
 	ChannelTypeXAI             = 56
+	ChannelTypeElevenLabs      = 57
+	ChannelTypeStability       = 58
 )
 
 const (
@@ -288,6 +305,7 @@ const (
 	RelayModeChatRealtime
 	RelayModeResponses
 	RelayModeResponsesWS
+	RelayModeOCR
 )
 
 type ContextKey string
@@ -0,0 +1,207 @@
+package credential
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// 信封加密：每个值使用随机生成的数据密钥加密，数据密钥再由主密钥加密后与密文一起保存
+// 格式：enc:v1:<主密钥ID>:<加密后的数据密钥>:<密文>
+const prefix = "enc:v1:"
+
+var ErrMasterKeyMissing = errors.New("credential master key not found")
+
+type masterKey struct {
+	id  string
+	key []byte
+}
+
+var (
+	mu      sync.RWMutex
+	current *masterKey
+	keyring = map[string]*masterKey{}
+)
+
+// Init 从配置读取主密钥，credential.master_key 为当前主密钥，credential.previous_keys 为轮换前的旧主密钥（逗号分隔），仅用于解密
+func Init() {
+	var previous []string
+	for _, key := range strings.Split(viper.GetString("credential.previous_keys"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			previous = append(previous, key)
+		}
+	}
+
+	SetMasterKeys(viper.GetString("credential.master_key"), previous...)
+}
+
+// SetMasterKeys 设置当前主密钥和旧主密钥，当前主密钥为空时不加密
+func SetMasterKeys(currentKey string, previousKeys ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	current = nil
+	keyring = map[string]*masterKey{}
+
+	if currentKey = strings.TrimSpace(currentKey); currentKey != "" {
+		current = newMasterKey(currentKey)
+		keyring[current.id] = current
+	}
+	for _, key := range previousKeys {
+		previous := newMasterKey(key)
+		keyring[previous.id] = previous
+	}
+}
+
+func newMasterKey(key string) *masterKey {
+	sum := sha256.Sum256([]byte(key))
+	idSum := sha256.Sum256(sum[:])
+	return &masterKey{
+		id:  hex.EncodeToString(idSum[:4]),
+		key: sum[:],
+	}
+}
+
+// Enabled 是否配置了主密钥
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current != nil
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// NeedsReEncrypt 判断值是否需要用当前主密钥重新加密：未加密的明文或由旧主密钥加密
+func NeedsReEncrypt(value string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if current == nil || value == "" {
+		return false
+	}
+	if !IsEncrypted(value) {
+		return true
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return id != current.id
+}
+
+// GenerateMasterKey 生成随机主密钥
+func GenerateMasterKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+func seal(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+// Encrypt 使用当前主密钥加密，未配置主密钥、值为空或已加密时原样返回
+func Encrypt(plaintext string) (string, error) {
+	mu.RLock()
+	key := current
+	mu.RUnlock()
+
+	if key == nil || plaintext == "" || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+	wrappedKey, err := seal(key.key, dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + key.id + ":" +
+		base64.RawStdEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt 解密，未加密的值原样返回
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", errors.New("invalid encrypted credential")
+	}
+
+	mu.RLock()
+	key, ok := keyring[parts[0]]
+	mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrMasterKeyMissing, parts[0])
+	}
+
+	wrappedKey, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := open(key.key, wrappedKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataKey, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,78 @@
+package credential
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	SetMasterKeys("master-key")
+	defer SetMasterKeys("")
+
+	encrypted, err := Encrypt("sk-test")
+	assert.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, encrypted, "sk-test")
+
+	// 每次加密使用不同的数据密钥
+	again, _ := Encrypt("sk-test")
+	assert.NotEqual(t, encrypted, again)
+
+	plaintext, err := Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-test", plaintext)
+
+	// 已加密的值不会重复加密
+	same, _ := Encrypt(encrypted)
+	assert.Equal(t, encrypted, same)
+}
+
+func TestEncryptWithoutMasterKey(t *testing.T) {
+	SetMasterKeys("")
+
+	value, err := Encrypt("sk-test")
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-test", value)
+	assert.False(t, NeedsReEncrypt("sk-test"))
+
+	plaintext, err := Decrypt("sk-test")
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-test", plaintext)
+}
+
+func TestMasterKeyRotation(t *testing.T) {
+	SetMasterKeys("old-key")
+	encrypted, _ := Encrypt("sk-test")
+	assert.False(t, NeedsReEncrypt(encrypted))
+
+	SetMasterKeys("new-key", "old-key")
+	defer SetMasterKeys("")
+	assert.True(t, NeedsReEncrypt(encrypted))
+	assert.True(t, NeedsReEncrypt("sk-plain"))
+
+	plaintext, err := Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-test", plaintext)
+
+	reEncrypted, _ := Encrypt(plaintext)
+	assert.False(t, NeedsReEncrypt(reEncrypted))
+
+	// 旧主密钥移除后无法解密
+	SetMasterKeys("new-key")
+	_, err = Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrMasterKeyMissing)
+}
+
+func TestDecryptTampered(t *testing.T) {
+	SetMasterKeys("master-key")
+	defer SetMasterKeys("")
+
+	encrypted, _ := Encrypt("sk-test")
+	parts := strings.Split(encrypted, ":")
+	parts[len(parts)-1] = strings.Repeat("A", len(parts[len(parts)-1]))
+
+	_, err := Decrypt(strings.Join(parts, ":"))
+	assert.Error(t, err)
+}
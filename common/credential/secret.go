@@ -0,0 +1,157 @@
+package credential
+
+import (
+	"czloapi/common/logger"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 密钥引用：env:NAME 从环境变量读取，file:/path 从文件读取
+// 渠道 key 的每一行以及 Bedrock 等 | 分隔格式的每一段都可以是引用
+const (
+	envRefPrefix  = "env:"
+	fileRefPrefix = "file:"
+)
+
+type secretEntry struct {
+	value string
+	err   error
+}
+
+var (
+	secretMu    sync.Mutex
+	secretCache = map[string]*secretEntry{}
+)
+
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, envRefPrefix) || strings.HasPrefix(value, fileRefPrefix)
+}
+
+// HasSecretRef 判断值中是否包含密钥引用
+func HasSecretRef(value string) bool {
+	for _, line := range strings.Split(value, "\n") {
+		for _, part := range strings.Split(strings.TrimSpace(line), "|") {
+			if IsSecretRef(part) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ResolveSecrets 将值中的密钥引用替换为实际内容，不包含引用时原样返回
+func ResolveSecrets(value string) (string, error) {
+	if !HasSecretRef(value) {
+		return value, nil
+	}
+
+	lines := strings.Split(value, "\n")
+	for i, line := range lines {
+		parts := strings.Split(strings.TrimSpace(line), "|")
+		if len(parts) == 1 && !IsSecretRef(parts[0]) {
+			continue
+		}
+
+		for j, part := range parts {
+			if !IsSecretRef(part) {
+				continue
+			}
+			resolved, err := resolveSecretRef(part)
+			if err != nil {
+				return "", err
+			}
+			parts[j] = resolved
+		}
+		lines[i] = strings.Join(parts, "|")
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// resolveSecretRef 读取引用的内容，成功的结果会被缓存直到下次刷新，失败的每次都会重新读取
+func resolveSecretRef(ref string) (string, error) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+
+	if entry, ok := secretCache[ref]; ok && entry.err == nil {
+		return entry.value, nil
+	}
+
+	value, err := readSecretRef(ref)
+	secretCache[ref] = &secretEntry{value: value, err: err}
+	return value, err
+}
+
+func readSecretRef(ref string) (string, error) {
+	if name, ok := strings.CutPrefix(ref, envRefPrefix); ok {
+		value, exists := os.LookupEnv(name)
+		if !exists || strings.TrimSpace(value) == "" {
+			return "", fmt.Errorf("secret %s: environment variable is not set", ref)
+		}
+		return strings.TrimSpace(value), nil
+	}
+
+	path := strings.TrimPrefix(ref, fileRefPrefix)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: %w", ref, err)
+	}
+	value := strings.TrimSpace(string(content))
+	if value == "" {
+		return "", fmt.Errorf("secret %s: file is empty", ref)
+	}
+	return value, nil
+}
+
+// RefreshSecrets 重新读取已缓存的全部引用，返回是否有内容发生变化
+func RefreshSecrets() bool {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+
+	changed := false
+	for ref, entry := range secretCache {
+		value, err := readSecretRef(ref)
+		if value != entry.value || (err == nil) != (entry.err == nil) {
+			changed = true
+		}
+		secretCache[ref] = &secretEntry{value: value, err: err}
+	}
+	return changed
+}
+
+var watchOnce sync.Once
+
+// WatchSecrets 按 credential.secret_refresh_interval（秒）定时刷新引用，收到 SIGHUP 时立即刷新，内容变化时调用 onChange
+func WatchSecrets(onChange func()) {
+	watchOnce.Do(func() {
+		var ticker <-chan time.Time
+		if interval := viper.GetInt("credential.secret_refresh_interval"); interval > 0 {
+			ticker = time.NewTicker(time.Duration(interval) * time.Second).C
+		}
+
+		hangup := make(chan os.Signal, 1)
+		signal.Notify(hangup, syscall.SIGHUP)
+
+		go func() {
+			for {
+				select {
+				case <-ticker:
+				case <-hangup:
+					logger.SysLog("received SIGHUP, refreshing secrets")
+				}
+
+				if RefreshSecrets() {
+					logger.SysLog("secrets changed, reloading channels")
+					onChange()
+				}
+			}
+		}()
+	})
+}
@@ -0,0 +1,61 @@
+package credential
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	t.Setenv("TEST_SECRET_AK", "ak-value")
+	t.Setenv("TEST_SECRET_SK", "sk-value")
+
+	path := filepath.Join(t.TempDir(), "key.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"type":"service_account"}`+"\n"), 0600))
+
+	value, err := ResolveSecrets("sk-plain")
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-plain", value)
+
+	value, err = ResolveSecrets("file:" + path)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"service_account"}`, value)
+
+	value, err = ResolveSecrets("us-east-1|env:TEST_SECRET_AK|env:TEST_SECRET_SK")
+	assert.NoError(t, err)
+	assert.Equal(t, "us-east-1|ak-value|sk-value", value)
+
+	value, err = ResolveSecrets("sk-plain\nenv:TEST_SECRET_AK")
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-plain\nak-value", value)
+
+	_, err = ResolveSecrets("env:TEST_SECRET_MISSING")
+	assert.Error(t, err)
+}
+
+func TestRefreshSecrets(t *testing.T) {
+	secretCache = map[string]*secretEntry{}
+
+	path := filepath.Join(t.TempDir(), "key")
+	assert.NoError(t, os.WriteFile(path, []byte("sk-old"), 0600))
+
+	value, _ := ResolveSecrets("file:" + path)
+	assert.Equal(t, "sk-old", value)
+	assert.False(t, RefreshSecrets())
+
+	// 刷新前使用缓存的内容
+	assert.NoError(t, os.WriteFile(path, []byte("sk-new"), 0600))
+	value, _ = ResolveSecrets("file:" + path)
+	assert.Equal(t, "sk-old", value)
+
+	assert.True(t, RefreshSecrets())
+	value, _ = ResolveSecrets("file:" + path)
+	assert.Equal(t, "sk-new", value)
+
+	assert.NoError(t, os.Remove(path))
+	assert.True(t, RefreshSecrets())
+	_, err := ResolveSecrets("file:" + path)
+	assert.Error(t, err)
+}
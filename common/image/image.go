@@ -11,6 +11,8 @@ import (
 	_ "image/png"
 	"net/http"
 	"czloapi/common/config"
+	"math"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -136,3 +138,44 @@ func ParseBase64File(base64Data string) (string, string, error) {
 
 	return mimeType, base64Data, nil
 }
+
+// GetClosestAspectRatio 将 1024x1024 形式的尺寸转换为候选比例（如 16:9）中最接近的一个，尺寸无效时返回空字符串
+func GetClosestAspectRatio(size string, aspectRatios []string) string {
+	width, height, ok := parseRatio(size, "x")
+	if !ok {
+		return ""
+	}
+
+	closest := ""
+	minDiff := math.MaxFloat64
+	for _, aspectRatio := range aspectRatios {
+		w, h, ok := parseRatio(aspectRatio, ":")
+		if !ok {
+			continue
+		}
+		diff := math.Abs(math.Log(width/height) - math.Log(w/h))
+		if diff < minDiff {
+			minDiff = diff
+			closest = aspectRatio
+		}
+	}
+
+	return closest
+}
+
+func parseRatio(value, sep string) (float64, float64, bool) {
+	parts := strings.Split(value, sep)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	w, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || w <= 0 {
+		return 0, 0, false
+	}
+	h, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil || h <= 0 {
+		return 0, 0, false
+	}
+
+	return w, h, true
+}
@@ -225,3 +225,11 @@ func TestParseBase64File(t *testing.T) {
 	assert.Equal(t, "audio/mpeg", mimeType)
 	assert.Equal(t, "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAQAAAC1HAwCAAAAC0lEQVR42mNkYAAAAAYAAjCB0C8AAAAASUVORK5CYII=", data)
 }
+
+func TestGetClosestAspectRatio(t *testing.T) {
+	aspectRatios := []string{"1:1", "16:9", "9:16", "3:2", "2:3"}
+	assert.Equal(t, "1:1", img.GetClosestAspectRatio("1024x1024", aspectRatios))
+	assert.Equal(t, "16:9", img.GetClosestAspectRatio("1792x1024", aspectRatios))
+	assert.Equal(t, "2:3", img.GetClosestAspectRatio("1024x1536", aspectRatios))
+	assert.Equal(t, "", img.GetClosestAspectRatio("auto", aspectRatios))
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"czloapi/common/config"
 	"czloapi/common/logger"
+	"errors"
+	"net"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -14,6 +16,9 @@ var RDB *redis.Client
 
 const Nil = redis.Nil
 
+// ErrReceiveTimeout 订阅在超时时间内没有收到任何消息
+var ErrReceiveTimeout = errors.New("redis pubsub receive timeout")
+
 // InitRedisClient This function is called after init()
 func InitRedisClient() (err error) {
 	redisConn := viper.GetString("redis_conn_string")
@@ -106,3 +111,40 @@ func RedisSIsMember(key string, member interface{}) (bool, error) {
 	ctx := context.Background()
 	return RDB.SIsMember(ctx, key, member).Result()
 }
+
+func RedisPublish(channel string, message string) error {
+	ctx := context.Background()
+	return RDB.Publish(ctx, channel, message).Err()
+}
+
+func RedisSubscribe(channels ...string) *redis.PubSub {
+	ctx := context.Background()
+	return RDB.Subscribe(ctx, channels...)
+}
+
+// RedisReceive 读取一条订阅消息，订阅确认、pong 等非消息帧返回 nil
+// 超时返回 ErrReceiveTimeout，其他错误说明连接已断开，需要重新订阅
+func RedisReceive(pubsub *redis.PubSub, timeout time.Duration) (*redis.Message, error) {
+	ctx := context.Background()
+	msg, err := pubsub.ReceiveTimeout(ctx, timeout)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, ErrReceiveTimeout
+		}
+		return nil, err
+	}
+
+	message, _ := msg.(*redis.Message)
+	return message, nil
+}
+
+func RedisScanKeys(pattern string) ([]string, error) {
+	ctx := context.Background()
+	keys := make([]string, 0)
+	iter := RDB.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
@@ -26,6 +26,8 @@ type HTTPRequester struct {
 	proxyAddr         string
 	Context           context.Context
 	IsOpenAI          bool
+	// RequestOverride 创建请求时修改请求头和请求体，用于渠道的覆盖规则
+	RequestOverride func(header http.Header, body any) (any, error)
 }
 
 // NewHTTPRequester 创建一个新的 HTTPRequester 实例。
@@ -64,6 +66,13 @@ func (r *HTTPRequester) NewRequest(method, url string, setters ...requestOption)
 	for _, setter := range setters {
 		setter(args)
 	}
+	if r.RequestOverride != nil {
+		body, err := r.RequestOverride(args.header, args.body)
+		if err != nil {
+			return nil, err
+		}
+		args.body = body
+	}
 	req, err := utils.RequestBuilder(r.setProxy(), method, url, args.body, args.header)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseJSONPointer 解析 RFC 6901 JSON Pointer，如 /messages/0/content
+func ParseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, errors.New("json pointer is empty")
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("json pointer %s must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// JSONPointerGet 获取 JSON 文档中指定路径的值，doc 为 json.Unmarshal 到 any 的结果
+func JSONPointerGet(doc any, tokens []string) (any, bool) {
+	current := doc
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// JSONPointerSet 设置指定路径的值，不存在的中间对象会被创建，数组可以使用 - 追加
+// 返回修改后的文档，根节点或数组追加时文档可能被替换
+func JSONPointerSet(doc any, tokens []string, value any) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	token, rest := tokens[0], tokens[1:]
+	switch node := doc.(type) {
+	case nil:
+		child, err := JSONPointerSet(nil, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{token: child}, nil
+	case map[string]any:
+		child, err := JSONPointerSet(node[token], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+		return node, nil
+	case []any:
+		if token == "-" {
+			child, err := JSONPointerSet(nil, rest, value)
+			if err != nil {
+				return nil, err
+			}
+			return append(node, child), nil
+		}
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || index >= len(node) {
+			return nil, fmt.Errorf("array index %s out of range", token)
+		}
+		child, err := JSONPointerSet(node[index], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = child
+		return node, nil
+	default:
+		return nil, fmt.Errorf("cannot set %s on a non-container value", token)
+	}
+}
+
+// JSONPointerRemove 删除指定路径的值，路径不存在时不做修改
+func JSONPointerRemove(doc any, tokens []string) any {
+	if len(tokens) == 0 {
+		return doc
+	}
+
+	token, rest := tokens[0], tokens[1:]
+	switch node := doc.(type) {
+	case map[string]any:
+		if len(rest) == 0 {
+			delete(node, token)
+		} else if child, ok := node[token]; ok {
+			node[token] = JSONPointerRemove(child, rest)
+		}
+		return node
+	case []any:
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || index >= len(node) {
+			return node
+		}
+		if len(rest) == 0 {
+			return append(node[:index], node[index+1:]...)
+		}
+		node[index] = JSONPointerRemove(node[index], rest)
+		return node
+	default:
+		return doc
+	}
+}
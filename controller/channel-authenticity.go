@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/common/logger"
+	"czloapi/common/notify"
+	"czloapi/common/utils"
+	"czloapi/controller/check_channel"
+	"czloapi/model"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	checkAuthenticityLock    sync.Mutex
+	checkAuthenticityRunning bool
+)
+
+func checkAllAuthenticity() error {
+	checkAuthenticityLock.Lock()
+	if checkAuthenticityRunning {
+		checkAuthenticityLock.Unlock()
+		return errors.New("检测已在运行中")
+	}
+	checkAuthenticityRunning = true
+	checkAuthenticityLock.Unlock()
+
+	channels, err := model.GetAllChannels()
+	if err != nil {
+		checkAuthenticityLock.Lock()
+		checkAuthenticityRunning = false
+		checkAuthenticityLock.Unlock()
+		return err
+	}
+
+	go func() {
+		defer func() {
+			checkAuthenticityLock.Lock()
+			checkAuthenticityRunning = false
+			checkAuthenticityLock.Unlock()
+		}()
+
+		var sendMessage string
+		for _, channel := range channels {
+			if channel.Status != config.ChannelStatusEnabled {
+				continue
+			}
+
+			records, err := check_channel.CheckAuthenticity(channel.Id)
+			if err != nil {
+				logger.SysError(fmt.Sprintf("failed to check authenticity for channel #%d: %s", channel.Id, err.Error()))
+			}
+			for _, record := range records {
+				if !record.Suspicious {
+					continue
+				}
+				sendMessage += fmt.Sprintf("- 渠道 %s - #%d : %s 得分 %d\n\n", utils.EscapeMarkdownText(channel.Name), channel.Id, utils.EscapeMarkdownText(record.Model), record.Score)
+			}
+		}
+
+		if sendMessage != "" {
+			notify.Send("渠道模型真实性检测", "以下渠道的模型可能被替换：\n\n"+sendMessage)
+		}
+	}()
+	return nil
+}
+
+// AutomaticallyCheckAuthenticity 定时进行模型真实性检测，frequency 单位为分钟
+func AutomaticallyCheckAuthenticity(frequency int) {
+	if frequency <= 0 || !config.IsMasterNode {
+		return
+	}
+
+	for {
+		time.Sleep(time.Duration(frequency) * time.Minute)
+		logger.SysLog("checking channel model authenticity")
+		if err := checkAllAuthenticity(); err != nil {
+			logger.SysError("failed to check channel model authenticity: " + err.Error())
+		}
+	}
+}
+
+func CheckAllAuthenticity(c *gin.Context) {
+	if err := checkAllAuthenticity(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+func CheckChannelAuthenticity(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	records, err := check_channel.CheckAuthenticity(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    records,
+	})
+}
+
+func GetAuthenticityList(c *gin.Context) {
+	var params model.SearchAuthenticityParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	records, err := model.GetAuthenticityList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    records,
+	})
+}
@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/common/logger"
+	"czloapi/controller/check_channel"
+	"czloapi/model"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	probeCapabilitiesLock    sync.Mutex
+	probeCapabilitiesRunning bool
+)
+
+func probeAllCapabilities() error {
+	probeCapabilitiesLock.Lock()
+	if probeCapabilitiesRunning {
+		probeCapabilitiesLock.Unlock()
+		return errors.New("探测已在运行中")
+	}
+	probeCapabilitiesRunning = true
+	probeCapabilitiesLock.Unlock()
+
+	defer func() {
+		probeCapabilitiesLock.Lock()
+		probeCapabilitiesRunning = false
+		probeCapabilitiesLock.Unlock()
+	}()
+
+	channels, err := model.GetAllChannels()
+	if err != nil {
+		return err
+	}
+
+	for _, channel := range channels {
+		if channel.Status != config.ChannelStatusEnabled {
+			continue
+		}
+		if err := check_channel.ProbeCapabilities(channel.Id); err != nil {
+			logger.SysError(fmt.Sprintf("failed to probe capabilities for channel #%d: %s", channel.Id, err.Error()))
+		}
+	}
+	return nil
+}
+
+// AutomaticallyProbeCapabilities 定时探测渠道能力，frequency 单位为分钟
+func AutomaticallyProbeCapabilities(frequency int) {
+	if frequency <= 0 || !config.IsMasterNode {
+		return
+	}
+
+	for {
+		time.Sleep(time.Duration(frequency) * time.Minute)
+		logger.SysLog("probing channel capabilities")
+		if err := probeAllCapabilities(); err != nil {
+			logger.SysError("failed to probe channel capabilities: " + err.Error())
+		}
+		logger.SysLog("channel capabilities probe finished")
+	}
+}
+
+func GetChannelsCapabilities(c *gin.Context) {
+	getChannelCapabilities(c, 0)
+}
+
+func GetChannelCapabilities(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	getChannelCapabilities(c, id)
+}
+
+func getChannelCapabilities(c *gin.Context, channelId int) {
+	capabilities, err := model.GetChannelCapabilities(channelId)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    capabilities,
+	})
+}
+
+func ProbeChannelCapabilities(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err = check_channel.ProbeCapabilities(id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	getChannelCapabilities(c, id)
+}
@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/common/logger"
+	"czloapi/common/notify"
+	"czloapi/common/utils"
+	"czloapi/model"
+	"czloapi/providers"
+	providersBase "czloapi/providers/base"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	detectModelDriftLock    sync.Mutex
+	detectModelDriftRunning bool
+)
+
+var errModelListNotSupported = errors.New("channel not implemented")
+
+// fetchUpstreamModels 调用渠道的模型列表接口
+func fetchUpstreamModels(channel *model.Channel) ([]string, error) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+
+	provider := providers.GetProvider(channel.SingleKey(), c)
+	if provider == nil {
+		return nil, errModelListNotSupported
+	}
+	modelProvider, ok := provider.(providersBase.ModelListInterface)
+	if !ok {
+		return nil, errModelListNotSupported
+	}
+
+	modelList, err := modelProvider.GetModelList()
+	if err != nil {
+		return nil, err
+	}
+	if len(modelList) == 0 {
+		return nil, errors.New("上游返回的模型列表为空")
+	}
+	return removeDuplicates(modelList), nil
+}
+
+// detectChannelModelDrift 检测单个渠道的模型变化，返回新下线的模型
+func detectChannelModelDrift(channel *model.Channel) ([]string, error) {
+	upstream, err := fetchUpstreamModels(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	added, removed := model.DiffChannelModels(channel, upstream)
+	return model.RecordModelDrift(channel, added, removed)
+}
+
+func detectAllModelDrift() error {
+	detectModelDriftLock.Lock()
+	if detectModelDriftRunning {
+		detectModelDriftLock.Unlock()
+		return errors.New("检测已在运行中")
+	}
+	detectModelDriftRunning = true
+	detectModelDriftLock.Unlock()
+
+	channels, err := model.GetAllChannels()
+	if err != nil {
+		detectModelDriftLock.Lock()
+		detectModelDriftRunning = false
+		detectModelDriftLock.Unlock()
+		return err
+	}
+
+	go func() {
+		defer func() {
+			detectModelDriftLock.Lock()
+			detectModelDriftRunning = false
+			detectModelDriftLock.Unlock()
+		}()
+
+		var sendMessage string
+		for _, channel := range channels {
+			if channel.Status != config.ChannelStatusEnabled || channel.ModelSyncPolicy == model.ModelSyncPolicyOff {
+				continue
+			}
+			time.Sleep(config.RequestInterval)
+
+			newlyRemoved, err := detectChannelModelDrift(channel)
+			if err != nil {
+				if !errors.Is(err, errModelListNotSupported) {
+					logger.SysError(fmt.Sprintf("failed to detect model drift for channel #%d: %s", channel.Id, err.Error()))
+				}
+				continue
+			}
+			if len(newlyRemoved) > 0 {
+				sendMessage += fmt.Sprintf("- 渠道 %s - #%d : %s\n\n", utils.EscapeMarkdownText(channel.Name), channel.Id, utils.EscapeMarkdownText(strings.Join(newlyRemoved, ", ")))
+			}
+		}
+
+		if sendMessage != "" {
+			notify.Send("上游模型已下线", "以下渠道正在提供的模型已不在上游模型列表中：\n\n"+sendMessage)
+		}
+	}()
+	return nil
+}
+
+// AutomaticallyDetectModelDrift 定时检测上游模型变化，frequency 单位为分钟
+func AutomaticallyDetectModelDrift(frequency int) {
+	if frequency <= 0 || !config.IsMasterNode {
+		return
+	}
+
+	for {
+		time.Sleep(time.Duration(frequency) * time.Minute)
+		logger.SysLog("detecting channel model drift")
+		if err := detectAllModelDrift(); err != nil {
+			logger.SysError("failed to detect channel model drift: " + err.Error())
+		}
+	}
+}
+
+func DetectAllModelDrift(c *gin.Context) {
+	if err := detectAllModelDrift(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+func DetectChannelModelDrift(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	channel, err := model.GetChannelById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if _, err = detectChannelModelDrift(channel); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+func GetModelDriftList(c *gin.Context) {
+	var params model.SearchModelDriftParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	drifts, err := model.GetModelDriftList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    drifts,
+	})
+}
+
+type ApplyModelDriftParams struct {
+	Add    bool `json:"add"`
+	Remove bool `json:"remove"`
+}
+
+func ApplyModelDrift(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	var params ApplyModelDriftParams
+	if err = c.ShouldBindJSON(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if !params.Add && !params.Remove {
+		common.AbortWithMessage(c, http.StatusOK, "请选择要应用的变化")
+		return
+	}
+
+	drift, err := model.GetModelDriftById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err = drift.Apply(params.Add, params.Remove); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    drift,
+	})
+}
+
+func DismissModelDrift(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	drift, err := model.GetModelDriftById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err = drift.Dismiss(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
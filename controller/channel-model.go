@@ -19,6 +19,13 @@ func GetModelList(c *gin.Context) {
 		})
 		return
 	}
+	if err = channel.RestoreMaskedKey(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
 	keys := strings.Split(channel.Key, "\n")
 	channel.Key = keys[0]
 
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"bytes"
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/model"
+	"czloapi/providers"
+	providersBase "czloapi/providers/base"
+	"czloapi/types"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
+)
+
+var errOverrideDryRun = errors.New("override dry run")
+
+type OverrideDryRunParams struct {
+	// 示例请求，格式与 /v1/chat/completions 相同
+	Request json.RawMessage `json:"request" binding:"required"`
+	// 不为空时使用这里的规则代替渠道已保存的规则，便于保存前验证
+	OverrideRules *datatypes.JSONSlice[model.OverrideRule] `json:"override_rules"`
+}
+
+type OverrideDryRunResult struct {
+	Model   string            `json:"model"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+// DryRunChannelOverride 使用渠道的请求构建流程处理示例请求，返回应用覆盖规则后的上游请求，不会实际发送
+func DryRunChannelOverride(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	var params OverrideDryRunParams
+	if err = c.ShouldBindJSON(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	channel, err := model.GetChannelById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if params.OverrideRules != nil {
+		channel.OverrideRules = params.OverrideRules
+		if err = channel.ValidateOverrideRules(); err != nil {
+			common.APIRespondWithError(c, http.StatusOK, err)
+			return
+		}
+	}
+
+	result, err := dryRunOverride(channel, params.Request)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    result,
+	})
+}
+
+func dryRunOverride(channel *model.Channel, rawRequest []byte) (*OverrideDryRunResult, error) {
+	var request types.ChatCompletionRequest
+	if err := json.Unmarshal(rawRequest, &request); err != nil {
+		return nil, err
+	}
+	if request.Model == "" {
+		return nil, errors.New("示例请求没有指定模型")
+	}
+
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(rawRequest))
+	ctx.Request.Header.Set("Content-Type", "application/json")
+	ctx.Set(config.GinRequestBodyKey, rawRequest)
+
+	provider := providers.GetProvider(channel, ctx)
+	if provider == nil {
+		return nil, errors.New("channel not implemented")
+	}
+	chatProvider, ok := provider.(providersBase.ChatInterface)
+	if !ok {
+		return nil, errors.New("channel not implemented")
+	}
+	provider.SetUsage(&types.Usage{})
+	provider.SetOriginalModel(request.Model)
+
+	modelName, err := provider.ModelMappingHandler(request.Model)
+	if err != nil {
+		return nil, err
+	}
+	request.Model = modelName
+
+	// 在创建请求时截获最终的请求头和请求体，并中止发送
+	var result *OverrideDryRunResult
+	requester := provider.GetRequester()
+	override := requester.RequestOverride
+	requester.RequestOverride = func(header http.Header, body any) (any, error) {
+		if override != nil {
+			var err error
+			if body, err = override(header, body); err != nil {
+				return nil, err
+			}
+		}
+
+		result = &OverrideDryRunResult{
+			Model:   modelName,
+			Headers: maskDryRunHeaders(header),
+		}
+		switch v := body.(type) {
+		case []byte:
+			result.Body = v
+		default:
+			result.Body, _ = json.Marshal(v)
+		}
+		return nil, errOverrideDryRun
+	}
+
+	var errWithCode *types.OpenAIErrorWithStatusCode
+	if request.Stream {
+		_, errWithCode = chatProvider.CreateChatCompletionStream(&request)
+	} else {
+		_, errWithCode = chatProvider.CreateChatCompletion(&request)
+	}
+	if result == nil {
+		if errWithCode != nil {
+			return nil, errors.New(errWithCode.Message)
+		}
+		return nil, errors.New("未能获取上游请求")
+	}
+	return result, nil
+}
+
+// maskDryRunHeaders 隐藏包含密钥的请求头
+func maskDryRunHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name := range header {
+		value := header.Get(name)
+		lower := strings.ToLower(name)
+		if strings.Contains(lower, "auth") || strings.Contains(lower, "key") || strings.Contains(lower, "token") || strings.Contains(lower, "secret") {
+			value = "***"
+		}
+		headers[name] = value
+	}
+	return headers
+}
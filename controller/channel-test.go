@@ -65,7 +65,7 @@ func testChannel(channel *model.Channel, testModel string) (openaiErr *types.Ope
 	c.Request = req
 
 	// 获取并验证provider
-	provider := providers.GetProvider(channel, c)
+	provider := providers.GetProvider(channel.SingleKey(), c)
 	if provider == nil {
 		return nil, errors.New("channel not implemented")
 	}
@@ -24,6 +24,9 @@ func GetChannelsList(c *gin.Context) {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
 	}
+	for _, channel := range *channels.Data {
+		channel.MaskCredentials()
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -48,6 +51,7 @@ func GetChannel(c *gin.Context) {
 		})
 		return
 	}
+	channel.MaskCredentials()
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -71,8 +75,47 @@ func AddChannel(c *gin.Context) {
 		})
 		return
 	}
+	if err = channel.ValidateRollout(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err = channel.ValidateSchedules(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err = channel.ValidateKeyPool(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err = channel.ValidateModelSyncPolicy(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err = channel.ValidateOverrideRules(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
 	channel.CreatedTime = utils.GetTimestamp()
 	keys := strings.Split(channel.Key, "\n")
+	// 启用账号池时多个 key 保存在同一个渠道中
+	if channel.GetKeyPool() != nil {
+		keys = []string{channel.Key}
+	}
 
 	baseUrls := []string{}
 	if channel.BaseURL != nil && *channel.BaseURL != "" {
@@ -176,6 +219,48 @@ func UpdateChannel(c *gin.Context) {
 		})
 		return
 	}
+	if err = channel.ValidateRollout(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err = channel.ValidateSchedules(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err = channel.ValidateKeyPool(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err = channel.ValidateModelSyncPolicy(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err = channel.ValidateOverrideRules(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err = channel.RestoreMaskedKey(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
 	if channel.Models == "" {
 		err = channel.Update(false)
 	} else {
@@ -188,6 +273,7 @@ func UpdateChannel(c *gin.Context) {
 		})
 		return
 	}
+	channel.MaskCredentials()
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -0,0 +1,298 @@
+package check_channel
+
+import (
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/common/utils"
+	"czloapi/model"
+	"czloapi/types"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// goldenPrompt 答案确定的标准问题，被替换成能力较弱的模型时通常无法答对
+type goldenPrompt struct {
+	Name   string
+	Prompt string
+	Weight int
+	Expect func(content string) bool
+}
+
+var goldenPrompts = []goldenPrompt{
+	{
+		Name:   "多位数乘法",
+		Prompt: "Compute 4817 * 263. Reply with the number only, no other text.",
+		Weight: 2,
+		Expect: func(content string) bool {
+			return strings.Contains(strings.NewReplacer(",", "", " ", "").Replace(content), "1266871")
+		},
+	},
+	{
+		Name:   "陷阱推理",
+		Prompt: "A bat and a ball cost $1.10 in total. The bat costs $1.00 more than the ball. How much does the ball cost in cents? Reply with the number only.",
+		Weight: 1,
+		Expect: func(content string) bool {
+			return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(content), "cents")) == "5"
+		},
+	},
+	{
+		Name:   "指令遵循",
+		Prompt: `Return a JSON object with keys "a" and "b", where "a" is the capital city of Australia and "b" is the chemical symbol of gold. Output the JSON only, without markdown.`,
+		Weight: 1,
+		Expect: func(content string) bool {
+			var answer struct {
+				A string `json:"a"`
+				B string `json:"b"`
+			}
+			if err := json.Unmarshal([]byte(content), &answer); err != nil {
+				return false
+			}
+			return strings.EqualFold(answer.A, "Canberra") && answer.B == "Au"
+		},
+	},
+}
+
+// 各模型系列的知识截止时间，按前缀匹配，较长的前缀需要放在前面
+var knowledgeCutoffs = []struct {
+	Prefix string
+	Cutoff string
+}{
+	{"gpt-4o-mini", "2023-10"},
+	{"gpt-4o", "2023-10"},
+	{"gpt-4.1", "2024-06"},
+	{"gpt-4-turbo", "2023-12"},
+	{"gpt-5", "2024-09"},
+	{"o3", "2024-06"},
+	{"o4-mini", "2024-06"},
+	{"claude-3-5-sonnet", "2024-04"},
+	{"claude-3-7-sonnet", "2024-10"},
+	{"claude-sonnet-4", "2025-03"},
+	{"claude-opus-4", "2025-03"},
+	{"gemini-1.5", "2023-11"},
+	{"gemini-2.0", "2024-08"},
+	{"gemini-2.5", "2025-01"},
+}
+
+// 知识截止时间允许的误差，模型自述的时间本身不够准确
+const knowledgeCutoffToleranceMonths = 6
+
+var cutoffPattern = regexp.MustCompile(`(\d{4})-(\d{1,2})`)
+
+// 用于 tokenizer 计数比对的文本，混合了中英文、数字和代码，不同 tokenizer 的计数差异较大
+const tokenizerProbeText = "The quick brown fox jumps over the lazy dog. 敏捷的棕色狐狸跳过了懒狗。" +
+	"func main() { fmt.Println(\"こんにちは世界\", 3.14159, 0xDEADBEEF) } // 🦊🐶"
+
+// CheckAuthenticity 对渠道的每个模型进行真实性检测并保存结果，基础请求失败的模型不记录
+func CheckAuthenticity(channelId int) ([]*model.ChannelAuthenticity, error) {
+	channel, err := model.GetChannelById(channelId)
+	if err != nil {
+		return nil, err
+	}
+
+	models := channel.CapabilityModels()
+	if len(models) == 0 {
+		return nil, errors.New("渠道没有可检测的模型")
+	}
+
+	ck, err := CreateCheckChannel(channelId, strings.Join(models, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*model.ChannelAuthenticity, 0)
+	for _, modelName := range ck.Models {
+		checks := ck.checkModelAuthenticity(modelName)
+		if len(checks) == 0 {
+			continue
+		}
+		record, err := model.RecordAuthenticity(channel, modelName, checks)
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+		time.Sleep(config.RequestInterval)
+	}
+	return records, nil
+}
+
+func (c *CheckChannel) checkModelAuthenticity(modelName string) []model.AuthenticityCheck {
+	baseProcess := CreateCheckBaseProcess(modelName)
+	baseRequest := baseProcess.GetRequest()
+	baseResponse, err := c.ChatInterface.CreateChatCompletion(baseRequest)
+	if err != nil {
+		return nil
+	}
+
+	checks := make([]model.AuthenticityCheck, 0)
+	for _, result := range baseProcess.Check(baseRequest, baseResponse, nil) {
+		if result.Name == "模型检测" {
+			checks = append(checks, model.AuthenticityCheck{
+				Name:   "响应模型",
+				Passed: result.Status == CheckStatusSuccess,
+				Weight: 1,
+				Remark: result.Remark,
+			})
+		}
+	}
+
+	for _, golden := range goldenPrompts {
+		content, err := c.ask(modelName, golden.Prompt)
+		if err != nil {
+			continue
+		}
+		checks = append(checks, model.AuthenticityCheck{
+			Name:   golden.Name,
+			Passed: golden.Expect(content),
+			Weight: golden.Weight,
+			Remark: content,
+		})
+	}
+
+	if check := c.checkIdentity(modelName); check != nil {
+		checks = append(checks, *check)
+	}
+	if check := c.checkKnowledgeCutoff(modelName); check != nil {
+		checks = append(checks, *check)
+	}
+	if check := c.checkTokenizer(modelName); check != nil {
+		checks = append(checks, *check)
+	}
+
+	return checks
+}
+
+func (c *CheckChannel) ask(modelName, prompt string) (string, error) {
+	resp, err := c.ChatInterface.CreateChatCompletion(&types.ChatCompletionRequest{
+		Model:       modelName,
+		MaxTokens:   64,
+		Temperature: utils.GetPointer(0.0),
+		Messages: []types.ChatCompletionMessage{
+			{
+				Role:    types.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("未返回响应数据")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.StringContent()), nil
+}
+
+// checkIdentity 检查模型自述的开发公司，仅支持能识别出系列的模型
+func (c *CheckChannel) checkIdentity(modelName string) *model.AuthenticityCheck {
+	var company string
+	switch getChannelTypeByModelName(modelName) {
+	case config.ChannelTypeOpenAI:
+		company = "openai"
+	case config.ChannelTypeAnthropic:
+		company = "anthropic"
+	case config.ChannelTypeGemini:
+		company = "google"
+	default:
+		return nil
+	}
+
+	content, err := c.ask(modelName, "Which company developed you? Reply with the company name only.")
+	if err != nil {
+		return nil
+	}
+	return &model.AuthenticityCheck{
+		Name:   "身份自述",
+		Passed: strings.Contains(strings.ToLower(content), company),
+		Weight: 1,
+		Remark: content,
+	}
+}
+
+func getKnowledgeCutoff(modelName string) string {
+	for _, item := range knowledgeCutoffs {
+		if strings.HasPrefix(modelName, item.Prefix) {
+			return item.Cutoff
+		}
+	}
+	return ""
+}
+
+// parseCutoffMonths 将 YYYY-MM 转换为月数
+func parseCutoffMonths(value string) (int, bool) {
+	match := cutoffPattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, false
+	}
+	year, _ := strconv.Atoi(match[1])
+	month, _ := strconv.Atoi(match[2])
+	if month < 1 || month > 12 {
+		return 0, false
+	}
+	return year*12 + month - 1, true
+}
+
+func (c *CheckChannel) checkKnowledgeCutoff(modelName string) *model.AuthenticityCheck {
+	expected := getKnowledgeCutoff(modelName)
+	if expected == "" {
+		return nil
+	}
+
+	content, err := c.ask(modelName, "What is your knowledge cutoff date? Reply in YYYY-MM format only.")
+	if err != nil {
+		return nil
+	}
+
+	check := &model.AuthenticityCheck{
+		Name:   "知识截止时间",
+		Weight: 1,
+		Remark: fmt.Sprintf("期望 %s，返回 %s", expected, content),
+	}
+	expectedMonths, _ := parseCutoffMonths(expected)
+	if reported, ok := parseCutoffMonths(content); ok {
+		diff := reported - expectedMonths
+		check.Passed = diff >= -knowledgeCutoffToleranceMonths && diff <= knowledgeCutoffToleranceMonths
+	}
+	return check
+}
+
+// checkTokenizer 比较上游返回的输入 tokens 与本地 tokenizer 的计数，仅支持本地有对应 tokenizer 的 OpenAI 模型
+func (c *CheckChannel) checkTokenizer(modelName string) *model.AuthenticityCheck {
+	if getChannelTypeByModelName(modelName) != config.ChannelTypeOpenAI || config.DisableTokenEncoders || config.ApproximateTokenEnabled {
+		return nil
+	}
+
+	messages := []types.ChatCompletionMessage{
+		{
+			Role:    types.ChatMessageRoleUser,
+			Content: tokenizerProbeText,
+		},
+	}
+	resp, err := c.ChatInterface.CreateChatCompletion(&types.ChatCompletionRequest{
+		Model:     modelName,
+		MaxTokens: 1,
+		Messages:  messages,
+	})
+	if err != nil || resp.Usage == nil || resp.Usage.PromptTokens == 0 {
+		return nil
+	}
+
+	expected := common.CountTokenMessages(messages, modelName, config.PreCostDefault)
+	return &model.AuthenticityCheck{
+		Name:   "tokenizer 计数",
+		Passed: tokenCountMatches(expected, resp.Usage.PromptTokens),
+		Weight: 2,
+		Remark: fmt.Sprintf("本地计数 %d，上游返回 %d", expected, resp.Usage.PromptTokens),
+	}
+}
+
+// tokenCountMatches 允许 10% 或 3 个 tokens 的误差，兼容不同的消息格式开销
+func tokenCountMatches(expected, actual int) bool {
+	tolerance := max(expected/10, 3)
+	diff := actual - expected
+	return diff >= -tolerance && diff <= tolerance
+}
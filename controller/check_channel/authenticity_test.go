@@ -0,0 +1,45 @@
+package check_channel
+
+import "testing"
+
+func TestGoldenPromptsExpect(t *testing.T) {
+	answers := map[string]string{
+		"多位数乘法": "1,266,871",
+		"陷阱推理":  "5 cents",
+		"指令遵循":  `{"a": "Canberra", "b": "Au"}`,
+	}
+	for _, golden := range goldenPrompts {
+		if !golden.Expect(answers[golden.Name]) {
+			t.Fatalf("%s should accept %q", golden.Name, answers[golden.Name])
+		}
+		if golden.Expect("10") {
+			t.Fatalf("%s should reject wrong answer", golden.Name)
+		}
+	}
+}
+
+func TestKnowledgeCutoff(t *testing.T) {
+	if cutoff := getKnowledgeCutoff("gpt-4o-mini-2024-07-18"); cutoff != "2023-10" {
+		t.Fatalf("unexpected cutoff %s", cutoff)
+	}
+	if cutoff := getKnowledgeCutoff("unknown-model"); cutoff != "" {
+		t.Fatalf("unexpected cutoff %s", cutoff)
+	}
+
+	months, ok := parseCutoffMonths("My knowledge cutoff is 2024-04.")
+	if !ok || months != 2024*12+3 {
+		t.Fatalf("unexpected months %d", months)
+	}
+	if _, ok = parseCutoffMonths("2024-13"); ok {
+		t.Fatal("invalid month should not parse")
+	}
+}
+
+func TestTokenCountMatches(t *testing.T) {
+	if !tokenCountMatches(100, 108) || !tokenCountMatches(10, 13) {
+		t.Fatal("counts within tolerance should match")
+	}
+	if tokenCountMatches(100, 130) {
+		t.Fatal("counts out of tolerance should not match")
+	}
+}
@@ -0,0 +1,158 @@
+package check_channel
+
+import (
+	"czloapi/common/config"
+	"czloapi/common/utils"
+	"czloapi/model"
+	"czloapi/types"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ProbeCapabilities 探测渠道全部模型的能力并保存，基础请求失败的模型不记录结果
+func ProbeCapabilities(channelId int) error {
+	channel, err := model.GetChannelById(channelId)
+	if err != nil {
+		return err
+	}
+
+	models := channel.CapabilityModels()
+	if len(models) == 0 {
+		return errors.New("渠道没有可探测的模型")
+	}
+
+	ck, err := CreateCheckChannel(channelId, strings.Join(models, ","))
+	if err != nil {
+		return err
+	}
+
+	for _, modelName := range ck.Models {
+		results, ok := ck.probeModel(modelName)
+		if !ok {
+			continue
+		}
+		if err = model.SaveCapabilityProbe(channelId, modelName, results); err != nil {
+			return err
+		}
+		time.Sleep(config.RequestInterval)
+	}
+	return nil
+}
+
+func (c *CheckChannel) probeModel(modelName string) (map[string]*bool, bool) {
+	if _, err := c.ChatInterface.CreateChatCompletion(CreateCheckBaseProcess(modelName).GetRequest()); err != nil {
+		return nil, false
+	}
+
+	return map[string]*bool{
+		model.CapabilityTools:      c.probeProcess(CreateCheckToolProcess(modelName)),
+		model.CapabilityJsonSchema: c.probeProcess(CreateCheckJsonFormatProcess(modelName)),
+		model.CapabilityVision:     c.probeVision(modelName),
+		model.CapabilityStreaming:  c.probeStreaming(modelName),
+		model.CapabilityReasoning:  c.probeReasoning(modelName),
+	}, true
+}
+
+// probeResult 上游明确拒绝请求参数时认为不支持，其他错误（超时、限流等）无法判断
+// 不支持的渠道会被请求过滤掉，因此只有明确的 4xx 拒绝才记录为不支持
+func probeResult(err *types.OpenAIErrorWithStatusCode) *bool {
+	switch err.StatusCode {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity:
+		return utils.GetPointer(false)
+	}
+	return nil
+}
+
+// probeProcess 请求被接受但结果不符合预期（如模型直接用文本回答而没有调用工具）时由模型自行决定，无法判断
+func (c *CheckChannel) probeProcess(process CheckProcess) *bool {
+	req := process.GetRequest()
+	resp, err := c.ChatInterface.CreateChatCompletion(req)
+	if err != nil {
+		return probeResult(err)
+	}
+
+	for _, result := range process.Check(req, resp, nil) {
+		if result.Status != CheckStatusSuccess {
+			return nil
+		}
+	}
+	return utils.GetPointer(true)
+}
+
+// probeVision 使用 data url 发送图片，不依赖上游能否访问本站地址
+func (c *CheckChannel) probeVision(modelName string) *bool {
+	process := &CheckImgProcess{
+		ModelName: modelName,
+		ImageUrl:  "data:image/png;base64," + base64.StdEncoding.EncodeToString(checkImage),
+	}
+	resp, err := c.ChatInterface.CreateChatCompletion(process.GetRequest())
+	if err != nil {
+		return probeResult(err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil
+	}
+
+	switch strings.TrimSpace(resp.Choices[0].Message.StringContent()) {
+	case "1":
+		return utils.GetPointer(true)
+	case "0":
+		return utils.GetPointer(false)
+	}
+	return nil
+}
+
+// probeStreaming 收到数据时认为支持，上游没有返回任何内容就结束时无法判断
+func (c *CheckChannel) probeStreaming(modelName string) *bool {
+	req := CreateCheckBaseProcess(modelName).GetRequest()
+	req.Stream = true
+	stream, err := c.ChatInterface.CreateChatCompletionStream(req)
+	if err != nil {
+		return probeResult(err)
+	}
+	defer stream.Close()
+
+	dataChan, errChan := stream.Recv()
+	select {
+	case _, ok := <-dataChan:
+		if ok {
+			return utils.GetPointer(true)
+		}
+	case <-errChan:
+	}
+	return nil
+}
+
+// probeReasoning 返回了思考内容或思考 tokens 时认为支持
+// 非思考模型通常会忽略思考参数，这类请求不会失败，因此没有思考输出时不判定为不支持
+func (c *CheckChannel) probeReasoning(modelName string) *bool {
+	effort := "low"
+	req := &types.ChatCompletionRequest{
+		Model:               modelName,
+		MaxCompletionTokens: 1024,
+		ReasoningEffort:     &effort,
+		Messages: []types.ChatCompletionMessage{
+			{
+				Role:    types.ChatMessageRoleUser,
+				Content: "1 + 1 = ?",
+			},
+		},
+	}
+	resp, err := c.ChatInterface.CreateChatCompletion(req)
+	if err != nil {
+		return probeResult(err)
+	}
+
+	if resp.Usage != nil && resp.Usage.CompletionTokensDetails.ReasoningTokens > 0 {
+		return utils.GetPointer(true)
+	}
+	for _, choice := range resp.Choices {
+		if choice.Message.ReasoningContent != "" || choice.Message.Reasoning != "" {
+			return utils.GetPointer(true)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package check_channel
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"czloapi/common/requester"
+	providers_base "czloapi/providers/base"
+	"czloapi/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProbeChat struct {
+	providers_base.ChatInterface
+	response  *types.ChatCompletionResponse
+	err       *types.OpenAIErrorWithStatusCode
+	streamErr error
+}
+
+func (f *fakeProbeChat) CreateChatCompletion(_ *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	return f.response, f.err
+}
+
+func (f *fakeProbeChat) CreateChatCompletionStream(_ *types.ChatCompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &fakeProbeStream{err: f.streamErr}, nil
+}
+
+// fakeProbeStream 不返回任何数据，直接以 err 结束
+type fakeProbeStream struct {
+	err error
+}
+
+func (f *fakeProbeStream) Recv() (<-chan string, <-chan error) {
+	errChan := make(chan error, 1)
+	errChan <- f.err
+	return make(chan string), errChan
+}
+
+func (f *fakeProbeStream) Close() {}
+
+func TestProbeOnlyExplicitRejectionIsUnsupported(t *testing.T) {
+	// 模型用文本回答而没有调用工具
+	textAnswer := &types.ChatCompletionResponse{
+		Choices: []types.ChatCompletionChoice{
+			{Message: types.ChatCompletionMessage{Role: types.ChatMessageRoleAssistant, Content: "36, 60"}},
+		},
+	}
+	c := &CheckChannel{ChatInterface: &fakeProbeChat{response: textAnswer}}
+	assert.Nil(t, c.probeProcess(CreateCheckToolProcess("gpt-4o")))
+
+	c = &CheckChannel{ChatInterface: &fakeProbeChat{err: &types.OpenAIErrorWithStatusCode{StatusCode: http.StatusBadRequest}}}
+	result := c.probeProcess(CreateCheckToolProcess("gpt-4o"))
+	assert.NotNil(t, result)
+	assert.False(t, *result)
+
+	c = &CheckChannel{ChatInterface: &fakeProbeChat{err: &types.OpenAIErrorWithStatusCode{StatusCode: http.StatusTooManyRequests}}}
+	assert.Nil(t, c.probeProcess(CreateCheckToolProcess("gpt-4o")))
+}
+
+func TestProbeStreamingEmptyStreamIsUnknown(t *testing.T) {
+	c := &CheckChannel{ChatInterface: &fakeProbeChat{streamErr: io.EOF}}
+	assert.Nil(t, c.probeStreaming("gpt-4o"))
+
+	c = &CheckChannel{ChatInterface: &fakeProbeChat{err: &types.OpenAIErrorWithStatusCode{StatusCode: http.StatusBadRequest}}}
+	result := c.probeStreaming("gpt-4o")
+	assert.NotNil(t, result)
+	assert.False(t, *result)
+}
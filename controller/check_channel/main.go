@@ -70,7 +70,7 @@ func CreateCheckChannel(channelId int, models string) (*CheckChannel, error) {
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
 
-	provider := providers.GetProvider(channel, c)
+	provider := providers.GetProvider(channel.SingleKey(), c)
 	if provider == nil {
 		return nil, errors.New("channel not implemented")
 	}
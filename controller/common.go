@@ -30,6 +30,11 @@ func ShouldDisableChannel(channelType int, err *types.OpenAIErrorWithStatusCode)
 		return false
 	}
 
+	// 匹配到错误策略时由策略决定
+	if policy := model.GlobalErrorPolicy.Match(channelType, err); policy != nil {
+		return policy.Action == model.ErrorPolicyActionDisable
+	}
+
 	// 状态码检查
 	if err.StatusCode == http.StatusUnauthorized {
 		return true
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"czloapi/common"
+	"czloapi/common/utils"
+	"czloapi/model"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetAllErrorPolicies(c *gin.Context) {
+	policies, err := model.GetAllErrorPolicies()
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    policies,
+	})
+}
+
+func GetErrorPolicy(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	policy, err := model.GetErrorPolicyById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    policy,
+	})
+}
+
+func CreateErrorPolicy(c *gin.Context) {
+	policy := model.ErrorPolicy{}
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	policy.Id = 0
+	policy.CreatedTime = utils.GetTimestamp()
+	policy.UpdatedTime = policy.CreatedTime
+	if err := model.CreateErrorPolicy(&policy); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	model.GlobalErrorPolicy.Load()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    policy,
+	})
+}
+
+func UpdateErrorPolicy(c *gin.Context) {
+	policy := model.ErrorPolicy{}
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	oldPolicy, err := model.GetErrorPolicyById(policy.Id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if policy.Enabled == nil {
+		policy.Enabled = oldPolicy.Enabled
+	}
+
+	policy.UpdatedTime = utils.GetTimestamp()
+	if err := model.UpdateErrorPolicy(&policy); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	model.GlobalErrorPolicy.Load()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    policy,
+	})
+}
+
+func DeleteErrorPolicy(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err = model.DeleteErrorPolicy(id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	model.GlobalErrorPolicy.Load()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
@@ -16,6 +16,9 @@ func GetIPProxyList(c *gin.Context) {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
 	}
+	for _, proxy := range proxies {
+		proxy.MaskCredentials()
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -37,6 +40,7 @@ func GetIPProxy(c *gin.Context) {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
 	}
+	proxy.MaskCredentials()
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -57,6 +61,7 @@ func AddIPProxy(c *gin.Context) {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
 	}
+	proxy.MaskCredentials()
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -82,6 +87,7 @@ func UpdateIPProxy(c *gin.Context) {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
 	}
+	proxy.MaskCredentials()
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -129,6 +135,7 @@ func TestIPProxyLatency(c *gin.Context) {
 		return
 	}
 
+	// 必须使用未脱敏的代理地址测试，脱敏后的密码无法通过认证
 	latency, err := proxy.TestLatency()
 	if err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
@@ -450,6 +450,10 @@ func validateTokenSetting(setting *model.TokenSetting) error {
 		}
 	}
 
+	if err := model.ValidateModelFallbacks(setting.ModelFallbacks); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -12,6 +12,8 @@ import (
 )
 
 func InitCron() {
+	initChannelScheduleCron()
+
 	if !config.IsMasterNode {
 		logger.SysLog("Cron is disabled on slave node")
 		return
@@ -104,3 +106,15 @@ func InitCron() {
 		return
 	}
 }
+
+// initChannelScheduleCron 渠道时间窗口作用于每个节点内存中的渠道数据，从节点也需要执行
+func initChannelScheduleCron() {
+	err := scheduler.Manager.AddJob(
+		"refresh_channel_schedules",
+		gocron.CronJob("* * * * *", false),
+		gocron.NewTask(model.ChannelGroup.RefreshSchedules),
+	)
+	if err != nil {
+		logger.SysError("Cron job error: " + err.Error())
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"czloapi/common"
 	"czloapi/common/cache"
 	"czloapi/common/config"
+	"czloapi/common/credential"
 	"czloapi/common/logger"
 	"czloapi/common/notify"
 	"czloapi/common/oidc"
@@ -54,14 +55,22 @@ func main() {
 		logger.FatalLog("failed to initialize user token: " + err.Error())
 	}
 
+	credential.Init()
+
 	// Initialize SQL Database
 	model.SetupDB()
 	defer model.CloseDB()
+	if *cli.ReEncrypt {
+		cli.ReEncryptCredentials()
+		return
+	}
 	// Initialize Redis
 	redis.InitRedisClient()
 	cache.InitCacheManager()
 	// Initialize options
 	model.InitOptionMap()
+	model.InitChannelStateSync()
+	credential.WatchSecrets(model.ChannelGroup.Load)
 	// Initialize oidc
 	oidc.InitOIDCConfig()
 	// Initialize wenauthn
@@ -113,6 +122,9 @@ func initMemoryCache() {
 func initSync() {
 	// go controller.AutomaticallyUpdateChannels(viper.GetInt("channel.update_frequency"))
 	go controller.AutomaticallyTestChannels(viper.GetInt("channel.test_frequency"))
+	go controller.AutomaticallyDetectModelDrift(viper.GetInt("channel.model_sync_frequency"))
+	go controller.AutomaticallyProbeCapabilities(viper.GetInt("channel.capability_probe_frequency"))
+	go controller.AutomaticallyCheckAuthenticity(viper.GetInt("channel.authenticity_check_frequency"))
 }
 
 func initHttpServer() {
@@ -159,6 +171,7 @@ func SyncChannelCache(frequency int) {
 		time.Sleep(time.Duration(frequency) * time.Second)
 		logger.SysLog("syncing channels from database")
 		model.ChannelGroup.Load()
+		model.GlobalErrorPolicy.Load()
 		model.GlobalUserGroupRatio.Load()
 		model.PricingInstance.Init()
 		model.ModelOwnedBysInstance.Load()
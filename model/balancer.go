@@ -2,11 +2,13 @@ package model
 
 import (
 	"czloapi/common/config"
+	"czloapi/common/credential"
 	"czloapi/common/logger"
 	"czloapi/common/utils"
 	"errors"
 	"fmt"
 	"math/rand"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -17,6 +19,16 @@ type ChannelChoice struct {
 	Channel       *Channel
 	CooldownsTime int64
 	Disable       bool
+	Schedule      ChannelScheduleState // 加载时按时间窗口计算的状态
+	SecretError   string               // 密钥引用解析失败的原因，不为空时渠道不可用
+}
+
+// weight 渠道的有效权重，时间窗口的设置优先
+func (choice *ChannelChoice) weight() uint {
+	if choice.Schedule.Weight != nil {
+		return *choice.Schedule.Weight
+	}
+	return *choice.Channel.Weight
 }
 
 type ChannelsChooser struct {
@@ -25,6 +37,11 @@ type ChannelsChooser struct {
 	Rule      map[string]map[string][][]int // group -> model -> priority -> channelIds
 	Match     []string
 	Cooldowns sync.Map
+	Health    sync.Map // channelId:model -> *ChannelHealth
+	Breakers  sync.Map // channelId:model -> *CircuitBreaker
+	Shadows   sync.Map // channelId:model -> *ShadowStats
+	// channelId:model -> *ChannelCapability
+	Capabilities sync.Map
 
 	ModelGroup map[string]map[string]bool
 }
@@ -61,12 +78,19 @@ func init() {
 		ticker := time.NewTicker(1 * time.Hour)
 		for range ticker.C {
 			ChannelGroup.CleanupExpiredCooldowns()
+			ChannelGroup.CleanupStaleHealth()
+			ChannelGroup.CleanupClosedBreakers()
 		}
 	}()
 }
 
 func (cc *ChannelsChooser) SetCooldowns(channelId int, modelName string) bool {
-	if channelId == 0 || modelName == "" || config.RetryCooldownSeconds == 0 {
+	return cc.SetCooldownsFor(channelId, modelName, config.RetryCooldownSeconds)
+}
+
+// SetCooldownsFor 冷却渠道指定的秒数
+func (cc *ChannelsChooser) SetCooldownsFor(channelId int, modelName string, seconds int) bool {
+	if channelId == 0 || modelName == "" || seconds <= 0 {
 		return false
 	}
 
@@ -78,7 +102,9 @@ func (cc *ChannelsChooser) SetCooldowns(channelId int, modelName string) bool {
 		return true
 	}
 
-	cc.Cooldowns.LoadOrStore(key, nowTime+int64(config.RetryCooldownSeconds))
+	until := nowTime + int64(seconds)
+	cc.storeCooldown(key, until)
+	cc.shareCooldown(key, until)
 	return true
 }
 
@@ -129,61 +155,129 @@ func (cc *ChannelsChooser) ChangeStatus(channelId int, status bool) {
 	} else {
 		cc.Disable(channelId)
 	}
+	cc.shareStatus(channelId, status)
 }
 
-func (cc *ChannelsChooser) balancer(channelIds []int, filters []ChannelsFilterFunc, modelName string) *Channel {
-	totalWeight := 0
+// selectable 判断渠道当前是否可被选中，saturated 表示仅因达到上游限制而不可用
+func (cc *ChannelsChooser) selectable(channelId int, filters []ChannelsFilterFunc, modelName string) (choice *ChannelChoice, saturated bool) {
+	choice, ok := cc.Channels[channelId]
+	if !ok || choice.Disable || choice.Schedule.Disabled || choice.SecretError != "" {
+		return nil, false
+	}
 
-	validChannels := make([]*ChannelChoice, 0, len(channelIds))
-	for _, channelId := range channelIds {
-		choice, ok := cc.Channels[channelId]
-		if !ok || choice.Disable {
-			continue
-		}
+	if cc.IsInCooldown(channelId, modelName) || !cc.BreakerAllow(channelId, modelName) {
+		return nil, false
+	}
 
-		if cc.IsInCooldown(channelId, modelName) {
-			continue
+	for _, filter := range filters {
+		if filter(channelId, choice) {
+			return nil, false
 		}
+	}
 
-		isSkip := false
-		for _, filter := range filters {
-			if filter(channelId, choice) {
-				isSkip = true
-				break
-			}
-		}
-		if isSkip {
+	if GlobalChannelLimiter.IsSaturated(choice.Channel, modelName) {
+		return nil, true
+	}
+
+	return choice, false
+}
+
+// balancer 在同一优先级内选择渠道并占用限制名额，saturated 表示存在仅因达到上游限制而被跳过的渠道
+// 选中的渠道占用名额失败时视为饱和，在剩余渠道中重新选择
+func (cc *ChannelsChooser) balancer(channelIds []int, filters []ChannelsFilterFunc, modelName string, balanceMode string) (channel *Channel, release func(), saturated bool) {
+	validChannels := make([]*ChannelChoice, 0, len(channelIds))
+	for _, channelId := range channelIds {
+		choice, isSaturated := cc.selectable(channelId, filters, modelName)
+		if choice == nil {
+			saturated = saturated || isSaturated
 			continue
 		}
 
-		weight := int(*choice.Channel.Weight)
-		totalWeight += weight
 		validChannels = append(validChannels, choice)
 	}
 
-	if len(validChannels) == 0 {
-		return nil
+	for len(validChannels) > 0 {
+		index := cc.choose(validChannels, modelName, balanceMode)
+		choice := validChannels[index]
+		if release, ok := GlobalChannelLimiter.TryAcquire(choice.Channel, modelName); ok {
+			return choice.Channel, release, false
+		}
+
+		saturated = true
+		validChannels = slices.Delete(validChannels, index, index+1)
 	}
 
+	return nil, nil, saturated
+}
+
+// choose 按负载均衡模式从可用渠道中选择一个，返回其下标
+func (cc *ChannelsChooser) choose(validChannels []*ChannelChoice, modelName string, balanceMode string) int {
 	if len(validChannels) == 1 {
-		return validChannels[0].Channel
+		return 0
 	}
 
-	choiceWeight := rand.Intn(totalWeight)
+	if balanceMode == BalanceModeAdaptive {
+		return cc.adaptiveBalancer(validChannels, modelName)
+	}
+
+	totalWeight := 0
 	for _, choice := range validChannels {
-		weight := int(*choice.Channel.Weight)
-		choiceWeight -= weight
+		totalWeight += int(choice.weight())
+	}
+	if totalWeight <= 0 {
+		return 0
+	}
+
+	choiceWeight := rand.Intn(totalWeight)
+	for i, choice := range validChannels {
+		choiceWeight -= int(choice.weight())
 		if choiceWeight < 0 {
-			return choice.Channel
+			return i
 		}
 	}
 
-	return nil
+	return len(validChannels) - 1
 }
 
-func (cc *ChannelsChooser) Next(group, modelName string, filters ...ChannelsFilterFunc) (*Channel, error) {
-	cc.RLock()
-	defer cc.RUnlock()
+// adaptiveBalancer 按健康数据调整后的有效权重选择渠道，返回其下标
+func (cc *ChannelsChooser) adaptiveBalancer(validChannels []*ChannelChoice, modelName string) int {
+	weights := cc.adaptiveWeights(validChannels, modelName)
+
+	totalWeight := 0.0
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+
+	choiceWeight := rand.Float64() * totalWeight
+	for i := range validChannels {
+		choiceWeight -= weights[i]
+		if choiceWeight < 0 {
+			return i
+		}
+	}
+
+	return len(validChannels) - 1
+}
+
+// Next 选择渠道并占用限制名额，调用方在请求结束后需调用 release 释放
+// 渠道熔断器处于半开状态时同时占用探测名额 probe，需通过 RecordBreakerResult 回报结果或 ReleaseBreakerProbe 释放
+func (cc *ChannelsChooser) Next(group, modelName string, filters ...ChannelsFilterFunc) (channel *Channel, release func(), probe *BreakerProbe, err error) {
+	channel, release, saturated, err := cc.next(group, modelName, filters...)
+	if channel == nil && saturated {
+		// 候选渠道均达到上游限制，排队等待名额释放
+		channel, release, err = GlobalChannelLimiter.Wait(func() (*Channel, func(), bool, error) {
+			return cc.next(group, modelName, filters...)
+		})
+	}
+	if channel == nil {
+		return channel, release, nil, err
+	}
+
+	return channel, release, cc.breakerAcquire(channel.Id, modelName), nil
+}
+
+// priorities 获取分组下模型对应的按优先级排列的渠道，调用方需持有读锁
+func (cc *ChannelsChooser) priorities(group, modelName string) ([][]int, error) {
 	if _, ok := cc.Rule[group]; !ok {
 		return nil, errors.New("group not found")
 	}
@@ -197,18 +291,82 @@ func (cc *ChannelsChooser) Next(group, modelName string, filters ...ChannelsFilt
 		}
 	}
 
+	return channelsPriority, nil
+}
+
+// Pick 若指定渠道属于该分组模型且当前可用则选中它并占用限制名额，用于会话粘性路由
+// 返回的 probe 同 Next
+func (cc *ChannelsChooser) Pick(group, modelName string, channelId int, filters ...ChannelsFilterFunc) (*Channel, func(), *BreakerProbe) {
+	cc.RLock()
+	defer cc.RUnlock()
+
+	channelsPriority, err := cc.priorities(group, modelName)
+	if err != nil {
+		return nil, nil, nil
+	}
+
+	for _, priority := range channelsPriority {
+		if !utils.Contains(channelId, priority) {
+			continue
+		}
+		choice, _ := cc.selectable(channelId, append(slices.Clip(filters), FilterShadow()), modelName)
+		if choice == nil {
+			return nil, nil, nil
+		}
+		release, ok := GlobalChannelLimiter.TryAcquire(choice.Channel, modelName)
+		if !ok {
+			return nil, nil, nil
+		}
+		return choice.Channel, release, cc.breakerAcquire(channelId, modelName)
+	}
+
+	return nil, nil, nil
+}
+
+func (cc *ChannelsChooser) next(group, modelName string, filters ...ChannelsFilterFunc) (*Channel, func(), bool, error) {
+	cc.RLock()
+	defer cc.RUnlock()
+
+	channelsPriority, err := cc.priorities(group, modelName)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
 	if len(channelsPriority) == 0 {
-		return nil, errors.New("channel not found")
+		return nil, nil, false, errors.New("channel not found")
+	}
+
+	// 灰度渠道先按比例抽样，未命中时不参与正常的权重分配
+	canaries := cc.rolloutChannels(channelsPriority, group, modelName, RolloutModeCanary)
+	if channel, release := cc.pickCanary(canaries, filters, modelName); channel != nil {
+		return channel, release, false, nil
 	}
 
+	balanceMode := GlobalUserGroupRatio.GetBalanceMode(group)
+	normalFilters := append(slices.Clip(filters), filterRollout(group, modelName))
+	anySaturated := false
 	for _, priority := range channelsPriority {
-		channel := cc.balancer(priority, filters, modelName)
+		channel, release, saturated := cc.balancer(priority, normalFilters, modelName, balanceMode)
+		if channel != nil {
+			return channel, release, false, nil
+		}
+		anySaturated = anySaturated || saturated
+	}
+
+	// 正常渠道都不可用时由灰度渠道兜底
+	if len(canaries) > 0 {
+		canaryIds := make([]int, 0, len(canaries))
+		for _, choice := range canaries {
+			canaryIds = append(canaryIds, choice.Channel.Id)
+		}
+		channel, release, saturated := cc.balancer(canaryIds, filters, modelName, balanceMode)
 		if channel != nil {
-			return channel, nil
+			return channel, release, false, nil
 		}
+		anySaturated = anySaturated || saturated
 	}
 
-	return nil, errors.New("channel not found")
+	return nil, nil, anySaturated, errors.New("channel not found")
 }
 
 func (cc *ChannelsChooser) GetGroupModels(group string) ([]string, error) {
@@ -250,11 +408,13 @@ var ChannelGroup = ChannelsChooser{}
 func (cc *ChannelsChooser) Load() {
 	var channels []*Channel
 	DB.Preload("ProxyPool").Where("status = ?", config.ChannelStatusEnabled).Find(&channels)
+	cc.LoadCapabilities()
 
 	newGroup := make(map[string]map[string][][]int)
 	newChannels := make(map[int]*ChannelChoice)
 	newMatch := make(map[string]bool)
 	newModelGroup := make(map[string]map[string]bool)
+	now := time.Now()
 
 	type groupModelKey struct {
 		group string
@@ -264,6 +424,17 @@ func (cc *ChannelsChooser) Load() {
 
 	// 处理每个channel
 	for _, channel := range channels {
+		// 解密失败时凭据仍为密文，不能用于请求
+		if credential.IsEncrypted(channel.Key) || (channel.ProxyPool != nil && credential.IsEncrypted(channel.ProxyPool.Proxy)) {
+			logger.SysError(fmt.Sprintf("channel #%d credentials cannot be decrypted, check credential.master_key", channel.Id))
+			continue
+		}
+		// 密钥引用无法解析时保留渠道但标记为不可用，等待下次刷新
+		secretError := ""
+		if err := channel.ResolveSecrets(); err != nil {
+			secretError = err.Error()
+			logger.SysError(fmt.Sprintf("failed to resolve secret for channel #%d: %s", channel.Id, secretError))
+		}
 		if err := channel.SetProxy(); err != nil {
 			logger.SysError(fmt.Sprintf("failed to resolve proxy for channel #%d: %s", channel.Id, err.Error()))
 			continue
@@ -275,6 +446,8 @@ func (cc *ChannelsChooser) Load() {
 			Channel:       channel,
 			CooldownsTime: 0,
 			Disable:       false,
+			Schedule:      channel.ScheduleState(now),
+			SecretError:   secretError,
 		}
 
 		// 处理groups和models
@@ -298,8 +471,11 @@ func (cc *ChannelsChooser) Load() {
 					channelGroups[key] = make(map[int64][]int)
 				}
 
-				// 按priority分组存储channelId
+				// 按priority分组存储channelId，时间窗口的设置优先
 				priority := *channel.Priority
+				if schedulePriority := newChannels[channel.Id].Schedule.Priority; schedulePriority != nil {
+					priority = *schedulePriority
+				}
 				channelGroups[key][priority] = append(channelGroups[key][priority], channel.Id)
 
 				// 处理通配符模型
@@ -376,8 +552,11 @@ func (cc *ChannelsChooser) Load() {
 		newMatchList = append(newMatchList, match)
 	}
 
+	GlobalChannelLimiter.Prune(channels)
+
 	// 更新ChannelsChooser
 	cc.Lock()
+	cc.logScheduleTransitions(newChannels)
 	cc.Rule = newGroup
 	cc.Channels = newChannels
 	cc.Match = newMatchList
@@ -0,0 +1,314 @@
+package model
+
+import (
+	"czloapi/common/config"
+	"czloapi/common/logger"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	BreakerStateClosed   = "closed"
+	BreakerStateOpen     = "open"
+	BreakerStateHalfOpen = "half_open"
+)
+
+// CircuitBreaker 渠道+模型 维度的熔断器
+// closed: 正常放行，窗口内失败次数达到阈值后进入 open
+// open: 拒绝流量，持续 CircuitBreakerOpenSeconds 后进入 half_open
+// half_open: 仅放行有限的探测请求，探测全部成功则恢复 closed，任一失败重新 open
+type CircuitBreaker struct {
+	sync.Mutex
+	ChannelId      int
+	Model          string
+	State          string
+	Failures       []int64 // 窗口内失败时间戳（秒）
+	OpenedAt       int64
+	Probes         []*BreakerProbe // 半开状态下进行中的探测请求
+	ProbeSuccesses int
+	ChangedAt      int64
+}
+
+// BreakerProbe 半开状态下占用的探测名额，只有持有名额的请求结果才计入半开状态的探测
+type BreakerProbe struct {
+	ChannelId int
+	Model     string
+	StartedAt int64
+}
+
+type CircuitBreakerStatus struct {
+	ChannelId      int    `json:"channel_id"`
+	Model          string `json:"model"`
+	State          string `json:"state"`
+	Failures       int    `json:"failures"`
+	OpenedAt       int64  `json:"opened_at"`
+	Probes         int    `json:"probes"`
+	ProbeSuccesses int    `json:"probe_successes"`
+	ChangedAt      int64  `json:"changed_at"`
+}
+
+func breakerOpenSeconds() int64 {
+	if config.CircuitBreakerOpenSeconds <= 0 {
+		return 30
+	}
+	return int64(config.CircuitBreakerOpenSeconds)
+}
+
+func breakerHalfOpenProbes() int {
+	if config.CircuitBreakerHalfOpenProbes <= 0 {
+		return 1
+	}
+	return config.CircuitBreakerHalfOpenProbes
+}
+
+func (b *CircuitBreaker) setState(state string, now int64) {
+	if b.State == state {
+		return
+	}
+	logger.SysLog(fmt.Sprintf("circuit breaker channel #%d model %s: %s -> %s", b.ChannelId, b.Model, b.State, state))
+	b.State = state
+	b.ChangedAt = now
+	b.Probes = nil
+	b.ProbeSuccesses = 0
+	switch state {
+	case BreakerStateOpen:
+		b.OpenedAt = now
+	case BreakerStateClosed:
+		b.Failures = nil
+		b.OpenedAt = 0
+	}
+}
+
+// refresh 处理基于时间的状态变化，调用方需持有锁
+func (b *CircuitBreaker) refresh(now int64) {
+	if b.State == BreakerStateOpen && now-b.OpenedAt >= breakerOpenSeconds() {
+		b.setState(BreakerStateHalfOpen, now)
+	}
+
+	if b.State == BreakerStateHalfOpen {
+		// 结果未回报的探测（如请求在发送前失败）超时后释放名额
+		probes := b.Probes[:0]
+		for _, probe := range b.Probes {
+			if now-probe.StartedAt < breakerOpenSeconds() {
+				probes = append(probes, probe)
+			}
+		}
+		b.Probes = probes
+	}
+}
+
+// takeProbe 移除并返回是否持有该探测名额，调用方需持有锁
+func (b *CircuitBreaker) takeProbe(probe *BreakerProbe) bool {
+	if probe == nil {
+		return false
+	}
+	index := slices.Index(b.Probes, probe)
+	if index < 0 {
+		return false
+	}
+	b.Probes = slices.Delete(b.Probes, index, index+1)
+	return true
+}
+
+func (b *CircuitBreaker) allow(now int64) bool {
+	b.refresh(now)
+	switch b.State {
+	case BreakerStateOpen:
+		return false
+	case BreakerStateHalfOpen:
+		return len(b.Probes)+b.ProbeSuccesses < breakerHalfOpenProbes()
+	default:
+		return true
+	}
+}
+
+func (b *CircuitBreaker) status() *CircuitBreakerStatus {
+	return &CircuitBreakerStatus{
+		ChannelId:      b.ChannelId,
+		Model:          b.Model,
+		State:          b.State,
+		Failures:       len(b.Failures),
+		OpenedAt:       b.OpenedAt,
+		Probes:         len(b.Probes),
+		ProbeSuccesses: b.ProbeSuccesses,
+		ChangedAt:      b.ChangedAt,
+	}
+}
+
+func (cc *ChannelsChooser) getBreaker(channelId int, modelName string) *CircuitBreaker {
+	value, ok := cc.Breakers.Load(healthKey(channelId, modelName))
+	if !ok {
+		return nil
+	}
+	return value.(*CircuitBreaker)
+}
+
+// BreakerAllow 判断熔断器是否放行该 渠道+模型，仅做判断不占用探测名额
+func (cc *ChannelsChooser) BreakerAllow(channelId int, modelName string) bool {
+	if !config.CircuitBreakerEnabled {
+		return true
+	}
+
+	breaker := cc.getBreaker(channelId, modelName)
+	if breaker == nil {
+		return true
+	}
+
+	breaker.Lock()
+	defer breaker.Unlock()
+	return breaker.allow(time.Now().Unix())
+}
+
+// breakerAcquire 渠道被选中后调用，半开状态下占用一个探测名额，返回的名额在回报结果或释放时交回，其他状态返回 nil
+func (cc *ChannelsChooser) breakerAcquire(channelId int, modelName string) *BreakerProbe {
+	if !config.CircuitBreakerEnabled {
+		return nil
+	}
+
+	breaker := cc.getBreaker(channelId, modelName)
+	if breaker == nil {
+		return nil
+	}
+
+	breaker.Lock()
+	defer breaker.Unlock()
+	now := time.Now().Unix()
+	breaker.refresh(now)
+	if breaker.State != BreakerStateHalfOpen {
+		return nil
+	}
+	probe := &BreakerProbe{ChannelId: channelId, Model: modelName, StartedAt: now}
+	breaker.Probes = append(breaker.Probes, probe)
+	return probe
+}
+
+// ReleaseBreakerProbe 请求结果与渠道无关（本地错误、参数错误、请求未发送等）时调用，
+// 释放半开状态下占用的探测名额，不计入成功或失败，probe 为 nil 时不做处理
+func (cc *ChannelsChooser) ReleaseBreakerProbe(probe *BreakerProbe) {
+	if !config.CircuitBreakerEnabled || probe == nil {
+		return
+	}
+
+	breaker := cc.getBreaker(probe.ChannelId, probe.Model)
+	if breaker == nil {
+		return
+	}
+
+	breaker.Lock()
+	defer breaker.Unlock()
+	breaker.takeProbe(probe)
+}
+
+// RecordBreakerResult 记录请求结果并驱动熔断器状态变化
+// 半开状态下只计入持有探测名额的请求，熔断前选中或同渠道重试的请求结果不影响探测
+func (cc *ChannelsChooser) RecordBreakerResult(channelId int, modelName string, success bool, probe *BreakerProbe) {
+	if !config.CircuitBreakerEnabled || channelId == 0 || modelName == "" {
+		return
+	}
+
+	breaker := cc.getBreaker(channelId, modelName)
+	if breaker == nil {
+		if success {
+			return
+		}
+		value, _ := cc.Breakers.LoadOrStore(healthKey(channelId, modelName), &CircuitBreaker{
+			ChannelId: channelId,
+			Model:     modelName,
+			State:     BreakerStateClosed,
+		})
+		breaker = value.(*CircuitBreaker)
+	}
+
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	now := time.Now().Unix()
+	breaker.refresh(now)
+
+	switch breaker.State {
+	case BreakerStateHalfOpen:
+		if !breaker.takeProbe(probe) {
+			return
+		}
+		if !success {
+			breaker.setState(BreakerStateOpen, now)
+			return
+		}
+		breaker.ProbeSuccesses++
+		if breaker.ProbeSuccesses >= breakerHalfOpenProbes() {
+			breaker.setState(BreakerStateClosed, now)
+		}
+	case BreakerStateClosed:
+		if success {
+			return
+		}
+		windowStart := now - int64(config.CircuitBreakerWindowSeconds)
+		failures := breaker.Failures[:0]
+		for _, failedAt := range breaker.Failures {
+			if failedAt > windowStart {
+				failures = append(failures, failedAt)
+			}
+		}
+		breaker.Failures = append(failures, now)
+		if len(breaker.Failures) >= config.CircuitBreakerFailureThreshold {
+			breaker.setState(BreakerStateOpen, now)
+		}
+	}
+}
+
+// ResetBreaker 手动重置熔断器，modelName 为空时重置该渠道的全部模型
+func (cc *ChannelsChooser) ResetBreaker(channelId int, modelName string) {
+	cc.Breakers.Range(func(key, value interface{}) bool {
+		breaker := value.(*CircuitBreaker)
+		if breaker.ChannelId == channelId && (modelName == "" || breaker.Model == modelName) {
+			cc.Breakers.Delete(key)
+			logger.SysLog(fmt.Sprintf("circuit breaker channel #%d model %s: reset", breaker.ChannelId, breaker.Model))
+		}
+		return true
+	})
+}
+
+// GetBreakerStatus 获取熔断器状态，channelId 为 0 时返回全部
+func (cc *ChannelsChooser) GetBreakerStatus(channelId int) []*CircuitBreakerStatus {
+	now := time.Now().Unix()
+	result := make([]*CircuitBreakerStatus, 0)
+	cc.Breakers.Range(func(_, value interface{}) bool {
+		breaker := value.(*CircuitBreaker)
+		if channelId != 0 && breaker.ChannelId != channelId {
+			return true
+		}
+		breaker.Lock()
+		breaker.refresh(now)
+		result = append(result, breaker.status())
+		breaker.Unlock()
+		return true
+	})
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ChannelId != result[j].ChannelId {
+			return result[i].ChannelId < result[j].ChannelId
+		}
+		return result[i].Model < result[j].Model
+	})
+
+	return result
+}
+
+// CleanupClosedBreakers 清理已恢复且窗口内没有失败记录的熔断器
+func (cc *ChannelsChooser) CleanupClosedBreakers() {
+	windowStart := time.Now().Unix() - int64(config.CircuitBreakerWindowSeconds)
+	cc.Breakers.Range(func(key, value interface{}) bool {
+		breaker := value.(*CircuitBreaker)
+		breaker.Lock()
+		idle := breaker.State == BreakerStateClosed &&
+			(len(breaker.Failures) == 0 || breaker.Failures[len(breaker.Failures)-1] <= windowStart)
+		breaker.Unlock()
+		if idle {
+			cc.Breakers.Delete(key)
+		}
+		return true
+	})
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"czloapi/common/config"
+	"czloapi/common/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	logger.SetupLogger()
+}
+
+func withBreakerConfig(t *testing.T, threshold, openSeconds, probes int) {
+	oldEnabled := config.CircuitBreakerEnabled
+	oldThreshold := config.CircuitBreakerFailureThreshold
+	oldOpenSeconds := config.CircuitBreakerOpenSeconds
+	oldProbes := config.CircuitBreakerHalfOpenProbes
+	t.Cleanup(func() {
+		config.CircuitBreakerEnabled = oldEnabled
+		config.CircuitBreakerFailureThreshold = oldThreshold
+		config.CircuitBreakerOpenSeconds = oldOpenSeconds
+		config.CircuitBreakerHalfOpenProbes = oldProbes
+	})
+
+	config.CircuitBreakerEnabled = true
+	config.CircuitBreakerFailureThreshold = threshold
+	config.CircuitBreakerOpenSeconds = openSeconds
+	config.CircuitBreakerHalfOpenProbes = probes
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	withBreakerConfig(t, 3, 30, 1)
+	cc := &ChannelsChooser{}
+
+	cc.RecordBreakerResult(1, "gpt-4o", false, nil)
+	cc.RecordBreakerResult(1, "gpt-4o", false, nil)
+	assert.True(t, cc.BreakerAllow(1, "gpt-4o"))
+
+	cc.RecordBreakerResult(1, "gpt-4o", false, nil)
+	assert.False(t, cc.BreakerAllow(1, "gpt-4o"))
+	assert.True(t, cc.BreakerAllow(1, "gpt-4o-mini"))
+	assert.Equal(t, BreakerStateOpen, cc.GetBreakerStatus(1)[0].State)
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	withBreakerConfig(t, 1, 30, 1)
+	cc := &ChannelsChooser{}
+
+	cc.RecordBreakerResult(1, "gpt-4o", false, nil)
+	breaker := cc.getBreaker(1, "gpt-4o")
+	breaker.OpenedAt = time.Now().Unix() - 31
+
+	assert.True(t, cc.BreakerAllow(1, "gpt-4o"))
+	probe := cc.breakerAcquire(1, "gpt-4o")
+	assert.NotNil(t, probe)
+	assert.Equal(t, BreakerStateHalfOpen, breaker.State)
+	// 探测名额已被占用
+	assert.False(t, cc.BreakerAllow(1, "gpt-4o"))
+
+	cc.RecordBreakerResult(1, "gpt-4o", true, probe)
+	assert.Equal(t, BreakerStateClosed, breaker.State)
+	assert.True(t, cc.BreakerAllow(1, "gpt-4o"))
+}
+
+func TestBreakerHalfOpenFailureReopens(t *testing.T) {
+	withBreakerConfig(t, 1, 30, 1)
+	cc := &ChannelsChooser{}
+
+	cc.RecordBreakerResult(1, "gpt-4o", false, nil)
+	breaker := cc.getBreaker(1, "gpt-4o")
+	breaker.OpenedAt = time.Now().Unix() - 31
+	probe := cc.breakerAcquire(1, "gpt-4o")
+
+	cc.RecordBreakerResult(1, "gpt-4o", false, probe)
+	assert.Equal(t, BreakerStateOpen, breaker.State)
+	assert.False(t, cc.BreakerAllow(1, "gpt-4o"))
+
+	cc.ResetBreaker(1, "")
+	assert.True(t, cc.BreakerAllow(1, "gpt-4o"))
+}
+
+func TestBreakerReleaseProbe(t *testing.T) {
+	withBreakerConfig(t, 1, 30, 1)
+	cc := &ChannelsChooser{}
+
+	cc.RecordBreakerResult(1, "gpt-4o", false, nil)
+	breaker := cc.getBreaker(1, "gpt-4o")
+	breaker.OpenedAt = time.Now().Unix() - 31
+
+	probe := cc.breakerAcquire(1, "gpt-4o")
+	assert.False(t, cc.BreakerAllow(1, "gpt-4o"))
+
+	// 与渠道无关的结果只释放名额，状态不变
+	cc.ReleaseBreakerProbe(probe)
+	assert.True(t, cc.BreakerAllow(1, "gpt-4o"))
+	assert.Equal(t, BreakerStateHalfOpen, breaker.State)
+	assert.Equal(t, 0, breaker.ProbeSuccesses)
+}
+
+func TestBreakerIgnoresResultsWithoutProbe(t *testing.T) {
+	withBreakerConfig(t, 1, 30, 1)
+	cc := &ChannelsChooser{}
+
+	cc.RecordBreakerResult(1, "gpt-4o", false, nil)
+	breaker := cc.getBreaker(1, "gpt-4o")
+	breaker.OpenedAt = time.Now().Unix() - 31
+
+	probe := cc.breakerAcquire(1, "gpt-4o")
+	assert.False(t, cc.BreakerAllow(1, "gpt-4o"))
+
+	// 熔断前选中或同渠道重试的请求没有探测名额，不能释放或占用其他请求的名额
+	cc.ReleaseBreakerProbe(nil)
+	cc.RecordBreakerResult(1, "gpt-4o", false, nil)
+	cc.RecordBreakerResult(1, "gpt-4o", true, nil)
+	assert.Equal(t, BreakerStateHalfOpen, breaker.State)
+	assert.Equal(t, 0, breaker.ProbeSuccesses)
+	assert.False(t, cc.BreakerAllow(1, "gpt-4o"))
+
+	// 已回报的名额重复释放不影响之后的探测
+	cc.RecordBreakerResult(1, "gpt-4o", true, probe)
+	assert.Equal(t, BreakerStateClosed, breaker.State)
+	cc.ReleaseBreakerProbe(probe)
+	assert.Nil(t, cc.breakerAcquire(1, "gpt-4o"))
+}
@@ -0,0 +1,242 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	BalanceModeWeight   = "weight"
+	BalanceModeAdaptive = "adaptive"
+)
+
+const (
+	// EWMA 平滑系数，越大越偏向最近的请求
+	healthEWMAAlpha = 0.2
+	// 样本数不足时不调整权重
+	healthMinSamples = 5
+	// 超过该时间没有新样本则视为数据过期，恢复原始权重
+	healthStaleAfter = 10 * time.Minute
+	// 最低权重系数，保证表现差的渠道仍能获得少量流量用于恢复评估
+	healthMinFactor = 0.05
+)
+
+// ChannelHealth 记录单个 渠道+模型 的实时表现（EWMA）
+type ChannelHealth struct {
+	sync.Mutex
+	ChannelId int
+	Model     string
+	Latency   float64 // 毫秒
+	TTFT      float64 // 毫秒
+	ErrorRate float64
+	Samples   int64
+	UpdatedAt time.Time
+}
+
+type ChannelHealthScore struct {
+	ChannelId int     `json:"channel_id"`
+	Model     string  `json:"model"`
+	Latency   float64 `json:"latency"`
+	TTFT      float64 `json:"ttft"`
+	ErrorRate float64 `json:"error_rate"`
+	Samples   int64   `json:"samples"`
+	Factor    float64 `json:"factor"`
+	UpdatedAt int64   `json:"updated_at"`
+}
+
+func IsValidBalanceMode(mode string) bool {
+	return mode == "" || mode == BalanceModeWeight || mode == BalanceModeAdaptive
+}
+
+func healthKey(channelId int, modelName string) string {
+	return fmt.Sprintf("%d:%s", channelId, modelName)
+}
+
+func ewma(old, value float64) float64 {
+	return old*(1-healthEWMAAlpha) + value*healthEWMAAlpha
+}
+
+// RecordResult 记录一次请求结果，latency 为整体耗时，ttft 为首字耗时（为0时忽略）
+func (cc *ChannelsChooser) RecordResult(channelId int, modelName string, latency, ttft time.Duration, success bool) {
+	if channelId == 0 || modelName == "" {
+		return
+	}
+
+	value, _ := cc.Health.LoadOrStore(healthKey(channelId, modelName), &ChannelHealth{
+		ChannelId: channelId,
+		Model:     modelName,
+	})
+	health := value.(*ChannelHealth)
+
+	errorValue := 0.0
+	if !success {
+		errorValue = 1
+	}
+	latencyMs := float64(latency.Milliseconds())
+	ttftMs := float64(ttft.Milliseconds())
+
+	health.Lock()
+	defer health.Unlock()
+
+	if health.Samples == 0 || time.Since(health.UpdatedAt) > healthStaleAfter {
+		health.Latency = latencyMs
+		health.TTFT = ttftMs
+		health.ErrorRate = errorValue
+		health.Samples = 0
+	} else {
+		health.ErrorRate = ewma(health.ErrorRate, errorValue)
+		// 失败请求的耗时不代表正常响应速度，只计入错误率
+		if success {
+			health.Latency = ewma(health.Latency, latencyMs)
+			if ttftMs > 0 {
+				if health.TTFT == 0 {
+					health.TTFT = ttftMs
+				} else {
+					health.TTFT = ewma(health.TTFT, ttftMs)
+				}
+			}
+		}
+	}
+	health.Samples++
+	health.UpdatedAt = time.Now()
+}
+
+// getHealth 获取渠道+模型的健康数据快照，数据不足或已过期时返回 false
+func (cc *ChannelsChooser) getHealth(channelId int, modelName string) (ChannelHealthScore, bool) {
+	value, ok := cc.Health.Load(healthKey(channelId, modelName))
+	if !ok {
+		return ChannelHealthScore{}, false
+	}
+	health := value.(*ChannelHealth)
+
+	health.Lock()
+	defer health.Unlock()
+
+	score := ChannelHealthScore{
+		ChannelId: health.ChannelId,
+		Model:     health.Model,
+		Latency:   health.Latency,
+		TTFT:      health.TTFT,
+		ErrorRate: health.ErrorRate,
+		Samples:   health.Samples,
+		Factor:    1,
+		UpdatedAt: health.UpdatedAt.Unix(),
+	}
+
+	if health.Samples < healthMinSamples || time.Since(health.UpdatedAt) > healthStaleAfter {
+		return score, false
+	}
+
+	return score, true
+}
+
+// responseTime 优先使用首字时间衡量速度
+func (s *ChannelHealthScore) responseTime() float64 {
+	if s.TTFT > 0 {
+		return s.TTFT
+	}
+	return s.Latency
+}
+
+// adaptiveWeights 根据健康数据计算同一优先级内各渠道的有效权重
+// 系数 = (1-错误率)^2 * (最快响应时间/本渠道响应时间)，并限制在 [healthMinFactor, 1]
+func (cc *ChannelsChooser) adaptiveWeights(choices []*ChannelChoice, modelName string) []float64 {
+	scores := make([]ChannelHealthScore, len(choices))
+	valid := make([]bool, len(choices))
+	bestTime := math.MaxFloat64
+
+	for i, choice := range choices {
+		scores[i], valid[i] = cc.getHealth(choice.Channel.Id, modelName)
+		if valid[i] {
+			if t := scores[i].responseTime(); t > 0 && t < bestTime {
+				bestTime = t
+			}
+		}
+	}
+
+	weights := make([]float64, len(choices))
+	for i, choice := range choices {
+		weight := float64(choice.weight())
+		if valid[i] {
+			weight *= healthFactor(&scores[i], bestTime)
+		}
+		weights[i] = weight
+	}
+
+	return weights
+}
+
+func healthFactor(score *ChannelHealthScore, bestTime float64) float64 {
+	successRate := 1 - score.ErrorRate
+	factor := successRate * successRate
+
+	if t := score.responseTime(); t > 0 && bestTime < math.MaxFloat64 {
+		factor *= bestTime / t
+	}
+
+	return math.Max(healthMinFactor, math.Min(1, factor))
+}
+
+// GetHealthScores 获取渠道健康评分，channelId 为 0 时返回全部
+// Factor 以同模型下最快的渠道为基准计算，实际负载均衡时以同优先级的渠道为基准
+func (cc *ChannelsChooser) GetHealthScores(channelId int) []*ChannelHealthScore {
+	scores := make([]*ChannelHealthScore, 0)
+	valid := make([]bool, 0)
+	bestTimes := make(map[string]float64)
+
+	cc.Health.Range(func(_, value interface{}) bool {
+		health := value.(*ChannelHealth)
+		score, ok := cc.getHealth(health.ChannelId, health.Model)
+		if ok {
+			if t := score.responseTime(); t > 0 {
+				if best, exists := bestTimes[score.Model]; !exists || t < best {
+					bestTimes[score.Model] = t
+				}
+			}
+		}
+		scores = append(scores, &score)
+		valid = append(valid, ok)
+		return true
+	})
+
+	result := make([]*ChannelHealthScore, 0, len(scores))
+	for i, score := range scores {
+		if channelId != 0 && score.ChannelId != channelId {
+			continue
+		}
+		if valid[i] {
+			bestTime, ok := bestTimes[score.Model]
+			if !ok {
+				bestTime = math.MaxFloat64
+			}
+			score.Factor = healthFactor(score, bestTime)
+		}
+		result = append(result, score)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ChannelId != result[j].ChannelId {
+			return result[i].ChannelId < result[j].ChannelId
+		}
+		return result[i].Model < result[j].Model
+	})
+
+	return result
+}
+
+// CleanupStaleHealth 清理长时间没有更新的健康数据
+func (cc *ChannelsChooser) CleanupStaleHealth() {
+	cc.Health.Range(func(key, value interface{}) bool {
+		health := value.(*ChannelHealth)
+		health.Lock()
+		stale := time.Since(health.UpdatedAt) > healthStaleAfter
+		health.Unlock()
+		if stale {
+			cc.Health.Delete(key)
+		}
+		return true
+	})
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newHealthTestChoice(id int, weight uint) *ChannelChoice {
+	return &ChannelChoice{
+		Channel: &Channel{
+			Id:     id,
+			Weight: &weight,
+		},
+	}
+}
+
+func TestAdaptiveWeightsIgnoreInsufficientSamples(t *testing.T) {
+	cc := &ChannelsChooser{}
+	for i := 0; i < healthMinSamples-1; i++ {
+		cc.RecordResult(1, "gpt-4o", 5*time.Second, 0, false)
+	}
+
+	weights := cc.adaptiveWeights([]*ChannelChoice{
+		newHealthTestChoice(1, 10),
+		newHealthTestChoice(2, 10),
+	}, "gpt-4o")
+
+	assert.Equal(t, []float64{10, 10}, weights)
+}
+
+func TestAdaptiveWeightsPenalizeSlowAndFailingChannels(t *testing.T) {
+	cc := &ChannelsChooser{}
+	for i := 0; i < healthMinSamples; i++ {
+		cc.RecordResult(1, "gpt-4o", time.Second, 200*time.Millisecond, true)
+		cc.RecordResult(2, "gpt-4o", 2*time.Second, 800*time.Millisecond, true)
+		cc.RecordResult(3, "gpt-4o", time.Second, 200*time.Millisecond, false)
+	}
+
+	weights := cc.adaptiveWeights([]*ChannelChoice{
+		newHealthTestChoice(1, 10),
+		newHealthTestChoice(2, 10),
+		newHealthTestChoice(3, 10),
+	}, "gpt-4o")
+
+	assert.InDelta(t, 10, weights[0], 0.001)
+	assert.InDelta(t, 2.5, weights[1], 0.001)
+	assert.InDelta(t, 10*healthMinFactor, weights[2], 0.001)
+}
+
+func TestGetHealthScoresFiltersByChannel(t *testing.T) {
+	cc := &ChannelsChooser{}
+	cc.RecordResult(1, "gpt-4o", time.Second, 0, true)
+	cc.RecordResult(1, "gpt-4o-mini", time.Second, 0, true)
+	cc.RecordResult(2, "gpt-4o", time.Second, 0, true)
+
+	scores := cc.GetHealthScores(1)
+
+	assert.Len(t, scores, 2)
+	assert.Equal(t, "gpt-4o", scores[0].Model)
+	assert.Equal(t, "gpt-4o-mini", scores[1].Model)
+	assert.Len(t, cc.GetHealthScores(0), 3)
+}
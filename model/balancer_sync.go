@@ -0,0 +1,260 @@
+package model
+
+import (
+	"context"
+	"czloapi/common/config"
+	"czloapi/common/logger"
+	"czloapi/common/redis"
+	"czloapi/common/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 多节点部署时通过 Redis 共享渠道冷却与运行时禁用状态
+// 冷却时间和启用/禁用状态写入带过期时间的 key，变化通过 pub/sub 通知其他节点更新本地状态
+// 启动、断线重连以及定期全量同步时从 Redis 读取，弥补错过的事件
+// 未启用时仅使用进程内的 sync.Map
+const (
+	channelStateCooldownPrefix = "channel_state:cooldown:"
+	channelStateStatusPrefix   = "channel_state:status:"
+	channelStateEventChannel   = "channel_state:events"
+
+	// 启用/禁用状态以数据库为准，各节点重新加载渠道后即一致，Redis 中只需保留一段时间
+	channelStateStatusTTL = time.Hour
+	// 空闲超过该时间发送 ping 检测连接，两倍时间内没有任何响应视为断开
+	channelStateReceiveTimeout = 30 * time.Second
+	channelStateResyncInterval = 5 * time.Minute
+
+	channelStateEventCooldown = "cooldown"
+	channelStateEventStatus   = "status"
+)
+
+var (
+	channelStateSyncEnabled = false
+	channelStateNodeId      = utils.GetUUID()
+)
+
+type channelStateEvent struct {
+	Node      string `json:"node"`
+	Type      string `json:"type"`
+	Key       string `json:"key,omitempty"`
+	Until     int64  `json:"until,omitempty"`
+	ChannelId int    `json:"channel_id,omitempty"`
+	Enabled   bool   `json:"enabled,omitempty"`
+}
+
+func InitChannelStateSync() {
+	if !viper.GetBool("channel.state_sync") {
+		return
+	}
+
+	if !config.RedisEnabled {
+		logger.SysLog("channel state sync requires Redis, fallback to in-memory state")
+		return
+	}
+
+	channelStateSyncEnabled = true
+	ChannelGroup.loadSharedState()
+	go ChannelGroup.subscribeChannelState()
+	logger.SysLog("channel state sync enabled")
+}
+
+func publishChannelState(event *channelStateEvent) {
+	event.Node = channelStateNodeId
+	message, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	if err := redis.RedisPublish(channelStateEventChannel, string(message)); err != nil {
+		logger.SysError("failed to publish channel state: " + err.Error())
+	}
+}
+
+// shareCooldown 将冷却时间写入 Redis 并通知其他节点
+func (cc *ChannelsChooser) shareCooldown(key string, until int64) {
+	if !channelStateSyncEnabled {
+		return
+	}
+
+	ttl := time.Until(time.Unix(until, 0))
+	if ttl <= 0 {
+		return
+	}
+
+	if err := redis.RedisSet(channelStateCooldownPrefix+key, strconv.FormatInt(until, 10), ttl); err != nil {
+		logger.SysError("failed to share channel cooldown: " + err.Error())
+		return
+	}
+
+	publishChannelState(&channelStateEvent{
+		Type:  channelStateEventCooldown,
+		Key:   key,
+		Until: until,
+	})
+}
+
+// shareStatus 将渠道的运行时启用/禁用状态写入 Redis 并通知其他节点
+func (cc *ChannelsChooser) shareStatus(channelId int, enabled bool) {
+	if !channelStateSyncEnabled {
+		return
+	}
+
+	value := "0"
+	if enabled {
+		value = "1"
+	}
+	if err := redis.RedisSet(channelStateStatusPrefix+strconv.Itoa(channelId), value, channelStateStatusTTL); err != nil {
+		logger.SysError("failed to share channel status: " + err.Error())
+	}
+
+	publishChannelState(&channelStateEvent{
+		Type:      channelStateEventStatus,
+		ChannelId: channelId,
+		Enabled:   enabled,
+	})
+}
+
+// storeCooldown 写入本地冷却时间，只会延长不会缩短
+func (cc *ChannelsChooser) storeCooldown(key string, until int64) {
+	for {
+		current, loaded := cc.Cooldowns.LoadOrStore(key, until)
+		if !loaded || current.(int64) >= until {
+			return
+		}
+		if cc.Cooldowns.CompareAndSwap(key, current, until) {
+			return
+		}
+	}
+}
+
+// loadSharedState 从 Redis 读取其他节点设置的冷却时间和启用/禁用状态
+func (cc *ChannelsChooser) loadSharedState() {
+	cc.loadSharedCooldowns()
+	cc.loadSharedStatus()
+}
+
+func (cc *ChannelsChooser) loadSharedCooldowns() {
+	keys, err := redis.RedisScanKeys(channelStateCooldownPrefix + "*")
+	if err != nil {
+		logger.SysError("failed to load shared channel cooldowns: " + err.Error())
+		return
+	}
+
+	for _, redisKey := range keys {
+		value, err := redis.RedisGet(redisKey)
+		if err != nil {
+			continue
+		}
+		until, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		cc.storeCooldown(strings.TrimPrefix(redisKey, channelStateCooldownPrefix), until)
+	}
+}
+
+// loadSharedStatus 只更新本地已加载的渠道，未加载的渠道在数据库中为禁用状态，由重新加载渠道处理
+func (cc *ChannelsChooser) loadSharedStatus() {
+	keys, err := redis.RedisScanKeys(channelStateStatusPrefix + "*")
+	if err != nil {
+		logger.SysError("failed to load shared channel status: " + err.Error())
+		return
+	}
+
+	for _, redisKey := range keys {
+		channelId, err := strconv.Atoi(strings.TrimPrefix(redisKey, channelStateStatusPrefix))
+		if err != nil {
+			continue
+		}
+		value, err := redis.RedisGet(redisKey)
+		if err != nil {
+			continue
+		}
+		if value == "1" {
+			cc.Enable(channelId)
+		} else {
+			cc.Disable(channelId)
+		}
+	}
+}
+
+func (cc *ChannelsChooser) subscribeChannelState() {
+	for {
+		err := cc.receiveChannelState()
+		logger.SysError(fmt.Sprintf("channel state subscription lost: %s, retrying in 5 seconds", err.Error()))
+		time.Sleep(5 * time.Second)
+		// 断线期间可能错过了事件，重新同步一次
+		cc.loadSharedState()
+	}
+}
+
+// receiveChannelState 接收其他节点的状态事件，连接断开时返回错误由调用方重新订阅
+func (cc *ChannelsChooser) receiveChannelState() error {
+	pubsub := redis.RedisSubscribe(channelStateEventChannel)
+	defer pubsub.Close()
+
+	lastReceived := time.Now()
+	lastSync := time.Now()
+	for {
+		message, err := redis.RedisReceive(pubsub, channelStateReceiveTimeout)
+		switch {
+		case errors.Is(err, redis.ErrReceiveTimeout):
+			if time.Since(lastReceived) > 2*channelStateReceiveTimeout {
+				return errors.New("no response from redis")
+			}
+			if err := pubsub.Ping(context.Background()); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			lastReceived = time.Now()
+			if message != nil {
+				cc.handleChannelStateMessage(message.Payload)
+			}
+		}
+
+		if time.Since(lastSync) > channelStateResyncInterval {
+			cc.loadSharedState()
+			lastSync = time.Now()
+		}
+	}
+}
+
+func (cc *ChannelsChooser) handleChannelStateMessage(payload string) {
+	var event channelStateEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		return
+	}
+	if event.Node == channelStateNodeId {
+		return
+	}
+	cc.applyChannelState(&event)
+}
+
+func (cc *ChannelsChooser) applyChannelState(event *channelStateEvent) {
+	switch event.Type {
+	case channelStateEventCooldown:
+		cc.storeCooldown(event.Key, event.Until)
+	case channelStateEventStatus:
+		if !event.Enabled {
+			cc.Disable(event.ChannelId)
+			return
+		}
+		// 本节点加载时渠道已被禁用则不在列表中，需要重新加载
+		if cc.GetChannel(event.ChannelId) == nil {
+			cc.Load()
+			return
+		}
+		cc.Enable(event.ChannelId)
+	default:
+		logger.SysError(fmt.Sprintf("unknown channel state event: %s", event.Type))
+	}
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreCooldownOnlyExtends(t *testing.T) {
+	cc := &ChannelsChooser{}
+	now := time.Now().Unix()
+
+	cc.storeCooldown("1:gpt-4o", now+10)
+	cc.storeCooldown("1:gpt-4o", now+5)
+	value, _ := cc.Cooldowns.Load("1:gpt-4o")
+	assert.Equal(t, now+10, value.(int64))
+
+	cc.storeCooldown("1:gpt-4o", now+20)
+	value, _ = cc.Cooldowns.Load("1:gpt-4o")
+	assert.Equal(t, now+20, value.(int64))
+}
+
+func TestApplyChannelStateEvents(t *testing.T) {
+	cc := &ChannelsChooser{
+		Channels: map[int]*ChannelChoice{
+			1: {Channel: &Channel{Id: 1}},
+		},
+	}
+
+	cc.applyChannelState(&channelStateEvent{
+		Type:  channelStateEventCooldown,
+		Key:   "1:gpt-4o",
+		Until: time.Now().Unix() + 30,
+	})
+	assert.True(t, cc.IsInCooldown(1, "gpt-4o"))
+
+	cc.applyChannelState(&channelStateEvent{
+		Type:      channelStateEventStatus,
+		ChannelId: 1,
+		Enabled:   false,
+	})
+	assert.True(t, cc.Channels[1].Disable)
+
+	cc.applyChannelState(&channelStateEvent{
+		Type:      channelStateEventStatus,
+		ChannelId: 1,
+		Enabled:   true,
+	})
+	assert.False(t, cc.Channels[1].Disable)
+}
+
+func TestHandleChannelStateMessageSkipsOwnNode(t *testing.T) {
+	cc := &ChannelsChooser{
+		Channels: map[int]*ChannelChoice{
+			1: {Channel: &Channel{Id: 1}},
+		},
+	}
+
+	cc.handleChannelStateMessage(`{"node":"` + channelStateNodeId + `","type":"status","channel_id":1}`)
+	assert.False(t, cc.Channels[1].Disable)
+
+	cc.handleChannelStateMessage(`invalid`)
+	cc.handleChannelStateMessage(`{"node":"other","type":"status","channel_id":1}`)
+	assert.True(t, cc.Channels[1].Disable)
+}
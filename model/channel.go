@@ -3,6 +3,7 @@ package model
 import (
 	"crypto/md5"
 	"czloapi/common/config"
+	"czloapi/common/credential"
 	"czloapi/common/logger"
 	"czloapi/common/utils"
 	"encoding/hex"
@@ -16,7 +17,7 @@ import (
 type Channel struct {
 	Id                 int     `json:"id"`
 	Type               int     `json:"type" form:"type" gorm:"default:0"`
-	Key                string  `json:"key" form:"key" gorm:"type:text"`
+	Key                string  `json:"key" form:"key" gorm:"type:text;serializer:credential"`
 	Status             int     `json:"status" form:"status" gorm:"default:1"`
 	Name               string  `json:"name" form:"name" gorm:"index"`
 	Weight             *uint   `json:"weight" gorm:"default:1"`
@@ -44,8 +45,14 @@ type Channel struct {
 	AllowExtraBody     bool    `json:"allow_extra_body" form:"allow_extra_body" gorm:"default:false"`
 	ResponsesWS        bool    `json:"responses_ws" form:"responses_ws" gorm:"default:false"`
 	RetryTimes         *int    `json:"retry_times" gorm:"default:0"`
+	ModelSyncPolicy    string  `json:"model_sync_policy" form:"model_sync_policy" gorm:"type:varchar(16);default:''"`
 
-	DisabledStream *datatypes.JSONSlice[string] `json:"disabled_stream,omitempty" gorm:"type:json"`
+	DisabledStream *datatypes.JSONSlice[string]          `json:"disabled_stream,omitempty" gorm:"type:json"`
+	Limits         *datatypes.JSONType[ChannelLimits]    `json:"limits,omitempty" gorm:"type:json"`
+	Rollout        *datatypes.JSONType[ChannelRollout]   `json:"rollout,omitempty" gorm:"type:json"`
+	Schedules      *datatypes.JSONSlice[ChannelSchedule] `json:"schedules,omitempty" gorm:"type:json"`
+	KeyPool        *datatypes.JSONType[ChannelKeyPool]   `json:"key_pool,omitempty" gorm:"type:json"`
+	OverrideRules  *datatypes.JSONSlice[OverrideRule]    `json:"override_rules,omitempty" gorm:"type:json"`
 
 	Plugin    *datatypes.JSONType[PluginType] `json:"plugin" form:"plugin" gorm:"type:json"`
 	ProxyPool *IPProxy                        `json:"proxy_pool,omitempty" gorm:"foreignKey:ProxyPoolID;references:Id;-:migration"`
@@ -131,8 +138,18 @@ func GetChannelsList(params *SearchChannelsParams) (*DataResult[Channel], error)
 	}
 
 	if params.Key != "" {
-		db = db.Where(quotePostgresField("key")+" = ?", params.Key)
-		tagDB = tagDB.Where(quotePostgresField("key")+" = ?", params.Key)
+		if credential.Enabled() {
+			// 加密后的 key 无法直接比较，解密后在内存中匹配
+			ids, err := findChannelIdsByKey(params.Key)
+			if err != nil {
+				return nil, err
+			}
+			db = db.Where("id IN ?", ids)
+			tagDB = tagDB.Where("id IN ?", ids)
+		} else {
+			db = db.Where(quotePostgresField("key")+" = ?", params.Key)
+			tagDB = tagDB.Where(quotePostgresField("key")+" = ?", params.Key)
+		}
 	}
 
 	if params.TestModel != "" {
@@ -0,0 +1,176 @@
+package model
+
+import (
+	"czloapi/common/logger"
+	"czloapi/common/utils"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"gorm.io/datatypes"
+)
+
+// 可疑渠道的处理方式
+const (
+	AuthenticityActionNotify        = "notify"         // 默认，只标记并发送通知
+	AuthenticityActionLowerPriority = "lower_priority" // 同时降低渠道优先级，优先级是渠道级别的设置，会影响渠道的所有模型
+)
+
+// AuthenticityCheck 单项真实性检测结果
+type AuthenticityCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Weight int    `json:"weight"`
+	Remark string `json:"remark"`
+}
+
+// ChannelAuthenticity 渠道+模型 的真实性检测记录，每次检测新增一条用于查看历史
+type ChannelAuthenticity struct {
+	Id          int                                    `json:"id"`
+	ChannelId   int                                    `json:"channel_id" gorm:"index"`
+	ChannelName string                                 `json:"channel_name" gorm:"type:varchar(255)"`
+	Model       string                                 `json:"model" gorm:"type:varchar(255);index"`
+	Score       int                                    `json:"score"`
+	Suspicious  bool                                   `json:"suspicious" gorm:"index"`
+	Checks      datatypes.JSONSlice[AuthenticityCheck] `json:"checks" gorm:"type:json"`
+	// 因检测结果被降低优先级时记录原优先级，渠道所有模型最近一次检测都不再可疑时恢复
+	PreviousPriority *int64 `json:"previous_priority" gorm:"bigint"`
+	RestoredTime     int64  `json:"restored_time" gorm:"bigint;default:0"`
+	CreatedTime      int64  `json:"created_time" gorm:"bigint"`
+}
+
+var allowedAuthenticityOrderFields = map[string]bool{
+	"id":           true,
+	"channel_id":   true,
+	"model":        true,
+	"score":        true,
+	"created_time": true,
+}
+
+type SearchAuthenticityParams struct {
+	ChannelId  int    `form:"channel_id"`
+	Model      string `form:"model"`
+	Suspicious bool   `form:"suspicious"`
+	PaginationParams
+}
+
+func GetAuthenticityList(params *SearchAuthenticityParams) (*DataResult[ChannelAuthenticity], error) {
+	var records []*ChannelAuthenticity
+	db := DB
+	if params.ChannelId != 0 {
+		db = db.Where("channel_id = ?", params.ChannelId)
+	}
+	if params.Model != "" {
+		db = db.Where("model = ?", params.Model)
+	}
+	if params.Suspicious {
+		db = db.Where("suspicious = ?", true)
+	}
+	if params.Order == "" {
+		params.Order = "-id"
+	}
+	return PaginateAndOrder(db, &params.PaginationParams, &records, allowedAuthenticityOrderFields)
+}
+
+// ScoreAuthenticityChecks 按权重计算通过率，返回 0-100 的分数
+func ScoreAuthenticityChecks(checks []AuthenticityCheck) int {
+	total, passed := 0, 0
+	for _, check := range checks {
+		total += check.Weight
+		if check.Passed {
+			passed += check.Weight
+		}
+	}
+	if total == 0 {
+		return 100
+	}
+	return passed * 100 / total
+}
+
+// RecordAuthenticity 保存检测结果，分数低于 channel.authenticity_threshold 时标记为可疑，并按 channel.authenticity_action 处理
+// 降低和恢复的都是整个渠道的优先级，对渠道的所有模型生效
+func RecordAuthenticity(channel *Channel, modelName string, checks []AuthenticityCheck) (*ChannelAuthenticity, error) {
+	score := ScoreAuthenticityChecks(checks)
+	record := &ChannelAuthenticity{
+		ChannelId:   channel.Id,
+		ChannelName: channel.Name,
+		Model:       modelName,
+		Score:       score,
+		Suspicious:  score < viper.GetInt("channel.authenticity_threshold"),
+		Checks:      checks,
+		CreatedTime: utils.GetTimestamp(),
+	}
+
+	changed := false
+	if !record.Suspicious {
+		restored, err := restoreAuthenticityPriority(channel, modelName)
+		if err != nil {
+			return nil, err
+		}
+		changed = restored
+	}
+
+	if record.Suspicious && viper.GetString("channel.authenticity_action") == AuthenticityActionLowerPriority {
+		priority := viper.GetInt64("channel.authenticity_lowered_priority")
+		if current := channel.GetPriority(); current > priority {
+			if err := DB.Model(&Channel{}).Where("id = ?", channel.Id).Update("priority", priority).Error; err != nil {
+				return nil, err
+			}
+			record.PreviousPriority = &current
+			channel.Priority = &priority
+			changed = true
+			logger.SysLog(fmt.Sprintf("channel #%d model %s failed authenticity check (score %d), priority lowered from %d to %d", channel.Id, modelName, score, current, priority))
+		}
+	}
+
+	if err := DB.Create(record).Error; err != nil {
+		return nil, err
+	}
+	if changed {
+		ChannelGroup.Load()
+	}
+	return record, nil
+}
+
+// restoreAuthenticityPriority 渠道因检测可疑被降低的优先级，在渠道所有模型最近一次检测都不再可疑时恢复
+// 期间优先级被管理员修改过时只标记为已处理，不覆盖管理员的设置
+func restoreAuthenticityPriority(channel *Channel, modelName string) (bool, error) {
+	var lowered []*ChannelAuthenticity
+	err := DB.Where("channel_id = ? AND previous_priority IS NOT NULL AND restored_time = 0", channel.Id).
+		Order("id").Find(&lowered).Error
+	if err != nil || len(lowered) == 0 {
+		return false, err
+	}
+
+	// 优先级是渠道级别的，其他模型最近一次检测仍可疑时保持降低
+	var suspicious int64
+	latestIds := DB.Model(&ChannelAuthenticity{}).Select("MAX(id)").Where("channel_id = ?", channel.Id).Group("model")
+	err = DB.Model(&ChannelAuthenticity{}).
+		Where("channel_id = ? AND model <> ? AND suspicious = ?", channel.Id, modelName, true).
+		Where("id IN (?)", latestIds).
+		Count(&suspicious).Error
+	if err != nil || suspicious > 0 {
+		return false, err
+	}
+
+	loweredIds := make([]int, 0, len(lowered))
+	for _, record := range lowered {
+		loweredIds = append(loweredIds, record.Id)
+	}
+	if err := DB.Model(&ChannelAuthenticity{}).Where("id IN ?", loweredIds).Update("restored_time", utils.GetTimestamp()).Error; err != nil {
+		return false, err
+	}
+
+	loweredPriority := viper.GetInt64("channel.authenticity_lowered_priority")
+	if channel.GetPriority() != loweredPriority {
+		return false, nil
+	}
+
+	// 最早一次降低时记录的才是渠道原本的优先级
+	previous := *lowered[0].PreviousPriority
+	if err := DB.Model(&Channel{}).Where("id = ?", channel.Id).Update("priority", previous).Error; err != nil {
+		return false, err
+	}
+	channel.Priority = &previous
+	logger.SysLog(fmt.Sprintf("channel #%d model %s passed authenticity check, priority restored from %d to %d", channel.Id, modelName, loweredPriority, previous))
+	return true, nil
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"testing"
+
+	"czloapi/common/utils"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreAuthenticityChecks(t *testing.T) {
+	checks := []AuthenticityCheck{
+		{Name: "a", Passed: true, Weight: 2},
+		{Name: "b", Passed: false, Weight: 1},
+		{Name: "c", Passed: true, Weight: 1},
+	}
+	assert.Equal(t, 75, ScoreAuthenticityChecks(checks))
+	assert.Equal(t, 100, ScoreAuthenticityChecks(nil))
+}
+
+func TestRestoreAuthenticityPriorityWaitsForAllModels(t *testing.T) {
+	setupTestDB(t, &Channel{}, &ChannelAuthenticity{})
+	viper.Set("channel.authenticity_lowered_priority", -1)
+	t.Cleanup(func() { viper.Set("channel.authenticity_lowered_priority", nil) })
+
+	channel := &Channel{Id: 1, Name: "test", Priority: utils.GetPointer[int64](-1)}
+	require.NoError(t, DB.Create(channel).Error)
+
+	record := func(modelName string, suspicious bool, previousPriority *int64) {
+		require.NoError(t, DB.Create(&ChannelAuthenticity{
+			ChannelId:        channel.Id,
+			Model:            modelName,
+			Suspicious:       suspicious,
+			PreviousPriority: previousPriority,
+		}).Error)
+	}
+
+	// 模型 a 可疑时把优先级从 10 降到 -1，之后模型 b 也可疑，此时优先级已经降低，不再记录原优先级
+	record("a", true, utils.GetPointer[int64](10))
+	record("b", true, nil)
+
+	// 模型 a 通过但 b 仍可疑，保持降低
+	restored, err := restoreAuthenticityPriority(channel, "a")
+	require.NoError(t, err)
+	assert.False(t, restored)
+	assert.Equal(t, int64(-1), channel.GetPriority())
+	record("a", false, nil)
+
+	// 模型 b 也通过后恢复到最初的优先级
+	restored, err = restoreAuthenticityPriority(channel, "b")
+	require.NoError(t, err)
+	assert.True(t, restored)
+	assert.Equal(t, int64(10), channel.GetPriority())
+
+	stored := &Channel{}
+	require.NoError(t, DB.First(stored, channel.Id).Error)
+	assert.Equal(t, int64(10), stored.GetPriority())
+
+	var pending int64
+	require.NoError(t, DB.Model(&ChannelAuthenticity{}).Where("previous_priority IS NOT NULL AND restored_time = 0").Count(&pending).Error)
+	assert.Zero(t, pending)
+}
@@ -0,0 +1,235 @@
+package model
+
+import (
+	"czloapi/common/logger"
+	"czloapi/common/utils"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	CapabilityTools      = "tools"
+	CapabilityVision     = "vision"
+	CapabilityJsonSchema = "json_schema"
+	CapabilityStreaming  = "streaming"
+	CapabilityReasoning  = "reasoning"
+)
+
+var Capabilities = []string{CapabilityTools, CapabilityVision, CapabilityJsonSchema, CapabilityStreaming, CapabilityReasoning}
+
+// ChannelCapability 渠道+模型 的能力探测结果，nil 表示未探测或结果无法判断
+type ChannelCapability struct {
+	Id         int    `json:"id"`
+	ChannelId  int    `json:"channel_id" gorm:"uniqueIndex:idx_channel_capability"`
+	Model      string `json:"model" gorm:"type:varchar(255);uniqueIndex:idx_channel_capability"`
+	Tools      *bool  `json:"tools"`
+	Vision     *bool  `json:"vision"`
+	JsonSchema *bool  `json:"json_schema"`
+	Streaming  *bool  `json:"streaming"`
+	Reasoning  *bool  `json:"reasoning"`
+	// 成功请求中观察到的最大输入 tokens
+	MaxContext  int   `json:"max_context" gorm:"default:0"`
+	ProbedTime  int64 `json:"probed_time" gorm:"bigint"`
+	UpdatedTime int64 `json:"updated_time" gorm:"bigint"`
+}
+
+func (c *ChannelCapability) field(capability string) **bool {
+	switch capability {
+	case CapabilityTools:
+		return &c.Tools
+	case CapabilityVision:
+		return &c.Vision
+	case CapabilityJsonSchema:
+		return &c.JsonSchema
+	case CapabilityStreaming:
+		return &c.Streaming
+	case CapabilityReasoning:
+		return &c.Reasoning
+	}
+	return nil
+}
+
+// Lacks 探测结果明确不支持该能力
+func (c *ChannelCapability) Lacks(capability string) bool {
+	field := c.field(capability)
+	return field != nil && *field != nil && !**field
+}
+
+func capabilityKey(channelId int, modelName string) string {
+	return fmt.Sprintf("%d:%s", channelId, modelName)
+}
+
+// SaveCapabilityProbe 保存探测结果，results 中为 nil 的能力保留之前的结果
+// 只写入探测的列，避免覆盖探测期间由请求更新的 max_context
+func SaveCapabilityProbe(channelId int, modelName string, results map[string]*bool) error {
+	now := utils.GetTimestamp()
+	probed := &ChannelCapability{ChannelId: channelId, Model: modelName, ProbedTime: now, UpdatedTime: now}
+	columns := []string{"probed_time", "updated_time"}
+	for _, name := range Capabilities {
+		if supported := results[name]; supported != nil {
+			*probed.field(name) = supported
+			columns = append(columns, name)
+		}
+	}
+
+	err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "channel_id"}, {Name: "model"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(probed).Error
+	if err != nil {
+		return err
+	}
+
+	capability := &ChannelCapability{}
+	if err = DB.Where("channel_id = ? AND model = ?", channelId, modelName).First(capability).Error; err != nil {
+		return err
+	}
+	ChannelGroup.Capabilities.Store(capabilityKey(channelId, modelName), capability)
+	return nil
+}
+
+func GetChannelCapabilities(channelId int) ([]*ChannelCapability, error) {
+	var capabilities []*ChannelCapability
+	db := DB.Order("channel_id, model")
+	if channelId != 0 {
+		db = db.Where("channel_id = ?", channelId)
+	}
+	err := db.Find(&capabilities).Error
+	return capabilities, err
+}
+
+// LoadCapabilities 从数据库加载能力数据
+func (cc *ChannelsChooser) LoadCapabilities() {
+	capabilities, err := GetChannelCapabilities(0)
+	if err != nil {
+		logger.SysError("failed to load channel capabilities: " + err.Error())
+		return
+	}
+
+	keys := make(map[string]bool, len(capabilities))
+	for _, capability := range capabilities {
+		key := capabilityKey(capability.ChannelId, capability.Model)
+		keys[key] = true
+		cc.Capabilities.Store(key, capability)
+	}
+	cc.Capabilities.Range(func(key, _ interface{}) bool {
+		if !keys[key.(string)] {
+			cc.Capabilities.Delete(key)
+		}
+		return true
+	})
+}
+
+func (cc *ChannelsChooser) getCapability(channelId int, modelName string) *ChannelCapability {
+	value, ok := cc.Capabilities.Load(capabilityKey(channelId, modelName))
+	if !ok {
+		return nil
+	}
+	return value.(*ChannelCapability)
+}
+
+// 观察到的最大输入 tokens 先写入内存，由后台定期批量写入数据库
+const contextObservationFlushInterval = time.Minute
+
+var (
+	contextObservationLock sync.Mutex
+	contextObservations    = make(map[string]*ChannelCapability)
+)
+
+// ObserveContext 记录成功请求的输入 tokens，超过已记录的最大值时更新
+func (cc *ChannelsChooser) ObserveContext(channelId int, modelName string, promptTokens int) {
+	if promptTokens <= 0 {
+		return
+	}
+
+	key := capabilityKey(channelId, modelName)
+	now := utils.GetTimestamp()
+	for {
+		value, loaded := cc.Capabilities.Load(key)
+		observed := &ChannelCapability{ChannelId: channelId, Model: modelName}
+		if loaded {
+			current := value.(*ChannelCapability)
+			if promptTokens <= current.MaxContext {
+				return
+			}
+			copied := *current
+			observed = &copied
+		}
+		observed.MaxContext = promptTokens
+		observed.UpdatedTime = now
+
+		if loaded {
+			if cc.Capabilities.CompareAndSwap(key, value, observed) {
+				break
+			}
+		} else if _, exists := cc.Capabilities.LoadOrStore(key, observed); !exists {
+			break
+		}
+	}
+
+	contextObservationLock.Lock()
+	if pending, ok := contextObservations[key]; !ok || pending.MaxContext < promptTokens {
+		contextObservations[key] = &ChannelCapability{ChannelId: channelId, Model: modelName, MaxContext: promptTokens, UpdatedTime: now}
+	}
+	contextObservationLock.Unlock()
+}
+
+// StartContextObservationFlusher 启动后台任务，定期将观察到的最大输入 tokens 写入数据库
+func StartContextObservationFlusher() {
+	go func() {
+		for {
+			time.Sleep(contextObservationFlushInterval)
+			flushContextObservations()
+		}
+	}()
+}
+
+func flushContextObservations() {
+	contextObservationLock.Lock()
+	observations := contextObservations
+	contextObservations = make(map[string]*ChannelCapability)
+	contextObservationLock.Unlock()
+
+	for _, observation := range observations {
+		// 只增不减，并且只更新 max_context，不覆盖探测结果
+		result := DB.Model(&ChannelCapability{}).
+			Where("channel_id = ? AND model = ? AND max_context < ?", observation.ChannelId, observation.Model, observation.MaxContext).
+			Updates(map[string]interface{}{"max_context": observation.MaxContext, "updated_time": observation.UpdatedTime})
+		err := result.Error
+		if err == nil && result.RowsAffected == 0 {
+			err = DB.Clauses(clause.OnConflict{DoNothing: true}).Create(observation).Error
+		}
+		if err != nil {
+			logger.SysError("failed to update channel max context: " + err.Error())
+		}
+	}
+}
+
+// FilterCapabilities 跳过探测结果明确不支持所需能力的渠道，未探测的渠道不受影响
+func FilterCapabilities(modelName string, required []string) ChannelsFilterFunc {
+	return func(channelId int, _ *ChannelChoice) bool {
+		capability := ChannelGroup.getCapability(channelId, modelName)
+		if capability == nil {
+			return false
+		}
+		return slices.ContainsFunc(required, capability.Lacks)
+	}
+}
+
+// CapabilityModels 渠道需要探测的模型，通配符模型无法探测
+func (c *Channel) CapabilityModels() []string {
+	models := make([]string, 0)
+	for _, name := range splitBatchChannelModels(c.Models) {
+		if !strings.HasSuffix(name, "*") {
+			models = append(models, name)
+		}
+	}
+	sort.Strings(models)
+	return models
+}
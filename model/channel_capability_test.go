@@ -0,0 +1,69 @@
+package model
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelCapabilityLacks(t *testing.T) {
+	supported, unsupported := true, false
+	capability := &ChannelCapability{Tools: &supported, Vision: &unsupported}
+
+	assert.False(t, capability.Lacks(CapabilityTools))
+	assert.True(t, capability.Lacks(CapabilityVision))
+	assert.False(t, capability.Lacks(CapabilityStreaming))
+	assert.False(t, capability.Lacks("unknown"))
+}
+
+func TestFilterCapabilities(t *testing.T) {
+	unsupported := false
+	ChannelGroup.Capabilities.Store(capabilityKey(1, "gpt-test"), &ChannelCapability{ChannelId: 1, Model: "gpt-test", Vision: &unsupported})
+	t.Cleanup(func() {
+		ChannelGroup.Capabilities.Delete(capabilityKey(1, "gpt-test"))
+	})
+
+	filter := FilterCapabilities("gpt-test", []string{CapabilityTools, CapabilityVision})
+	assert.True(t, filter(1, nil))
+	// 未探测的渠道不跳过
+	assert.False(t, filter(2, nil))
+
+	filter = FilterCapabilities("gpt-test", []string{CapabilityTools})
+	assert.False(t, filter(1, nil))
+}
+
+func TestChannelCapabilityModels(t *testing.T) {
+	channel := &Channel{Models: "gpt-4o, claude-*,gpt-4o-mini"}
+	assert.Equal(t, []string{"gpt-4o", "gpt-4o-mini"}, channel.CapabilityModels())
+}
+
+func TestObserveContextConcurrent(t *testing.T) {
+	supported := true
+	cc := &ChannelsChooser{}
+	cc.Capabilities.Store(capabilityKey(1, "gpt-test"), &ChannelCapability{ChannelId: 1, Model: "gpt-test", Tools: &supported, MaxContext: 10})
+	t.Cleanup(func() {
+		contextObservationLock.Lock()
+		contextObservations = make(map[string]*ChannelCapability)
+		contextObservationLock.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 100; i++ {
+		wg.Add(1)
+		go func(tokens int) {
+			defer wg.Done()
+			cc.ObserveContext(1, "gpt-test", tokens)
+		}(i * 10)
+	}
+	wg.Wait()
+
+	capability := cc.getCapability(1, "gpt-test")
+	assert.Equal(t, 1000, capability.MaxContext)
+	// 探测结果不会因为更新 max_context 丢失
+	assert.False(t, capability.Lacks(CapabilityTools))
+
+	contextObservationLock.Lock()
+	defer contextObservationLock.Unlock()
+	assert.Equal(t, 1000, contextObservations[capabilityKey(1, "gpt-test")].MaxContext)
+}
@@ -0,0 +1,330 @@
+package model
+
+import (
+	"czloapi/common/credential"
+	"czloapi/common/logger"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	KeyPoolStrategyRoundRobin = "round_robin"
+	KeyPoolStrategyLeastUsed  = "least_used"
+)
+
+const (
+	// 账号池 key 默认冷却时间
+	keyPoolDefaultCooldown = 60
+	// 连续多少次 401/403 视为 key 已被吊销
+	keyPoolDefaultRevokeThreshold = 3
+)
+
+// ChannelKeyPool 账号池配置，启用后渠道的 Key 按行存放多个上游 key，每次请求选择其中一个
+type ChannelKeyPool struct {
+	Enabled         bool   `json:"enabled"`
+	Strategy        string `json:"strategy,omitempty"`         // round_robin（默认）或 least_used
+	CooldownSeconds int    `json:"cooldown_seconds,omitempty"` // 429/401/403 后的冷却时间
+	RevokeThreshold int    `json:"revoke_threshold,omitempty"` // 连续 401/403 达到该次数后从账号池移除，小于 0 表示不移除
+}
+
+func (p *ChannelKeyPool) Validate() error {
+	if p.Strategy != "" && p.Strategy != KeyPoolStrategyRoundRobin && p.Strategy != KeyPoolStrategyLeastUsed {
+		return fmt.Errorf("无效的账号池策略 %s", p.Strategy)
+	}
+	if p.CooldownSeconds < 0 {
+		return errors.New("账号池冷却时间不能小于 0")
+	}
+	return nil
+}
+
+func (p *ChannelKeyPool) cooldown() time.Duration {
+	if p.CooldownSeconds > 0 {
+		return time.Duration(p.CooldownSeconds) * time.Second
+	}
+	return keyPoolDefaultCooldown * time.Second
+}
+
+func (p *ChannelKeyPool) revokeThreshold() int {
+	if p.RevokeThreshold != 0 {
+		return p.RevokeThreshold
+	}
+	return keyPoolDefaultRevokeThreshold
+}
+
+// GetKeyPool 获取账号池配置，未启用时返回 nil
+func (c *Channel) GetKeyPool() *ChannelKeyPool {
+	if c.KeyPool == nil {
+		return nil
+	}
+
+	pool := c.KeyPool.Data()
+	if !pool.Enabled {
+		return nil
+	}
+	return &pool
+}
+
+func (c *Channel) ValidateKeyPool() error {
+	if pool := c.GetKeyPool(); pool != nil {
+		return pool.Validate()
+	}
+	return nil
+}
+
+// PoolKeys 账号池中的全部 key
+func (c *Channel) PoolKeys() []string {
+	keys := make([]string, 0)
+	for _, key := range strings.Split(c.Key, "\n") {
+		key = strings.TrimSpace(key)
+		if key != "" && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// WithKey 返回使用指定 key 的渠道副本
+func (c *Channel) WithKey(key string) *Channel {
+	channel := *c
+	channel.Key = key
+	return &channel
+}
+
+// SingleKey 启用账号池时返回使用第一个 key 的渠道副本，用于测试、查询余额等管理操作
+func (c *Channel) SingleKey() *Channel {
+	if c.GetKeyPool() == nil {
+		return c
+	}
+
+	keys := c.PoolKeys()
+	if len(keys) == 0 {
+		return c
+	}
+	return c.WithKey(keys[0])
+}
+
+func MaskKey(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+	return key[:4] + "****" + key[len(key)-4:]
+}
+
+// poolKeyState 单个 key 的使用情况
+type poolKeyState struct {
+	Requests      int64
+	Failures      int64
+	Unauthorized  int // 连续 401/403 次数
+	CooldownUntil time.Time
+	LastUsed      time.Time
+}
+
+type channelKeyPoolState struct {
+	next int
+	keys map[string]*poolKeyState
+}
+
+// KeyPoolManager 维护各渠道账号池中 key 的冷却和使用计数，仅保存在当前节点内存中
+type KeyPoolManager struct {
+	sync.Mutex
+	pools map[int]*channelKeyPoolState
+	// 移除 key 的处理函数，默认写回数据库
+	remove func(channelId int, key string)
+}
+
+var GlobalKeyPool = NewKeyPoolManager()
+
+func NewKeyPoolManager() *KeyPoolManager {
+	return &KeyPoolManager{
+		pools:  make(map[int]*channelKeyPoolState),
+		remove: removeChannelPoolKey,
+	}
+}
+
+func (m *KeyPoolManager) getPool(channelId int) *channelKeyPoolState {
+	pool, ok := m.pools[channelId]
+	if !ok {
+		pool = &channelKeyPoolState{keys: make(map[string]*poolKeyState)}
+		m.pools[channelId] = pool
+	}
+	return pool
+}
+
+func (p *channelKeyPoolState) getKey(key string) *poolKeyState {
+	state, ok := p.keys[key]
+	if !ok {
+		state = &poolKeyState{}
+		p.keys[key] = state
+	}
+	return state
+}
+
+// Pick 按策略选择一个未尝试过的 key，优先选择不在冷却中的，全部冷却时选择最早结束冷却的
+func (m *KeyPoolManager) Pick(channel *Channel, tried []string) (string, bool) {
+	poolConfig := channel.GetKeyPool()
+	if poolConfig == nil {
+		return "", false
+	}
+
+	keys := channel.PoolKeys()
+	candidates := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !slices.Contains(tried, key) {
+			candidates = append(candidates, key)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	pool := m.getPool(channel.Id)
+	now := time.Now()
+
+	var picked string
+	switch poolConfig.Strategy {
+	case KeyPoolStrategyLeastUsed:
+		for _, key := range candidates {
+			state := pool.getKey(key)
+			if state.CooldownUntil.After(now) {
+				continue
+			}
+			if picked == "" || state.Requests < pool.keys[picked].Requests {
+				picked = key
+			}
+		}
+	default:
+		for i := range keys {
+			index := (pool.next + i) % len(keys)
+			key := keys[index]
+			if slices.Contains(tried, key) || pool.getKey(key).CooldownUntil.After(now) {
+				continue
+			}
+			picked = key
+			pool.next = index + 1
+			break
+		}
+	}
+
+	if picked == "" {
+		for _, key := range candidates {
+			if picked == "" || pool.getKey(key).CooldownUntil.Before(pool.keys[picked].CooldownUntil) {
+				picked = key
+			}
+		}
+	}
+
+	state := pool.getKey(picked)
+	state.Requests++
+	state.LastUsed = now
+	return picked, true
+}
+
+// RecordResult 记录 key 的请求结果，429/401/403 进入冷却，连续 401/403 达到阈值后从账号池移除
+func (m *KeyPoolManager) RecordResult(channel *Channel, statusCode int) {
+	poolConfig := channel.GetKeyPool()
+	if poolConfig == nil || channel.Key == "" {
+		return
+	}
+
+	m.Lock()
+	state := m.getPool(channel.Id).getKey(channel.Key)
+	if statusCode == http.StatusOK {
+		state.Unauthorized = 0
+		m.Unlock()
+		return
+	}
+
+	state.Failures++
+	unauthorized := statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden
+	if unauthorized {
+		state.Unauthorized++
+	}
+	if unauthorized || statusCode == http.StatusTooManyRequests {
+		state.CooldownUntil = time.Now().Add(poolConfig.cooldown())
+	}
+	revoked := unauthorized && poolConfig.revokeThreshold() > 0 && state.Unauthorized >= poolConfig.revokeThreshold()
+	if revoked {
+		delete(m.pools[channel.Id].keys, channel.Key)
+	}
+	m.Unlock()
+
+	if revoked && m.remove != nil {
+		m.remove(channel.Id, channel.Key)
+	}
+}
+
+// removeChannelPoolKey 从渠道的账号池中移除 key 并重新加载渠道，账号池只剩一个 key 时保留，交由渠道自动禁用处理
+// 账号池中的 key 已解析密钥引用，数据库中可能是 env:/file: 引用，需要解析后比较
+func removeChannelPoolKey(channelId int, key string) {
+	channel, err := GetChannelById(channelId)
+	if err != nil {
+		return
+	}
+
+	keys := channel.PoolKeys()
+	index := slices.IndexFunc(keys, func(k string) bool {
+		resolved, err := credential.ResolveSecrets(k)
+		return err == nil && resolved == key
+	})
+	if len(keys) <= 1 || index < 0 {
+		return
+	}
+	keys = slices.Delete(keys, index, index+1)
+
+	// 按列更新不经过序列化器，需要手动加密
+	encrypted, err := credential.Encrypt(strings.Join(keys, "\n"))
+	if err != nil {
+		logger.SysError(fmt.Sprintf("failed to encrypt key pool of channel #%d: %s", channelId, err.Error()))
+		return
+	}
+	if err := DB.Model(&Channel{}).Where("id = ?", channelId).Update("key", encrypted).Error; err != nil {
+		logger.SysError(fmt.Sprintf("failed to remove revoked key from channel #%d: %s", channelId, err.Error()))
+		return
+	}
+	logger.SysLog(fmt.Sprintf("channel #%d(%s) key %s revoked, removed from key pool", channelId, channel.Name, MaskKey(key)))
+	ChannelGroup.Load()
+}
+
+type PoolKeyStatus struct {
+	Key           string `json:"key"`
+	Requests      int64  `json:"requests"`
+	Failures      int64  `json:"failures"`
+	Unauthorized  int    `json:"unauthorized"`
+	CooldownUntil int64  `json:"cooldown_until"`
+	LastUsed      int64  `json:"last_used"`
+}
+
+// GetStatus 获取渠道账号池中各 key 的使用情况，key 已脱敏
+func (m *KeyPoolManager) GetStatus(channel *Channel) []*PoolKeyStatus {
+	m.Lock()
+	defer m.Unlock()
+
+	pool := m.getPool(channel.Id)
+	result := make([]*PoolKeyStatus, 0)
+	for _, key := range channel.PoolKeys() {
+		state := pool.getKey(key)
+		status := &PoolKeyStatus{
+			Key:          MaskKey(key),
+			Requests:     state.Requests,
+			Failures:     state.Failures,
+			Unauthorized: state.Unauthorized,
+		}
+		if !state.CooldownUntil.IsZero() {
+			status.CooldownUntil = state.CooldownUntil.Unix()
+		}
+		if !state.LastUsed.IsZero() {
+			status.LastUsed = state.LastUsed.Unix()
+		}
+		result = append(result, status)
+	}
+
+	return result
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func newKeyPoolTestChannel(pool ChannelKeyPool, key string) *Channel {
+	poolJSON := datatypes.NewJSONType(pool)
+	return &Channel{
+		Id:      1,
+		Key:     key,
+		KeyPool: &poolJSON,
+	}
+}
+
+func TestKeyPoolRoundRobin(t *testing.T) {
+	manager := NewKeyPoolManager()
+	channel := newKeyPoolTestChannel(ChannelKeyPool{Enabled: true}, "k1\nk2\n\nk3\n")
+
+	picked := make([]string, 0, 4)
+	for i := 0; i < 4; i++ {
+		key, ok := manager.Pick(channel, nil)
+		assert.True(t, ok)
+		picked = append(picked, key)
+	}
+	assert.Equal(t, []string{"k1", "k2", "k3", "k1"}, picked)
+
+	key, ok := manager.Pick(channel, []string{"k2", "k3"})
+	assert.True(t, ok)
+	assert.Equal(t, "k1", key)
+
+	_, ok = manager.Pick(channel, []string{"k1", "k2", "k3"})
+	assert.False(t, ok)
+}
+
+func TestKeyPoolLeastUsedSkipsCooldown(t *testing.T) {
+	manager := NewKeyPoolManager()
+	channel := newKeyPoolTestChannel(ChannelKeyPool{Enabled: true, Strategy: KeyPoolStrategyLeastUsed}, "k1\nk2")
+
+	key, _ := manager.Pick(channel, nil)
+	assert.Equal(t, "k1", key)
+	key, _ = manager.Pick(channel, nil)
+	assert.Equal(t, "k2", key)
+
+	manager.RecordResult(channel.WithKey("k1"), http.StatusTooManyRequests)
+	for i := 0; i < 3; i++ {
+		key, _ = manager.Pick(channel, nil)
+		assert.Equal(t, "k2", key)
+	}
+
+	// 全部冷却时仍返回最早结束冷却的 key
+	manager.RecordResult(channel.WithKey("k2"), http.StatusTooManyRequests)
+	key, ok := manager.Pick(channel, nil)
+	assert.True(t, ok)
+	assert.Equal(t, "k1", key)
+}
+
+func TestKeyPoolRevokesUnauthorizedKey(t *testing.T) {
+	manager := NewKeyPoolManager()
+	var removed []string
+	manager.remove = func(_ int, key string) {
+		removed = append(removed, key)
+	}
+	channel := newKeyPoolTestChannel(ChannelKeyPool{Enabled: true, RevokeThreshold: 2}, "k1\nk2")
+
+	manager.RecordResult(channel.WithKey("k1"), http.StatusUnauthorized)
+	manager.RecordResult(channel.WithKey("k1"), http.StatusOK)
+	manager.RecordResult(channel.WithKey("k1"), http.StatusUnauthorized)
+	assert.Empty(t, removed)
+
+	manager.RecordResult(channel.WithKey("k1"), http.StatusUnauthorized)
+	assert.Equal(t, []string{"k1"}, removed)
+
+	status := manager.GetStatus(channel)
+	assert.Len(t, status, 2)
+	assert.Equal(t, int64(0), status[0].Failures)
+}
+
+func TestChannelSingleKey(t *testing.T) {
+	channel := newKeyPoolTestChannel(ChannelKeyPool{Enabled: true}, "sk-first\nsk-second")
+	assert.Equal(t, "sk-first", channel.SingleKey().Key)
+	assert.Equal(t, "sk-first\nsk-second", channel.Key)
+
+	channel = newKeyPoolTestChannel(ChannelKeyPool{}, "sk-first\nsk-second")
+	assert.Same(t, channel, channel.SingleKey())
+	assert.Nil(t, channel.GetKeyPool())
+}
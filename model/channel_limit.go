@@ -0,0 +1,336 @@
+package model
+
+import (
+	"czloapi/common/config"
+	"czloapi/common/limit"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrChannelSaturated = errors.New("channel saturated")
+
+// ChannelLimit 上游账号自身的限制，0 表示不限制
+type ChannelLimit struct {
+	Concurrency int `json:"concurrency"`
+	RPM         int `json:"rpm"`
+	TPM         int `json:"tpm"`
+}
+
+func (l ChannelLimit) IsEmpty() bool {
+	return l.Concurrency <= 0 && l.RPM <= 0 && l.TPM <= 0
+}
+
+// ChannelLimits 渠道级限制，Models 中可按模型单独设置，两者同时生效
+type ChannelLimits struct {
+	ChannelLimit
+	Models map[string]ChannelLimit `json:"models,omitempty"`
+}
+
+// GetLimits 返回渠道级和模型级的限制
+func (c *Channel) GetLimits(modelName string) (channelLimit ChannelLimit, modelLimit ChannelLimit) {
+	if c.Limits == nil {
+		return
+	}
+
+	limits := c.Limits.Data()
+	channelLimit = limits.ChannelLimit
+	if limits.Models != nil {
+		modelLimit = limits.Models[modelName]
+	}
+	return
+}
+
+type channelLimitState struct {
+	concurrency atomic.Int64
+	rpm         int
+	rpmLimiter  limit.RateLimiter
+	tpm         int
+	tpmLimiter  limit.RateLimiter
+}
+
+// ChannelLimiter 维护各渠道的并发、RPM、TPM 使用情况
+// 并发数为进程内计数，RPM/TPM 使用 common/limit 的限流器（启用 Redis 时多节点共享）
+type ChannelLimiter struct {
+	sync.Mutex
+	states  map[string]*channelLimitState
+	notify  chan struct{}
+	waiting atomic.Int64
+}
+
+var GlobalChannelLimiter = NewChannelLimiter()
+
+func NewChannelLimiter() *ChannelLimiter {
+	return &ChannelLimiter{
+		states: make(map[string]*channelLimitState),
+		notify: make(chan struct{}),
+	}
+}
+
+func channelLimitKey(channelId int, modelName string) string {
+	if modelName == "" {
+		return fmt.Sprintf("channel_limit:%d", channelId)
+	}
+	return fmt.Sprintf("channel_limit:%d:%s", channelId, modelName)
+}
+
+// getState 获取限制状态，配置变化时重建对应的限流器
+func (l *ChannelLimiter) getState(key string, channelLimit ChannelLimit) *channelLimitState {
+	l.Lock()
+	defer l.Unlock()
+
+	state, ok := l.states[key]
+	if !ok {
+		state = &channelLimitState{}
+		l.states[key] = state
+	}
+
+	if state.rpm != channelLimit.RPM {
+		state.rpm = channelLimit.RPM
+		stopLimiter(state.rpmLimiter)
+		state.rpmLimiter = nil
+		if channelLimit.RPM > 0 {
+			state.rpmLimiter = limit.NewAPILimiter(channelLimit.RPM)
+		}
+	}
+
+	if state.tpm != channelLimit.TPM {
+		state.tpm = channelLimit.TPM
+		stopLimiter(state.tpmLimiter)
+		state.tpmLimiter = nil
+		if channelLimit.TPM > 0 {
+			state.tpmLimiter = limit.NewAPILimiter(channelLimit.TPM)
+		}
+	}
+
+	return state
+}
+
+// stopLimiter 停止内存限流器的清理协程
+func stopLimiter(limiter limit.RateLimiter) {
+	if memoryLimiter, ok := limiter.(*limit.MemoryLimiter); ok {
+		memoryLimiter.Stop()
+	}
+}
+
+// scopes 返回需要检查的限制：渠道级和模型级
+func (l *ChannelLimiter) scopes(channel *Channel, modelName string) map[string]ChannelLimit {
+	channelLimit, modelLimit := channel.GetLimits(modelName)
+	scopes := make(map[string]ChannelLimit, 2)
+	if !channelLimit.IsEmpty() {
+		scopes[channelLimitKey(channel.Id, "")] = channelLimit
+	}
+	if !modelLimit.IsEmpty() {
+		scopes[channelLimitKey(channel.Id, modelName)] = modelLimit
+	}
+	return scopes
+}
+
+func isRateSaturated(limiter limit.RateLimiter, key string, max int) bool {
+	if limiter == nil {
+		return false
+	}
+	current, err := limiter.GetCurrentRate(key)
+	if err != nil {
+		return false
+	}
+	return current >= max
+}
+
+// IsSaturated 判断渠道是否已达到上游限制，只用于快速过滤，实际占用名额使用 TryAcquire
+func (l *ChannelLimiter) IsSaturated(channel *Channel, modelName string) bool {
+	for key, channelLimit := range l.scopes(channel, modelName) {
+		state := l.getState(key, channelLimit)
+		if channelLimit.Concurrency > 0 && state.concurrency.Load() >= int64(channelLimit.Concurrency) {
+			return true
+		}
+		if isRateSaturated(state.rpmLimiter, key+":rpm", channelLimit.RPM) {
+			return true
+		}
+		if isRateSaturated(state.tpmLimiter, key+":tpm", channelLimit.TPM) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tryIncrement 并发数未达到上限时加一
+func (s *channelLimitState) tryIncrement(max int64) bool {
+	for {
+		current := s.concurrency.Load()
+		if current >= max {
+			return false
+		}
+		if s.concurrency.CompareAndSwap(current, current+1) {
+			return true
+		}
+	}
+}
+
+// TryAcquire 选择渠道时调用，原子地占用并发名额并计入 RPM，任一限制已满时返回 false
+// 返回的函数用于释放并发名额，可重复调用；RPM 无法回退，渠道级和模型级同时限制时可能多计一次
+func (l *ChannelLimiter) TryAcquire(channel *Channel, modelName string) (func(), bool) {
+	scopes := l.scopes(channel, modelName)
+	if len(scopes) == 0 {
+		return func() {}, true
+	}
+
+	states := make(map[string]*channelLimitState, len(scopes))
+	acquired := make([]*channelLimitState, 0, len(scopes))
+	rollback := func() {
+		for _, state := range acquired {
+			state.concurrency.Add(-1)
+		}
+		if len(acquired) > 0 {
+			l.wake()
+		}
+	}
+
+	// TPM 在请求完成后才知道用量，这里只检查是否已满
+	for key, channelLimit := range scopes {
+		state := l.getState(key, channelLimit)
+		states[key] = state
+		if isRateSaturated(state.tpmLimiter, key+":tpm", channelLimit.TPM) {
+			rollback()
+			return nil, false
+		}
+		if channelLimit.Concurrency > 0 {
+			if !state.tryIncrement(int64(channelLimit.Concurrency)) {
+				rollback()
+				return nil, false
+			}
+			acquired = append(acquired, state)
+		}
+	}
+
+	for key, state := range states {
+		if state.rpmLimiter != nil && !state.rpmLimiter.Allow(key+":rpm") {
+			rollback()
+			return nil, false
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			for _, state := range acquired {
+				state.concurrency.Add(-1)
+			}
+			l.wake()
+		})
+	}, true
+}
+
+// Prune 渠道重新加载后调用，移除已删除的渠道或已取消的限制对应的状态，并停止其限流器
+// 仍被占用的名额在释放时只作用于已移除的状态，不影响新状态
+func (l *ChannelLimiter) Prune(channels []*Channel) {
+	active := make(map[string]bool)
+	for _, channel := range channels {
+		if channel.Limits == nil {
+			continue
+		}
+		limits := channel.Limits.Data()
+		if !limits.ChannelLimit.IsEmpty() {
+			active[channelLimitKey(channel.Id, "")] = true
+		}
+		for modelName, modelLimit := range limits.Models {
+			if !modelLimit.IsEmpty() {
+				active[channelLimitKey(channel.Id, modelName)] = true
+			}
+		}
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	for key, state := range l.states {
+		if active[key] {
+			continue
+		}
+		stopLimiter(state.rpmLimiter)
+		stopLimiter(state.tpmLimiter)
+		delete(l.states, key)
+	}
+}
+
+// RecordTokens 请求完成后计入 TPM，超出剩余额度时直接占满，使渠道进入饱和状态
+func (l *ChannelLimiter) RecordTokens(channel *Channel, modelName string, tokens int) {
+	if tokens <= 0 {
+		return
+	}
+
+	for key, channelLimit := range l.scopes(channel, modelName) {
+		state := l.getState(key, channelLimit)
+		if state.tpmLimiter == nil {
+			continue
+		}
+		tpmKey := key + ":tpm"
+		if state.tpmLimiter.AllowN(tpmKey, tokens) {
+			continue
+		}
+		current, err := state.tpmLimiter.GetCurrentRate(tpmKey)
+		if err == nil && channelLimit.TPM > current {
+			state.tpmLimiter.AllowN(tpmKey, channelLimit.TPM-current)
+		}
+	}
+}
+
+// wake 通知排队中的请求重新尝试选择渠道
+func (l *ChannelLimiter) wake() {
+	if l.waiting.Load() == 0 {
+		return
+	}
+
+	l.Lock()
+	close(l.notify)
+	l.notify = make(chan struct{})
+	l.Unlock()
+}
+
+func (l *ChannelLimiter) waitChan() <-chan struct{} {
+	l.Lock()
+	defer l.Unlock()
+	return l.notify
+}
+
+// Wait 所有候选渠道均饱和时排队等待，直到 retry 返回已占用名额的渠道、超时或队列已满
+func (l *ChannelLimiter) Wait(retry func() (*Channel, func(), bool, error)) (*Channel, func(), error) {
+	timeout := time.Duration(config.ChannelQueueTimeout) * time.Millisecond
+	if timeout <= 0 {
+		return nil, nil, ErrChannelSaturated
+	}
+
+	if l.waiting.Add(1) > int64(config.ChannelQueueSize) {
+		l.waiting.Add(-1)
+		return nil, nil, ErrChannelSaturated
+	}
+	defer l.waiting.Add(-1)
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		// 先获取通知通道再重试，避免错过两者之间的释放
+		notify := l.waitChan()
+		channel, release, saturated, err := retry()
+		if channel != nil {
+			return channel, release, nil
+		}
+		if !saturated {
+			return nil, nil, err
+		}
+
+		// RPM/TPM 的恢复不会触发通知，定期轮询
+		poll := time.NewTimer(500 * time.Millisecond)
+		select {
+		case <-notify:
+		case <-poll.C:
+		case <-deadline.C:
+			poll.Stop()
+			return nil, nil, ErrChannelSaturated
+		}
+		poll.Stop()
+	}
+}
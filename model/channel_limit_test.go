@@ -0,0 +1,216 @@
+package model
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"czloapi/common/config"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func newLimitTestChannel(id int, limits ChannelLimits) *Channel {
+	weight := uint(1)
+	limitsJSON := datatypes.NewJSONType(limits)
+	return &Channel{
+		Id:     id,
+		Weight: &weight,
+		Limits: &limitsJSON,
+	}
+}
+
+func TestChannelLimiterConcurrency(t *testing.T) {
+	limiter := NewChannelLimiter()
+	channel := newLimitTestChannel(1, ChannelLimits{
+		ChannelLimit: ChannelLimit{Concurrency: 2},
+	})
+
+	release1, ok := limiter.TryAcquire(channel, "gpt-4o")
+	assert.True(t, ok)
+	assert.False(t, limiter.IsSaturated(channel, "gpt-4o"))
+	release2, ok := limiter.TryAcquire(channel, "gpt-4o-mini")
+	assert.True(t, ok)
+	assert.True(t, limiter.IsSaturated(channel, "gpt-4o"))
+	_, ok = limiter.TryAcquire(channel, "gpt-4o")
+	assert.False(t, ok)
+
+	release1()
+	release1()
+	assert.False(t, limiter.IsSaturated(channel, "gpt-4o"))
+	release2()
+}
+
+func TestChannelLimiterTryAcquireConcurrent(t *testing.T) {
+	limiter := NewChannelLimiter()
+	channel := newLimitTestChannel(1, ChannelLimits{
+		ChannelLimit: ChannelLimit{Concurrency: 3},
+	})
+
+	var current, peak, acquired atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, ok := limiter.TryAcquire(channel, "gpt-4o")
+			if !ok {
+				return
+			}
+			acquired.Add(1)
+			n := current.Add(1)
+			for {
+				old := peak.Load()
+				if n <= old || peak.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			current.Add(-1)
+			release()
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, peak.Load(), int64(3))
+	assert.Greater(t, acquired.Load(), int64(0))
+	assert.False(t, limiter.IsSaturated(channel, "gpt-4o"))
+}
+
+func TestChannelLimiterModelLimit(t *testing.T) {
+	limiter := NewChannelLimiter()
+	channel := newLimitTestChannel(1, ChannelLimits{
+		Models: map[string]ChannelLimit{
+			"gpt-4o": {RPM: 2},
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		_, ok := limiter.TryAcquire(channel, "gpt-4o")
+		assert.True(t, ok)
+	}
+
+	_, ok := limiter.TryAcquire(channel, "gpt-4o")
+	assert.False(t, ok)
+	assert.True(t, limiter.IsSaturated(channel, "gpt-4o"))
+	assert.False(t, limiter.IsSaturated(channel, "gpt-4o-mini"))
+}
+
+func TestChannelLimiterRPMRejectReleasesConcurrency(t *testing.T) {
+	limiter := NewChannelLimiter()
+	channel := newLimitTestChannel(1, ChannelLimits{
+		ChannelLimit: ChannelLimit{Concurrency: 5, RPM: 1},
+	})
+
+	release, ok := limiter.TryAcquire(channel, "gpt-4o")
+	assert.True(t, ok)
+	release()
+
+	// RPM 已满时不应占用并发名额
+	_, ok = limiter.TryAcquire(channel, "gpt-4o")
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), limiter.getState(channelLimitKey(1, ""), channel.Limits.Data().ChannelLimit).concurrency.Load())
+}
+
+func TestChannelLimiterPrune(t *testing.T) {
+	limiter := NewChannelLimiter()
+	kept := newLimitTestChannel(1, ChannelLimits{
+		ChannelLimit: ChannelLimit{RPM: 10},
+	})
+	removed := newLimitTestChannel(2, ChannelLimits{
+		Models: map[string]ChannelLimit{
+			"gpt-4o": {Concurrency: 1},
+		},
+	})
+
+	_, ok := limiter.TryAcquire(kept, "gpt-4o")
+	assert.True(t, ok)
+	release, ok := limiter.TryAcquire(removed, "gpt-4o")
+	assert.True(t, ok)
+	assert.Len(t, limiter.states, 2)
+
+	limiter.Prune([]*Channel{kept})
+	assert.Len(t, limiter.states, 1)
+	assert.Contains(t, limiter.states, channelLimitKey(1, ""))
+
+	// 移除后释放旧名额不影响新状态
+	release()
+	_, ok = limiter.TryAcquire(removed, "gpt-4o")
+	assert.True(t, ok)
+}
+
+func TestChannelLimiterTPM(t *testing.T) {
+	limiter := NewChannelLimiter()
+	channel := newLimitTestChannel(1, ChannelLimits{
+		ChannelLimit: ChannelLimit{TPM: 50},
+	})
+
+	limiter.RecordTokens(channel, "gpt-4o", 30)
+	assert.False(t, limiter.IsSaturated(channel, "gpt-4o"))
+
+	limiter.RecordTokens(channel, "gpt-4o", 30)
+	assert.True(t, limiter.IsSaturated(channel, "gpt-4o"))
+}
+
+func TestChannelLimiterWaitReleased(t *testing.T) {
+	oldTimeout := config.ChannelQueueTimeout
+	defer func() {
+		config.ChannelQueueTimeout = oldTimeout
+	}()
+	config.ChannelQueueTimeout = 2000
+
+	limiter := NewChannelLimiter()
+	channel := newLimitTestChannel(1, ChannelLimits{
+		ChannelLimit: ChannelLimit{Concurrency: 1},
+	})
+	release, ok := limiter.TryAcquire(channel, "gpt-4o")
+	assert.True(t, ok)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		release()
+	}()
+
+	start := time.Now()
+	selected, selectedRelease, err := limiter.Wait(func() (*Channel, func(), bool, error) {
+		release, ok := limiter.TryAcquire(channel, "gpt-4o")
+		if !ok {
+			return nil, nil, true, nil
+		}
+		return channel, release, false, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, channel, selected)
+	selectedRelease()
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestBalancerSkipsSaturatedChannel(t *testing.T) {
+	saturated := newLimitTestChannel(1, ChannelLimits{
+		ChannelLimit: ChannelLimit{Concurrency: 1},
+	})
+	free := newLimitTestChannel(2, ChannelLimits{})
+	cc := &ChannelsChooser{
+		Channels: map[int]*ChannelChoice{
+			1: {Channel: saturated},
+			2: {Channel: free},
+		},
+	}
+
+	release, ok := GlobalChannelLimiter.TryAcquire(saturated, "gpt-4o")
+	assert.True(t, ok)
+	defer release()
+
+	for i := 0; i < 10; i++ {
+		channel, channelRelease, _ := cc.balancer([]int{1, 2}, nil, "gpt-4o", BalanceModeWeight)
+		assert.Equal(t, free, channel)
+		channelRelease()
+	}
+
+	channel, _, isSaturated := cc.balancer([]int{1}, nil, "gpt-4o", BalanceModeWeight)
+	assert.Nil(t, channel)
+	assert.True(t, isSaturated)
+}
@@ -0,0 +1,261 @@
+package model
+
+import (
+	"czloapi/common/utils"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// 渠道模型同步策略，决定检测到上游模型变化后如何处理
+const (
+	ModelSyncPolicyManual     = "manual"      // 默认，生成待审核记录
+	ModelSyncPolicyAutoAdd    = "auto_add"    // 自动添加上游新增的模型
+	ModelSyncPolicyAutoRemove = "auto_remove" // 自动移除上游已下线的模型
+	ModelSyncPolicyAuto       = "auto"        // 自动添加和移除
+	ModelSyncPolicyOff        = "off"         // 不检测
+)
+
+const (
+	ModelDriftStatusPending   = "pending"
+	ModelDriftStatusApplied   = "applied"
+	ModelDriftStatusDismissed = "dismissed"
+)
+
+func IsValidModelSyncPolicy(policy string) bool {
+	switch policy {
+	case "", ModelSyncPolicyManual, ModelSyncPolicyAutoAdd, ModelSyncPolicyAutoRemove, ModelSyncPolicyAuto, ModelSyncPolicyOff:
+		return true
+	}
+	return false
+}
+
+func (c *Channel) ValidateModelSyncPolicy() error {
+	if !IsValidModelSyncPolicy(c.ModelSyncPolicy) {
+		return errors.New("无效的模型同步策略 " + c.ModelSyncPolicy)
+	}
+	return nil
+}
+
+// ChannelModelDrift 渠道模型与上游模型列表的差异，每个渠道最多有一条待处理记录
+type ChannelModelDrift struct {
+	Id          int                         `json:"id"`
+	ChannelId   int                         `json:"channel_id" gorm:"index"`
+	ChannelName string                      `json:"channel_name" gorm:"type:varchar(255)"`
+	Added       datatypes.JSONSlice[string] `json:"added" gorm:"type:json"`   // 上游新增、渠道未配置的模型
+	Removed     datatypes.JSONSlice[string] `json:"removed" gorm:"type:json"` // 渠道已配置、上游已下线的模型
+	Status      string                      `json:"status" gorm:"type:varchar(16);index"`
+	CreatedTime int64                       `json:"created_time" gorm:"bigint"`
+	UpdatedTime int64                       `json:"updated_time" gorm:"bigint"`
+}
+
+var allowedModelDriftOrderFields = map[string]bool{
+	"id":           true,
+	"channel_id":   true,
+	"status":       true,
+	"created_time": true,
+	"updated_time": true,
+}
+
+type SearchModelDriftParams struct {
+	ChannelId int    `form:"channel_id"`
+	Status    string `form:"status"`
+	PaginationParams
+}
+
+func GetModelDriftList(params *SearchModelDriftParams) (*DataResult[ChannelModelDrift], error) {
+	var drifts []*ChannelModelDrift
+	db := DB
+	if params.ChannelId != 0 {
+		db = db.Where("channel_id = ?", params.ChannelId)
+	}
+	if params.Status != "" {
+		db = db.Where("status = ?", params.Status)
+	}
+	if params.Order == "" {
+		params.Order = "-updated_time"
+	}
+	return PaginateAndOrder(db, &params.PaginationParams, &drifts, allowedModelDriftOrderFields)
+}
+
+func GetModelDriftById(id int) (*ChannelModelDrift, error) {
+	drift := ChannelModelDrift{}
+	err := DB.First(&drift, "id = ?", id).Error
+	return &drift, err
+}
+
+func getPendingModelDrift(channelId int) (*ChannelModelDrift, error) {
+	drift := ChannelModelDrift{}
+	err := DB.Where("channel_id = ? AND status = ?", channelId, ModelDriftStatusPending).First(&drift).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &drift, err
+}
+
+// DiffChannelModels 比较渠道模型与上游模型列表，配置了模型映射的按映射后的名称比较，通配符模型不参与比较
+func DiffChannelModels(channel *Channel, upstream []string) (added []string, removed []string) {
+	mapping := make(map[string]string)
+	if modelMapping := channel.GetModelMapping(); modelMapping != "" {
+		_ = json.Unmarshal([]byte(modelMapping), &mapping)
+	}
+
+	upstreamSet := make(map[string]bool, len(upstream))
+	for _, name := range upstream {
+		if name = strings.TrimSpace(name); name != "" {
+			upstreamSet[name] = true
+		}
+	}
+
+	served := make(map[string]bool)
+	for _, name := range splitBatchChannelModels(channel.Models) {
+		served[name] = true
+		target := name
+		if mapped := mapping[name]; mapped != "" {
+			target = mapped
+			served[mapped] = true
+		}
+		if !strings.HasSuffix(name, "*") && !upstreamSet[target] {
+			removed = append(removed, name)
+		}
+	}
+
+	for name := range upstreamSet {
+		if !served[name] {
+			added = append(added, name)
+		}
+	}
+
+	slices.Sort(added)
+	slices.Sort(removed)
+	return added, removed
+}
+
+// RecordModelDrift 记录检测结果并按渠道策略自动应用，返回相比上次新下线的模型，用于发送通知
+// 没有差异时删除待处理记录
+func RecordModelDrift(channel *Channel, added, removed []string) (newlyRemoved []string, err error) {
+	drift, err := getPendingModelDrift(channel.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(added) == 0 && len(removed) == 0 {
+		if drift != nil {
+			err = DB.Delete(drift).Error
+		}
+		return nil, err
+	}
+
+	now := utils.GetTimestamp()
+	if drift == nil {
+		// 与上次忽略的差异相同时不再重复生成
+		dismissed := ChannelModelDrift{}
+		err = DB.Where("channel_id = ? AND status = ?", channel.Id, ModelDriftStatusDismissed).Order("id desc").Limit(1).Find(&dismissed).Error
+		if err != nil {
+			return nil, err
+		}
+		if dismissed.Id != 0 && slices.Equal(dismissed.Added, added) && slices.Equal(dismissed.Removed, removed) {
+			return nil, nil
+		}
+
+		drift = &ChannelModelDrift{
+			ChannelId:   channel.Id,
+			Status:      ModelDriftStatusPending,
+			CreatedTime: now,
+		}
+	}
+	for _, name := range removed {
+		if !slices.Contains(drift.Removed, name) {
+			newlyRemoved = append(newlyRemoved, name)
+		}
+	}
+
+	drift.ChannelName = channel.Name
+	drift.Added = added
+	drift.Removed = removed
+	drift.UpdatedTime = now
+	if err = DB.Save(drift).Error; err != nil {
+		return nil, err
+	}
+
+	switch channel.ModelSyncPolicy {
+	case ModelSyncPolicyAuto:
+		err = drift.Apply(true, true)
+	case ModelSyncPolicyAutoAdd:
+		if len(added) > 0 {
+			err = drift.Apply(true, false)
+		}
+	case ModelSyncPolicyAutoRemove:
+		if len(removed) > 0 {
+			err = drift.Apply(false, true)
+		}
+	}
+
+	return newlyRemoved, err
+}
+
+// Apply 将差异应用到渠道模型，add 和 remove 分别表示是否应用新增和下线的模型
+func (d *ChannelModelDrift) Apply(add, remove bool) error {
+	if d.Status != ModelDriftStatusPending {
+		return errors.New("该记录已处理")
+	}
+
+	channel, err := GetChannelById(d.ChannelId)
+	if err != nil {
+		return err
+	}
+
+	models := splitBatchChannelModels(channel.Models)
+	if add {
+		for _, name := range d.Added {
+			if !slices.Contains(models, name) {
+				models = append(models, name)
+			}
+		}
+	}
+	if remove {
+		models = slices.DeleteFunc(models, func(name string) bool {
+			return slices.Contains(d.Removed, name)
+		})
+	}
+	if len(models) == 0 {
+		return errors.New("应用后渠道没有可用模型")
+	}
+
+	// 全部应用后保留差异内容作为记录，只应用一部分时剩余部分继续等待处理
+	if (add || len(d.Added) == 0) && (remove || len(d.Removed) == 0) {
+		d.Status = ModelDriftStatusApplied
+	} else if add {
+		d.Added = nil
+	} else if remove {
+		d.Removed = nil
+	}
+	d.UpdatedTime = utils.GetTimestamp()
+
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Channel{}).Where("id = ?", d.ChannelId).Update("models", strings.Join(models, ",")).Error; err != nil {
+			return err
+		}
+		return tx.Save(d).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	ChannelGroup.Load()
+	return nil
+}
+
+func (d *ChannelModelDrift) Dismiss() error {
+	if d.Status != ModelDriftStatusPending {
+		return errors.New("该记录已处理")
+	}
+
+	d.Status = ModelDriftStatusDismissed
+	d.UpdatedTime = utils.GetTimestamp()
+	return DB.Save(d).Error
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffChannelModels(t *testing.T) {
+	mapping := `{"gpt-4o-alias":"gpt-4o"}`
+	channel := &Channel{
+		Models:       "gpt-4o-alias, gpt-4o-mini,gpt-3.5-turbo,claude-*",
+		ModelMapping: &mapping,
+	}
+
+	added, removed := DiffChannelModels(channel, []string{"gpt-4o", "gpt-4o-mini", "o3", "gpt-4.1", "o3"})
+	assert.Equal(t, []string{"gpt-4.1", "o3"}, added)
+	assert.Equal(t, []string{"gpt-3.5-turbo"}, removed)
+
+	added, removed = DiffChannelModels(channel, []string{"gpt-4o", "gpt-4o-mini", "gpt-3.5-turbo"})
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+}
+
+func TestIsValidModelSyncPolicy(t *testing.T) {
+	assert.True(t, IsValidModelSyncPolicy(""))
+	assert.True(t, IsValidModelSyncPolicy(ModelSyncPolicyAutoAdd))
+	assert.False(t, IsValidModelSyncPolicy("sometimes"))
+}
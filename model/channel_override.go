@@ -0,0 +1,222 @@
+package model
+
+import (
+	"bytes"
+	"czloapi/common/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// 覆盖规则的操作类型
+const (
+	OverrideOpSet          = "set"           // 设置 Path 的值为 Value
+	OverrideOpRemove       = "remove"        // 删除 Path
+	OverrideOpRename       = "rename"        // 将 From 的值移动到 Path
+	OverrideOpClamp        = "clamp"         // 将 Path 的数值限制在 [Min, Max] 之间
+	OverrideOpSetHeader    = "set_header"    // 设置请求头 Header 为 Value
+	OverrideOpRemoveHeader = "remove_header" // 删除请求头 Header
+)
+
+// OverrideRule 渠道的上游请求覆盖规则，按顺序应用，路径使用 JSON Pointer（如 /max_tokens、/messages/0/role）
+// Models 为空时对所有模型生效，支持 * 结尾的前缀匹配，匹配的是用户请求的模型名称
+type OverrideRule struct {
+	Models []string `json:"models,omitempty"`
+	Op     string   `json:"op"`
+	Path   string   `json:"path,omitempty"`
+	From   string   `json:"from,omitempty"`
+	Header string   `json:"header,omitempty"`
+	Value  any      `json:"value,omitempty"`
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+}
+
+func (r *OverrideRule) Validate() error {
+	switch r.Op {
+	case OverrideOpSet, OverrideOpRemove, OverrideOpClamp:
+		if _, err := utils.ParseJSONPointer(r.Path); err != nil {
+			return fmt.Errorf("覆盖规则 %s 的路径无效: %s", r.Op, err.Error())
+		}
+	case OverrideOpRename:
+		if _, err := utils.ParseJSONPointer(r.Path); err != nil {
+			return fmt.Errorf("覆盖规则 %s 的路径无效: %s", r.Op, err.Error())
+		}
+		if _, err := utils.ParseJSONPointer(r.From); err != nil {
+			return fmt.Errorf("覆盖规则 %s 的源路径无效: %s", r.Op, err.Error())
+		}
+	case OverrideOpSetHeader, OverrideOpRemoveHeader:
+		if strings.TrimSpace(r.Header) == "" {
+			return fmt.Errorf("覆盖规则 %s 没有设置请求头名称", r.Op)
+		}
+	default:
+		return fmt.Errorf("覆盖规则的操作 %s 无效", r.Op)
+	}
+
+	if r.Op == OverrideOpSetHeader {
+		if _, ok := r.Value.(string); !ok {
+			return errors.New("请求头的值必须是字符串")
+		}
+	}
+	if r.Op == OverrideOpClamp {
+		if r.Min == nil && r.Max == nil {
+			return fmt.Errorf("覆盖规则 clamp %s 没有设置上下限", r.Path)
+		}
+		if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+			return fmt.Errorf("覆盖规则 clamp %s 的下限大于上限", r.Path)
+		}
+	}
+	return nil
+}
+
+// Matches 判断规则是否对该模型生效
+func (r *OverrideRule) Matches(modelName string) bool {
+	if len(r.Models) == 0 {
+		return true
+	}
+	for _, pattern := range r.Models {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(modelName, prefix) {
+				return true
+			}
+		} else if pattern == modelName {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Channel) GetOverrideRules() []OverrideRule {
+	if c.OverrideRules == nil {
+		return nil
+	}
+	return *c.OverrideRules
+}
+
+func (c *Channel) ValidateOverrideRules() error {
+	for _, rule := range c.GetOverrideRules() {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyOverrides 按规则修改上游请求的请求头和请求体，返回修改后的请求体
+// 只处理 JSON 请求体，表单等流式请求体只应用请求头规则
+func (c *Channel) ApplyOverrides(modelName string, header http.Header, body any) (any, error) {
+	var doc any
+	parsed, isJSON := false, false
+
+	for _, rule := range c.GetOverrideRules() {
+		if !rule.Matches(modelName) {
+			continue
+		}
+
+		switch rule.Op {
+		case OverrideOpSetHeader:
+			header.Set(rule.Header, rule.Value.(string))
+			continue
+		case OverrideOpRemoveHeader:
+			header.Del(rule.Header)
+			continue
+		}
+
+		if !parsed {
+			doc, isJSON = decodeOverrideBody(body)
+			parsed = true
+		}
+		if !isJSON {
+			continue
+		}
+
+		var err error
+		if doc, err = rule.applyBody(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	if !isJSON {
+		return body, nil
+	}
+	return doc, nil
+}
+
+// decodeOverrideBody 将请求体转换为可修改的 JSON 文档，数字保留原始精度
+func decodeOverrideBody(body any) (any, bool) {
+	var data []byte
+	switch v := body.(type) {
+	case nil, io.Reader:
+		return nil, false
+	case []byte:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, false
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, false
+	}
+	if _, ok := doc.(map[string]any); !ok {
+		return nil, false
+	}
+	return doc, true
+}
+
+func (r *OverrideRule) applyBody(doc any) (any, error) {
+	path, _ := utils.ParseJSONPointer(r.Path)
+
+	switch r.Op {
+	case OverrideOpSet:
+		return utils.JSONPointerSet(doc, path, r.Value)
+	case OverrideOpRemove:
+		return utils.JSONPointerRemove(doc, path), nil
+	case OverrideOpRename:
+		from, _ := utils.ParseJSONPointer(r.From)
+		value, ok := utils.JSONPointerGet(doc, from)
+		if !ok {
+			return doc, nil
+		}
+		return utils.JSONPointerSet(utils.JSONPointerRemove(doc, from), path, value)
+	case OverrideOpClamp:
+		value, ok := utils.JSONPointerGet(doc, path)
+		if !ok {
+			return doc, nil
+		}
+		number, ok := overrideNumber(value)
+		if !ok {
+			return doc, nil
+		}
+		clamped := number
+		if r.Min != nil && clamped < *r.Min {
+			clamped = *r.Min
+		}
+		if r.Max != nil && clamped > *r.Max {
+			clamped = *r.Max
+		}
+		if clamped == number {
+			return doc, nil
+		}
+		return utils.JSONPointerSet(doc, path, clamped)
+	}
+	return doc, nil
+}
+
+func overrideNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		number, err := v.Float64()
+		return number, err == nil
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
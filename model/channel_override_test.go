@@ -0,0 +1,70 @@
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	maxTokens, minTemperature := 4096.0, 0.1
+	rules := datatypes.JSONSlice[OverrideRule]{
+		{Op: OverrideOpSet, Path: "/metadata/source", Value: "gateway"},
+		{Op: OverrideOpRemove, Path: "/user"},
+		{Op: OverrideOpRename, From: "/max_tokens", Path: "/max_completion_tokens"},
+		{Op: OverrideOpClamp, Path: "/max_completion_tokens", Max: &maxTokens},
+		{Op: OverrideOpClamp, Path: "/temperature", Min: &minTemperature, Models: []string{"o3*"}},
+		{Op: OverrideOpSetHeader, Header: "X-Region", Value: "us"},
+		{Op: OverrideOpRemoveHeader, Header: "User-Agent"},
+	}
+	channel := &Channel{OverrideRules: &rules}
+
+	header := http.Header{}
+	header.Set("User-Agent", "test")
+	body := map[string]any{"model": "gpt-4o", "user": "u1", "max_tokens": 10000, "temperature": 0, "seed": 9007199254740993}
+
+	result, err := channel.ApplyOverrides("gpt-4o", header, body)
+	assert.NoError(t, err)
+	assert.Equal(t, "us", header.Get("X-Region"))
+	assert.Empty(t, header.Get("User-Agent"))
+
+	data, _ := json.Marshal(result)
+	var got map[string]any
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	assert.NoError(t, decoder.Decode(&got))
+	assert.Equal(t, map[string]any{"source": "gateway"}, got["metadata"])
+	assert.NotContains(t, got, "user")
+	assert.NotContains(t, got, "max_tokens")
+	assert.Equal(t, json.Number("4096"), got["max_completion_tokens"])
+	// 模型不匹配的规则不生效，数字保持原始精度
+	assert.Equal(t, json.Number("0"), got["temperature"])
+	assert.Equal(t, json.Number("9007199254740993"), got["seed"])
+}
+
+func TestApplyOverridesNonJSONBody(t *testing.T) {
+	rules := datatypes.JSONSlice[OverrideRule]{
+		{Op: OverrideOpRemove, Path: "/user"},
+		{Op: OverrideOpSetHeader, Header: "X-Region", Value: "us"},
+	}
+	channel := &Channel{OverrideRules: &rules}
+
+	header := http.Header{}
+	body := strings.NewReader("form data")
+	result, err := channel.ApplyOverrides("whisper-1", header, body)
+	assert.NoError(t, err)
+	assert.Equal(t, body, result)
+	assert.Equal(t, "us", header.Get("X-Region"))
+}
+
+func TestOverrideRuleValidate(t *testing.T) {
+	assert.NoError(t, (&OverrideRule{Op: OverrideOpSet, Path: "/a~1b/0", Value: 1}).Validate())
+	assert.Error(t, (&OverrideRule{Op: OverrideOpSet, Path: "max_tokens"}).Validate())
+	assert.Error(t, (&OverrideRule{Op: OverrideOpClamp, Path: "/temperature"}).Validate())
+	assert.Error(t, (&OverrideRule{Op: OverrideOpSetHeader, Header: "X-A", Value: 1}).Validate())
+	assert.Error(t, (&OverrideRule{Op: "append", Path: "/a"}).Validate())
+}
@@ -0,0 +1,171 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+)
+
+const (
+	RolloutModeCanary = "canary"
+	RolloutModeShadow = "shadow"
+)
+
+// ChannelRollout 新渠道的上线方式，Groups/Models 为空表示不限制
+// canary: 范围内的请求按 Percent 比例交给该渠道，其余请求不再按权重分配给它，范围外与普通渠道相同
+// shadow: 不承接线上流量，范围内的请求按 Percent 比例异步复制一份发给该渠道，响应只用于与线上结果比较
+type ChannelRollout struct {
+	Mode       string   `json:"mode"`
+	Percent    float64  `json:"percent"`
+	Groups     []string `json:"groups,omitempty"`
+	Models     []string `json:"models,omitempty"`
+	Similarity bool     `json:"similarity,omitempty"` // shadow 模式下是否比较输出文本的相似度
+}
+
+// Matches 判断分组和模型是否在灰度范围内，模型支持 * 结尾的前缀匹配
+func (r *ChannelRollout) Matches(group, modelName string) bool {
+	if len(r.Groups) > 0 && !slices.Contains(r.Groups, group) {
+		return false
+	}
+	if len(r.Models) == 0 {
+		return true
+	}
+
+	for _, rolloutModel := range r.Models {
+		if rolloutModel == modelName {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(rolloutModel, "*"); ok && strings.HasPrefix(modelName, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ChannelRollout) Validate() error {
+	switch r.Mode {
+	case "":
+		return nil
+	case RolloutModeCanary, RolloutModeShadow:
+	default:
+		return fmt.Errorf("无效的上线方式 %s", r.Mode)
+	}
+
+	if r.Percent <= 0 || r.Percent > 100 {
+		return errors.New("灰度比例必须在 0-100 之间")
+	}
+	return nil
+}
+
+// GetRollout 获取渠道的上线方式，未配置时返回 nil
+func (c *Channel) GetRollout() *ChannelRollout {
+	if c.Rollout == nil {
+		return nil
+	}
+
+	rollout := c.Rollout.Data()
+	if rollout.Mode == "" {
+		return nil
+	}
+	return &rollout
+}
+
+func (c *Channel) ValidateRollout() error {
+	if c.Rollout == nil {
+		return nil
+	}
+
+	rollout := c.Rollout.Data()
+	return rollout.Validate()
+}
+
+// FilterShadow 过滤影子渠道，影子渠道不承接线上流量
+func FilterShadow() ChannelsFilterFunc {
+	return func(_ int, choice *ChannelChoice) bool {
+		rollout := choice.Channel.GetRollout()
+		return rollout != nil && rollout.Mode == RolloutModeShadow
+	}
+}
+
+// filterRollout 过滤影子渠道和范围内的灰度渠道，用于正常的按权重选择
+func filterRollout(group, modelName string) ChannelsFilterFunc {
+	return func(_ int, choice *ChannelChoice) bool {
+		rollout := choice.Channel.GetRollout()
+		if rollout == nil {
+			return false
+		}
+		return rollout.Mode == RolloutModeShadow || rollout.Matches(group, modelName)
+	}
+}
+
+// rolloutChannels 获取分组模型下指定上线方式且在范围内的渠道，调用方需持有读锁
+func (cc *ChannelsChooser) rolloutChannels(channelsPriority [][]int, group, modelName, mode string) []*ChannelChoice {
+	var choices []*ChannelChoice
+	for _, priority := range channelsPriority {
+		for _, channelId := range priority {
+			choice, ok := cc.Channels[channelId]
+			if !ok || slices.Contains(choices, choice) {
+				continue
+			}
+			rollout := choice.Channel.GetRollout()
+			if rollout == nil || rollout.Mode != mode || !rollout.Matches(group, modelName) {
+				continue
+			}
+			choices = append(choices, choice)
+		}
+	}
+
+	return choices
+}
+
+// pickCanary 按各灰度渠道的比例抽样，命中的渠道不可用时返回 nil，由正常渠道处理
+func (cc *ChannelsChooser) pickCanary(canaries []*ChannelChoice, filters []ChannelsFilterFunc, modelName string) (*Channel, func()) {
+	if len(canaries) == 0 {
+		return nil, nil
+	}
+
+	choiceWeight := rand.Float64() * 100
+	for _, choice := range canaries {
+		choiceWeight -= choice.Channel.GetRollout().Percent
+		if choiceWeight >= 0 {
+			continue
+		}
+		selected, _ := cc.selectable(choice.Channel.Id, filters, modelName)
+		if selected == nil {
+			return nil, nil
+		}
+		// 名额已满时回到正常渠道的选择
+		release, ok := GlobalChannelLimiter.TryAcquire(selected.Channel, modelName)
+		if !ok {
+			return nil, nil
+		}
+		return selected.Channel, release
+	}
+
+	return nil, nil
+}
+
+// ShadowChannels 按比例抽样获取需要镜像本次请求的影子渠道
+func (cc *ChannelsChooser) ShadowChannels(group, modelName string) []*Channel {
+	cc.RLock()
+	defer cc.RUnlock()
+
+	channelsPriority, err := cc.priorities(group, modelName)
+	if err != nil {
+		return nil
+	}
+
+	var channels []*Channel
+	for _, choice := range cc.rolloutChannels(channelsPriority, group, modelName, RolloutModeShadow) {
+		if rand.Float64()*100 >= choice.Channel.GetRollout().Percent {
+			continue
+		}
+		if selected, _ := cc.selectable(choice.Channel.Id, nil, modelName); selected != nil {
+			channels = append(channels, selected.Channel)
+		}
+	}
+
+	return channels
+}
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func newRolloutTestChannel(id int, rollout *ChannelRollout) *Channel {
+	weight := uint(1)
+	channel := &Channel{
+		Id:     id,
+		Weight: &weight,
+	}
+	if rollout != nil {
+		rolloutJSON := datatypes.NewJSONType(*rollout)
+		channel.Rollout = &rolloutJSON
+	}
+	return channel
+}
+
+func newRolloutTestChooser(channels ...*Channel) *ChannelsChooser {
+	cc := &ChannelsChooser{
+		Channels: map[int]*ChannelChoice{},
+		Rule: map[string]map[string][][]int{
+			"default": {"gpt-4o": {{}}},
+		},
+	}
+	for _, channel := range channels {
+		cc.Channels[channel.Id] = &ChannelChoice{Channel: channel}
+		cc.Rule["default"]["gpt-4o"][0] = append(cc.Rule["default"]["gpt-4o"][0], channel.Id)
+	}
+	return cc
+}
+
+func TestChannelRolloutMatches(t *testing.T) {
+	rollout := &ChannelRollout{Mode: RolloutModeCanary, Percent: 10, Groups: []string{"vip"}, Models: []string{"gpt-4o*"}}
+
+	assert.True(t, rollout.Matches("vip", "gpt-4o-mini"))
+	assert.False(t, rollout.Matches("default", "gpt-4o"))
+	assert.False(t, rollout.Matches("vip", "claude-3"))
+	assert.NoError(t, rollout.Validate())
+	assert.Error(t, (&ChannelRollout{Mode: RolloutModeShadow}).Validate())
+	assert.Error(t, (&ChannelRollout{Mode: "blue-green", Percent: 10}).Validate())
+}
+
+func TestNextCanaryPercent(t *testing.T) {
+	stable := newRolloutTestChannel(1, nil)
+	canary := newRolloutTestChannel(2, &ChannelRollout{Mode: RolloutModeCanary, Percent: 100})
+	cc := newRolloutTestChooser(stable, canary)
+
+	channel, _, _, err := cc.next("default", "gpt-4o")
+	assert.NoError(t, err)
+	assert.Equal(t, canary, channel)
+
+	// 灰度渠道跳过后由正常渠道处理
+	channel, _, _, err = cc.next("default", "gpt-4o", FilterChannelId([]int{2}))
+	assert.NoError(t, err)
+	assert.Equal(t, stable, channel)
+}
+
+func TestNextCanaryExcludedFromWeights(t *testing.T) {
+	stable := newRolloutTestChannel(1, nil)
+	canary := newRolloutTestChannel(2, &ChannelRollout{Mode: RolloutModeCanary, Percent: 0.000001})
+	cc := newRolloutTestChooser(stable, canary)
+
+	for i := 0; i < 100; i++ {
+		channel, _, _, err := cc.next("default", "gpt-4o")
+		assert.NoError(t, err)
+		assert.Equal(t, stable, channel)
+	}
+
+	// 正常渠道不可用时由灰度渠道兜底
+	channel, _, _, err := cc.next("default", "gpt-4o", FilterChannelId([]int{1}))
+	assert.NoError(t, err)
+	assert.Equal(t, canary, channel)
+}
+
+func TestShadowChannelNotSelected(t *testing.T) {
+	stable := newRolloutTestChannel(1, nil)
+	shadow := newRolloutTestChannel(2, &ChannelRollout{Mode: RolloutModeShadow, Percent: 100})
+	cc := newRolloutTestChooser(stable, shadow)
+
+	for i := 0; i < 20; i++ {
+		channel, _, _, err := cc.next("default", "gpt-4o")
+		assert.NoError(t, err)
+		assert.Equal(t, stable, channel)
+	}
+
+	_, _, _, err := cc.next("default", "gpt-4o", FilterChannelId([]int{1}))
+	assert.Error(t, err)
+	channel, _, _ := cc.Pick("default", "gpt-4o", 2)
+	assert.Nil(t, channel)
+	assert.Equal(t, []*Channel{shadow}, cc.ShadowChannels("default", "gpt-4o"))
+	assert.Empty(t, cc.ShadowChannels("default", "gpt-4o-mini"))
+}
+
+func TestRecordShadowResult(t *testing.T) {
+	cc := &ChannelsChooser{}
+	cc.RecordShadowResult(&ShadowResult{ChannelId: 2, Model: "gpt-4o", PrimaryStatus: 200, ShadowStatus: 200, PrimaryLength: 10, ShadowLength: 20, Similarity: 0.5})
+	cc.RecordShadowResult(&ShadowResult{ChannelId: 2, Model: "gpt-4o", PrimaryStatus: 200, ShadowStatus: 500, PrimaryLength: 10, ShadowLength: 0, Similarity: -1})
+
+	stats := cc.GetShadowStats(2)
+	assert.Len(t, stats, 1)
+	assert.Equal(t, int64(2), stats[0].Samples)
+	assert.Equal(t, int64(1), stats[0].StatusMatches)
+	assert.Equal(t, int64(1), stats[0].ShadowErrors)
+	assert.InDelta(t, 10, stats[0].ShadowLength, 0.001)
+	assert.InDelta(t, 0.5, stats[0].Similarity, 0.001)
+	assert.Equal(t, int64(1), stats[0].SimilaritySamples)
+
+	cc.ResetShadowStats(2, "")
+	assert.Empty(t, cc.GetShadowStats(0))
+}
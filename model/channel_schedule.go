@@ -0,0 +1,214 @@
+package model
+
+import (
+	"czloapi/common/logger"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	ScheduleActionActive      = "active"      // 渠道只在窗口内可用
+	ScheduleActionMaintenance = "maintenance" // 渠道在窗口内不可用
+	ScheduleActionOverride    = "override"    // 窗口内仅调整优先级和权重
+)
+
+var scheduleParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ChannelSchedule 渠道时间窗口，Cron 为窗口开始时间（5 段表达式，可用 CRON_TZ= 前缀指定时区），持续 Duration 分钟
+// active 和 override 窗口可设置 Priority/Weight，窗口内覆盖渠道自身的设置，多个窗口同时生效时取靠前的
+type ChannelSchedule struct {
+	Name     string `json:"name,omitempty"`
+	Cron     string `json:"cron"`
+	Duration int    `json:"duration"`
+	Action   string `json:"action"`
+	Priority *int64 `json:"priority,omitempty"`
+	Weight   *uint  `json:"weight,omitempty"`
+}
+
+func (s *ChannelSchedule) label() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Cron
+}
+
+// InWindow 判断时间是否在窗口内，即 (now-duration, now] 之间是否触发过
+func (s *ChannelSchedule) InWindow(now time.Time) bool {
+	schedule, err := scheduleParser.Parse(s.Cron)
+	if err != nil {
+		return false
+	}
+
+	windowStart := now.Add(-time.Duration(s.Duration) * time.Minute)
+	return !schedule.Next(windowStart).After(now)
+}
+
+func (s *ChannelSchedule) Validate() error {
+	if _, err := scheduleParser.Parse(s.Cron); err != nil {
+		return fmt.Errorf("时间窗口 %s 的 cron 表达式无效: %s", s.label(), err.Error())
+	}
+	if s.Duration <= 0 {
+		return fmt.Errorf("时间窗口 %s 的持续时间必须大于 0", s.label())
+	}
+
+	switch s.Action {
+	case ScheduleActionActive, ScheduleActionOverride:
+	case ScheduleActionMaintenance:
+		if s.Priority != nil || s.Weight != nil {
+			return fmt.Errorf("维护窗口 %s 不能设置优先级和权重", s.label())
+		}
+	default:
+		return fmt.Errorf("时间窗口 %s 的类型 %s 无效", s.label(), s.Action)
+	}
+
+	if s.Action == ScheduleActionOverride && s.Priority == nil && s.Weight == nil {
+		return fmt.Errorf("时间窗口 %s 没有设置优先级或权重", s.label())
+	}
+	if s.Weight != nil && *s.Weight == 0 {
+		return errors.New("时间窗口的权重必须大于 0")
+	}
+	return nil
+}
+
+// ChannelScheduleState 渠道在某一时刻按时间窗口计算出的状态
+type ChannelScheduleState struct {
+	Disabled bool
+	Reason   string
+	Priority *int64
+	Weight   *uint
+}
+
+func (s ChannelScheduleState) Equal(other ChannelScheduleState) bool {
+	return s.Disabled == other.Disabled && s.Reason == other.Reason &&
+		equalPointer(s.Priority, other.Priority) && equalPointer(s.Weight, other.Weight)
+}
+
+func equalPointer[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (s ChannelScheduleState) String() string {
+	if s.Disabled {
+		return "disabled (" + s.Reason + ")"
+	}
+
+	parts := []string{"enabled"}
+	if s.Priority != nil {
+		parts = append(parts, fmt.Sprintf("priority=%d", *s.Priority))
+	}
+	if s.Weight != nil {
+		parts = append(parts, fmt.Sprintf("weight=%d", *s.Weight))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c *Channel) GetSchedules() []ChannelSchedule {
+	if c.Schedules == nil {
+		return nil
+	}
+	return *c.Schedules
+}
+
+func (c *Channel) ValidateSchedules() error {
+	for _, schedule := range c.GetSchedules() {
+		if err := schedule.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScheduleState 计算渠道在 now 时的状态
+// 配置了 active 窗口时只在窗口内可用，处于 maintenance 窗口时不可用
+func (c *Channel) ScheduleState(now time.Time) ChannelScheduleState {
+	state := ChannelScheduleState{}
+	hasActive, inActive := false, false
+
+	for _, schedule := range c.GetSchedules() {
+		if schedule.Action == ScheduleActionActive {
+			hasActive = true
+		}
+		if !schedule.InWindow(now) {
+			continue
+		}
+
+		switch schedule.Action {
+		case ScheduleActionMaintenance:
+			if !state.Disabled {
+				state.Disabled = true
+				state.Reason = "maintenance " + schedule.label()
+			}
+			continue
+		case ScheduleActionActive:
+			inActive = true
+		}
+
+		if state.Priority == nil && schedule.Priority != nil {
+			state.Priority = schedule.Priority
+		}
+		if state.Weight == nil && schedule.Weight != nil {
+			state.Weight = schedule.Weight
+		}
+	}
+
+	if hasActive && !inActive && !state.Disabled {
+		state.Disabled = true
+		state.Reason = "outside active window"
+	}
+	if state.Disabled {
+		state.Priority = nil
+		state.Weight = nil
+	}
+
+	return state
+}
+
+// RefreshSchedules 由定时任务每分钟调用，渠道的时间窗口状态发生变化时重新加载渠道
+func (cc *ChannelsChooser) RefreshSchedules() {
+	now := time.Now()
+	changed := false
+
+	cc.RLock()
+	for _, choice := range cc.Channels {
+		if len(choice.Channel.GetSchedules()) == 0 {
+			continue
+		}
+		if !choice.Schedule.Equal(choice.Channel.ScheduleState(now)) {
+			changed = true
+			break
+		}
+	}
+	cc.RUnlock()
+
+	if changed {
+		cc.Load()
+	}
+}
+
+// logScheduleTransitions 记录时间窗口导致的渠道状态变化，调用方需持有写锁
+func (cc *ChannelsChooser) logScheduleTransitions(newChannels map[int]*ChannelChoice) {
+	for channelId, choice := range newChannels {
+		if len(choice.Channel.GetSchedules()) == 0 {
+			continue
+		}
+
+		previous := ChannelScheduleState{}
+		if oldChoice, ok := cc.Channels[channelId]; ok {
+			previous = oldChoice.Schedule
+		} else if !choice.Schedule.Disabled && choice.Schedule.Priority == nil && choice.Schedule.Weight == nil {
+			continue
+		}
+
+		if previous.Equal(choice.Schedule) {
+			continue
+		}
+		logger.SysLog(fmt.Sprintf("channel #%d(%s) schedule: %s -> %s", channelId, choice.Channel.Name, previous.String(), choice.Schedule.String()))
+	}
+}
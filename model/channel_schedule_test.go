@@ -0,0 +1,83 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func newScheduleTestChannel(schedules ...ChannelSchedule) *Channel {
+	weight := uint(1)
+	priority := int64(0)
+	slice := datatypes.JSONSlice[ChannelSchedule](schedules)
+	return &Channel{
+		Id:        1,
+		Weight:    &weight,
+		Priority:  &priority,
+		Schedules: &slice,
+	}
+}
+
+func TestChannelScheduleInWindow(t *testing.T) {
+	schedule := ChannelSchedule{Cron: "0 2 * * *", Duration: 60, Action: ScheduleActionMaintenance}
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+
+	assert.False(t, schedule.InWindow(day.Add(time.Hour+59*time.Minute)))
+	assert.True(t, schedule.InWindow(day.Add(2*time.Hour)))
+	assert.True(t, schedule.InWindow(day.Add(2*time.Hour+59*time.Minute)))
+	assert.False(t, schedule.InWindow(day.Add(3*time.Hour)))
+}
+
+func TestChannelScheduleState(t *testing.T) {
+	weight := uint(50)
+	priority := int64(10)
+	channel := newScheduleTestChannel(
+		ChannelSchedule{Name: "night", Cron: "0 0 * * *", Duration: 8 * 60, Action: ScheduleActionOverride, Weight: &weight, Priority: &priority},
+		ChannelSchedule{Name: "upgrade", Cron: "0 2 * * *", Duration: 30, Action: ScheduleActionMaintenance},
+	)
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+
+	state := channel.ScheduleState(day.Add(time.Hour))
+	assert.False(t, state.Disabled)
+	assert.Equal(t, weight, *state.Weight)
+	assert.Equal(t, priority, *state.Priority)
+
+	state = channel.ScheduleState(day.Add(2*time.Hour + 10*time.Minute))
+	assert.True(t, state.Disabled)
+	assert.Equal(t, "maintenance upgrade", state.Reason)
+	assert.Nil(t, state.Weight)
+
+	assert.True(t, channel.ScheduleState(day.Add(12*time.Hour)).Equal(ChannelScheduleState{}))
+}
+
+func TestChannelScheduleActiveWindow(t *testing.T) {
+	channel := newScheduleTestChannel(ChannelSchedule{Cron: "0 9 * * 1-5", Duration: 9 * 60, Action: ScheduleActionActive})
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+
+	assert.False(t, channel.ScheduleState(monday.Add(10*time.Hour)).Disabled)
+	assert.True(t, channel.ScheduleState(monday.Add(20*time.Hour)).Disabled)
+	assert.True(t, channel.ScheduleState(monday.Add(-24*time.Hour+10*time.Hour)).Disabled)
+}
+
+func TestChannelScheduleValidate(t *testing.T) {
+	weight := uint(5)
+	assert.NoError(t, newScheduleTestChannel(ChannelSchedule{Cron: "CRON_TZ=Asia/Shanghai 0 2 * * *", Duration: 30, Action: ScheduleActionMaintenance}).ValidateSchedules())
+	assert.Error(t, newScheduleTestChannel(ChannelSchedule{Cron: "bad", Duration: 30, Action: ScheduleActionMaintenance}).ValidateSchedules())
+	assert.Error(t, newScheduleTestChannel(ChannelSchedule{Cron: "0 2 * * *", Action: ScheduleActionMaintenance}).ValidateSchedules())
+	assert.Error(t, newScheduleTestChannel(ChannelSchedule{Cron: "0 2 * * *", Duration: 30, Action: ScheduleActionOverride}).ValidateSchedules())
+	assert.Error(t, newScheduleTestChannel(ChannelSchedule{Cron: "0 2 * * *", Duration: 30, Action: ScheduleActionMaintenance, Weight: &weight}).ValidateSchedules())
+}
+
+func TestSelectableSkipsScheduleDisabled(t *testing.T) {
+	channel := newScheduleTestChannel()
+	cc := &ChannelsChooser{
+		Channels: map[int]*ChannelChoice{
+			1: {Channel: channel, Schedule: ChannelScheduleState{Disabled: true}},
+		},
+	}
+
+	choice, _ := cc.selectable(1, nil, "gpt-4o")
+	assert.Nil(t, choice)
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"czloapi/common/credential"
+	"sort"
+)
+
+// ResolveSecrets 将 key 中的 env:/file: 引用替换为实际内容
+// 缓存中的渠道加载时已解析，不包含引用时不写入，避免并发请求共享同一个渠道对象时产生数据竞争
+func (c *Channel) ResolveSecrets() error {
+	if !credential.HasSecretRef(c.Key) {
+		return nil
+	}
+	key, err := credential.ResolveSecrets(c.Key)
+	if err != nil {
+		return err
+	}
+	c.Key = key
+	return nil
+}
+
+type ChannelSecretStatus struct {
+	ChannelId int    `json:"channel_id"`
+	Name      string `json:"name"`
+	Error     string `json:"error"`
+}
+
+// GetSecretErrors 获取密钥引用无法解析的渠道，这些渠道不会被选中
+func (cc *ChannelsChooser) GetSecretErrors() []*ChannelSecretStatus {
+	cc.RLock()
+	defer cc.RUnlock()
+
+	result := make([]*ChannelSecretStatus, 0)
+	for channelId, choice := range cc.Channels {
+		if choice.SecretError == "" {
+			continue
+		}
+		result = append(result, &ChannelSecretStatus{
+			ChannelId: channelId,
+			Name:      choice.Channel.Name,
+			Error:     choice.SecretError,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ChannelId < result[j].ChannelId
+	})
+	return result
+}
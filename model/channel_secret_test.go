@@ -0,0 +1,19 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelResolveSecrets(t *testing.T) {
+	t.Setenv("CHANNEL_SECRET_TEST_KEY", "sk-resolved")
+
+	channel := &Channel{Key: "env:CHANNEL_SECRET_TEST_KEY\nsk-plain"}
+	assert.NoError(t, channel.ResolveSecrets())
+	assert.Equal(t, "sk-resolved\nsk-plain", channel.Key)
+
+	channel = &Channel{Key: "sk-plain"}
+	assert.NoError(t, channel.ResolveSecrets())
+	assert.Equal(t, "sk-plain", channel.Key)
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ShadowResult 一次影子请求与线上请求的比较结果
+type ShadowResult struct {
+	ChannelId      int
+	Model          string
+	PrimaryStatus  int
+	ShadowStatus   int
+	PrimaryLatency time.Duration
+	ShadowLatency  time.Duration
+	PrimaryLength  int     // 输出文本的字符数，无法提取文本时为响应字节数
+	ShadowLength   int     // 同上
+	Similarity     float64 // 小于 0 表示未比较
+}
+
+// ShadowStats 影子渠道 渠道+模型 的累计比较结果，延迟和长度为平均值
+type ShadowStats struct {
+	sync.Mutex
+	ChannelId         int
+	Model             string
+	Samples           int64
+	StatusMatches     int64
+	ShadowErrors      int64
+	PrimaryLatency    float64 // 毫秒
+	ShadowLatency     float64 // 毫秒
+	PrimaryLength     float64
+	ShadowLength      float64
+	Similarity        float64
+	SimilaritySamples int64
+	UpdatedAt         time.Time
+}
+
+type ShadowStatsSnapshot struct {
+	ChannelId         int     `json:"channel_id"`
+	Model             string  `json:"model"`
+	Samples           int64   `json:"samples"`
+	StatusMatches     int64   `json:"status_matches"`
+	ShadowErrors      int64   `json:"shadow_errors"`
+	PrimaryLatency    float64 `json:"primary_latency"`
+	ShadowLatency     float64 `json:"shadow_latency"`
+	PrimaryLength     float64 `json:"primary_length"`
+	ShadowLength      float64 `json:"shadow_length"`
+	Similarity        float64 `json:"similarity"`
+	SimilaritySamples int64   `json:"similarity_samples"`
+	UpdatedAt         int64   `json:"updated_at"`
+}
+
+func runningMean(mean float64, value float64, samples int64) float64 {
+	return mean + (value-mean)/float64(samples)
+}
+
+// RecordShadowResult 累计一次影子请求的比较结果
+func (cc *ChannelsChooser) RecordShadowResult(result *ShadowResult) {
+	if result.ChannelId == 0 || result.Model == "" {
+		return
+	}
+
+	value, _ := cc.Shadows.LoadOrStore(healthKey(result.ChannelId, result.Model), &ShadowStats{
+		ChannelId: result.ChannelId,
+		Model:     result.Model,
+	})
+	stats := value.(*ShadowStats)
+
+	stats.Lock()
+	defer stats.Unlock()
+
+	stats.Samples++
+	if result.PrimaryStatus == result.ShadowStatus {
+		stats.StatusMatches++
+	}
+	if result.ShadowStatus >= 400 {
+		stats.ShadowErrors++
+	}
+	stats.PrimaryLatency = runningMean(stats.PrimaryLatency, float64(result.PrimaryLatency.Milliseconds()), stats.Samples)
+	stats.ShadowLatency = runningMean(stats.ShadowLatency, float64(result.ShadowLatency.Milliseconds()), stats.Samples)
+	stats.PrimaryLength = runningMean(stats.PrimaryLength, float64(result.PrimaryLength), stats.Samples)
+	stats.ShadowLength = runningMean(stats.ShadowLength, float64(result.ShadowLength), stats.Samples)
+	if result.Similarity >= 0 {
+		stats.SimilaritySamples++
+		stats.Similarity = runningMean(stats.Similarity, result.Similarity, stats.SimilaritySamples)
+	}
+	stats.UpdatedAt = time.Now()
+}
+
+// GetShadowStats 获取影子渠道的比较结果，channelId 为 0 时返回全部
+func (cc *ChannelsChooser) GetShadowStats(channelId int) []*ShadowStatsSnapshot {
+	result := make([]*ShadowStatsSnapshot, 0)
+	cc.Shadows.Range(func(_, value interface{}) bool {
+		stats := value.(*ShadowStats)
+		if channelId != 0 && stats.ChannelId != channelId {
+			return true
+		}
+
+		stats.Lock()
+		result = append(result, &ShadowStatsSnapshot{
+			ChannelId:         stats.ChannelId,
+			Model:             stats.Model,
+			Samples:           stats.Samples,
+			StatusMatches:     stats.StatusMatches,
+			ShadowErrors:      stats.ShadowErrors,
+			PrimaryLatency:    stats.PrimaryLatency,
+			ShadowLatency:     stats.ShadowLatency,
+			PrimaryLength:     stats.PrimaryLength,
+			ShadowLength:      stats.ShadowLength,
+			Similarity:        stats.Similarity,
+			SimilaritySamples: stats.SimilaritySamples,
+			UpdatedAt:         stats.UpdatedAt.Unix(),
+		})
+		stats.Unlock()
+		return true
+	})
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ChannelId != result[j].ChannelId {
+			return result[i].ChannelId < result[j].ChannelId
+		}
+		return result[i].Model < result[j].Model
+	})
+
+	return result
+}
+
+// ResetShadowStats 清空影子渠道的比较结果，modelName 为空时清空该渠道全部模型
+func (cc *ChannelsChooser) ResetShadowStats(channelId int, modelName string) {
+	cc.Shadows.Range(func(key, value interface{}) bool {
+		stats := value.(*ShadowStats)
+		if stats.ChannelId == channelId && (modelName == "" || stats.Model == modelName) {
+			cc.Shadows.Delete(key)
+		}
+		return true
+	})
+}
@@ -79,6 +79,10 @@ func UpdateChannelsTag(tag string, channel *Channel) error {
 	if channel.Key == "" {
 		return errors.New("key不能为空")
 	}
+	channel.Key, err = restoreMaskedKey(channel.Key, channelTag.Key)
+	if err != nil {
+		return err
+	}
 
 	addKeys := []string{}
 	delIds := []int{}
@@ -158,6 +162,10 @@ func UpdateChannelsTag(tag string, channel *Channel) error {
 		"plugin":              channel.Plugin,
 		"pre_cost":            channel.PreCost,
 		"disabled_stream":     channel.DisabledStream,
+		"limits":              channel.Limits,
+		"schedules":           channel.Schedules,
+		"override_rules":      channel.OverrideRules,
+		"model_sync_policy":   channel.ModelSyncPolicy,
 		"compatible_response": channel.CompatibleResponse,
 	}).Error
 
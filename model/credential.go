@@ -0,0 +1,203 @@
+package model
+
+import (
+	"context"
+	"czloapi/common/credential"
+	"czloapi/common/logger"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	schema.RegisterSerializer("credential", CredentialSerializer{})
+}
+
+// CredentialSerializer 凭据字段写入数据库时加密，读取时解密
+// 解密失败时保留密文并记录错误，避免一条记录导致整个查询失败
+type CredentialSerializer struct{}
+
+func (CredentialSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	}
+
+	plaintext, err := credential.Decrypt(value)
+	if err != nil {
+		logger.SysError(fmt.Sprintf("failed to decrypt %s.%s: %s", field.Schema.Table, field.DBName, err.Error()))
+		plaintext = value
+	}
+
+	return field.Set(ctx, dst, plaintext)
+}
+
+func (CredentialSerializer) Value(_ context.Context, _ *schema.Field, _ reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, _ := fieldValue.(string)
+	return credential.Encrypt(value)
+}
+
+// MaskChannelKey 脱敏渠道 key，多个 key 按行分别脱敏，密钥引用不是密钥本身，原样显示
+func MaskChannelKey(key string) string {
+	lines := strings.Split(key, "\n")
+	for i, line := range lines {
+		if line = strings.TrimSpace(line); line != "" && !credential.IsSecretRef(line) {
+			lines[i] = MaskKey(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// restoreMaskedKey 将提交的 key 中未修改的脱敏行还原为原来的 key
+// 不同的 key 可能脱敏成相同的值（如 8 位以内的 key），这些行只有在数量和行号都没有变化时才按行号还原，否则无法确定对应关系，返回错误
+func restoreMaskedKey(submitted, existing string) (string, error) {
+	existingLines := strings.Split(existing, "\n")
+	masked := make(map[string][]string)
+	for _, line := range existingLines {
+		if line = strings.TrimSpace(line); line != "" {
+			maskedLine := MaskKey(line)
+			masked[maskedLine] = append(masked[maskedLine], line)
+		}
+	}
+
+	lines := strings.Split(submitted, "\n")
+	submittedCount := make(map[string]int)
+	for _, line := range lines {
+		submittedCount[strings.TrimSpace(line)]++
+	}
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		originals, ok := masked[line]
+		if !ok {
+			continue
+		}
+		if !slices.ContainsFunc(originals, func(original string) bool { return original != originals[0] }) {
+			lines[i] = originals[0]
+			continue
+		}
+
+		if submittedCount[line] != len(originals) || i >= len(existingLines) || MaskKey(strings.TrimSpace(existingLines[i])) != line {
+			return "", fmt.Errorf("第 %d 行的 key %s 无法确定对应的原 key，请重新填写完整的 key", i+1, line)
+		}
+		lines[i] = strings.TrimSpace(existingLines[i])
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// MaskProxy 脱敏代理地址中的密码
+func MaskProxy(proxy string) string {
+	parsed, err := url.Parse(proxy)
+	if err != nil || parsed.User == nil {
+		return proxy
+	}
+	return parsed.Redacted()
+}
+
+// MaskCredentials 脱敏渠道的 key 和关联代理池的密码，用于返回给管理后台
+func (c *Channel) MaskCredentials() {
+	c.Key = MaskChannelKey(c.Key)
+	if c.ProxyPool != nil {
+		c.ProxyPool.MaskCredentials()
+	}
+}
+
+func (p *IPProxy) MaskCredentials() {
+	p.Proxy = MaskProxy(p.Proxy)
+}
+
+// RestoreMaskedKey 编辑渠道时提交的是脱敏后的 key，未修改的部分还原为数据库中的原值
+func (c *Channel) RestoreMaskedKey() error {
+	if c.Key == "" || c.Id == 0 {
+		return nil
+	}
+
+	existing, err := GetChannelById(c.Id)
+	if err != nil {
+		return err
+	}
+	c.Key, err = restoreMaskedKey(c.Key, existing.Key)
+	return err
+}
+
+// findChannelIdsByKey 查找 key 完全匹配的渠道
+func findChannelIdsByKey(key string) ([]int, error) {
+	var channels []*Channel
+	if err := DB.Select("id", quotePostgresField("key")).Find(&channels).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0)
+	for _, channel := range channels {
+		if channel.Key == key {
+			ids = append(ids, channel.Id)
+		}
+	}
+	return ids, nil
+}
+
+// ReEncryptCredentials 使用当前主密钥重新加密渠道 key 和代理池地址，返回更新的记录数
+func ReEncryptCredentials() (channels int, proxies int, err error) {
+	if !credential.Enabled() {
+		return 0, 0, fmt.Errorf("credential.master_key is not configured")
+	}
+
+	var channelRows []struct {
+		Id  int
+		Key string
+	}
+	if err = DB.Model(&Channel{}).Select("id", quotePostgresField("key")).Find(&channelRows).Error; err != nil {
+		return
+	}
+	for _, row := range channelRows {
+		if !credential.NeedsReEncrypt(row.Key) {
+			continue
+		}
+		var encrypted string
+		if encrypted, err = reEncrypt(row.Key); err != nil {
+			return channels, proxies, fmt.Errorf("channel #%d: %w", row.Id, err)
+		}
+		if err = DB.Model(&Channel{}).Where("id = ?", row.Id).UpdateColumn("key", encrypted).Error; err != nil {
+			return
+		}
+		channels++
+	}
+
+	var proxyRows []struct {
+		Id    int
+		Proxy string
+	}
+	if err = DB.Model(&IPProxy{}).Select("id", "proxy").Find(&proxyRows).Error; err != nil {
+		return
+	}
+	for _, row := range proxyRows {
+		if !credential.NeedsReEncrypt(row.Proxy) {
+			continue
+		}
+		var encrypted string
+		if encrypted, err = reEncrypt(row.Proxy); err != nil {
+			return channels, proxies, fmt.Errorf("ip proxy #%d: %w", row.Id, err)
+		}
+		if err = DB.Model(&IPProxy{}).Where("id = ?", row.Id).UpdateColumn("proxy", encrypted).Error; err != nil {
+			return
+		}
+		proxies++
+	}
+
+	return
+}
+
+func reEncrypt(value string) (string, error) {
+	plaintext, err := credential.Decrypt(value)
+	if err != nil {
+		return "", err
+	}
+	return credential.Encrypt(plaintext)
+}
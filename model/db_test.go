@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+
+	"czloapi/common"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestDB 使用内存 SQLite 替换 DB，测试结束后恢复
+func setupTestDB(t *testing.T, models ...any) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// 内存库每个连接各自独立，只保留一个连接
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(models...))
+
+	oldDB, oldUsingSQLite := DB, common.UsingSQLite
+	DB, common.UsingSQLite = db, true
+	t.Cleanup(func() {
+		DB, common.UsingSQLite = oldDB, oldUsingSQLite
+		sqlDB.Close()
+	})
+}
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"czloapi/common/logger"
+	"czloapi/types"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// 错误策略的处理方式
+const (
+	ErrorPolicyActionRetry       = "retry"       // 换渠道重试
+	ErrorPolicyActionRetrySame   = "retry_same"  // 先在同渠道重试，再换渠道
+	ErrorPolicyActionCooldown    = "cooldown"    // 冷却渠道 CooldownSeconds 秒后换渠道重试
+	ErrorPolicyActionDisable     = "disable"     // 自动禁用渠道后换渠道重试
+	ErrorPolicyActionPassthrough = "passthrough" // 不重试，错误原样返回给客户端
+)
+
+// ErrorPolicy 上游错误的分类规则，按 Sort 从小到大匹配，第一条匹配的规则生效，没有匹配时使用内置的判断逻辑
+// ChannelType、StatusCode 为 0 以及 ErrorCode、ErrorType、MessageRegex 为空时表示不限制
+type ErrorPolicy struct {
+	Id              int    `json:"id"`
+	Name            string `json:"name" gorm:"type:varchar(64);default:''"`
+	ChannelType     int    `json:"channel_type" gorm:"default:0"`
+	StatusCode      int    `json:"status_code" gorm:"default:0"`
+	ErrorCode       string `json:"error_code" gorm:"type:varchar(128);default:''"`
+	ErrorType       string `json:"error_type" gorm:"type:varchar(128);default:''"`
+	MessageRegex    string `json:"message_regex" gorm:"type:text"`
+	Action          string `json:"action" gorm:"type:varchar(16)"`
+	CooldownSeconds int    `json:"cooldown_seconds" gorm:"default:0"`
+	Sort            int    `json:"sort" gorm:"default:0"`
+	Enabled         *bool  `json:"enabled" gorm:"default:true"`
+	CreatedTime     int64  `json:"created_time" gorm:"bigint"`
+	UpdatedTime     int64  `json:"updated_time" gorm:"bigint"`
+
+	messageRegex *regexp.Regexp `gorm:"-"`
+}
+
+func (p *ErrorPolicy) Validate() error {
+	switch p.Action {
+	case ErrorPolicyActionRetry, ErrorPolicyActionRetrySame, ErrorPolicyActionDisable, ErrorPolicyActionPassthrough:
+	case ErrorPolicyActionCooldown:
+		if p.CooldownSeconds <= 0 {
+			return errors.New("冷却时间必须大于 0")
+		}
+	default:
+		return fmt.Errorf("无效的处理方式 %s", p.Action)
+	}
+
+	if p.MessageRegex != "" {
+		if _, err := regexp.Compile(p.MessageRegex); err != nil {
+			return fmt.Errorf("错误信息正则无效: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+func (p *ErrorPolicy) Label() string {
+	if p.Name != "" {
+		return fmt.Sprintf("#%d(%s)", p.Id, p.Name)
+	}
+	return fmt.Sprintf("#%d", p.Id)
+}
+
+// Matches 判断错误是否符合规则
+func (p *ErrorPolicy) Matches(channelType int, err *types.OpenAIErrorWithStatusCode) bool {
+	if p.ChannelType != 0 && p.ChannelType != channelType {
+		return false
+	}
+	if p.StatusCode != 0 && p.StatusCode != err.StatusCode {
+		return false
+	}
+	if p.ErrorCode != "" && (err.Code == nil || fmt.Sprint(err.Code) != p.ErrorCode) {
+		return false
+	}
+	if p.ErrorType != "" && p.ErrorType != err.Type {
+		return false
+	}
+	if p.messageRegex != nil && !p.messageRegex.MatchString(err.Message) {
+		return false
+	}
+	return true
+}
+
+func GetAllErrorPolicies() ([]*ErrorPolicy, error) {
+	var policies []*ErrorPolicy
+	err := DB.Order("sort, id").Find(&policies).Error
+	return policies, err
+}
+
+func GetErrorPolicyById(id int) (*ErrorPolicy, error) {
+	policy := &ErrorPolicy{}
+	err := DB.First(policy, "id = ?", id).Error
+	return policy, err
+}
+
+func CreateErrorPolicy(policy *ErrorPolicy) error {
+	return DB.Create(policy).Error
+}
+
+func UpdateErrorPolicy(policy *ErrorPolicy) error {
+	return DB.Omit("id", "created_time").Save(policy).Error
+}
+
+func DeleteErrorPolicy(id int) error {
+	return DB.Delete(&ErrorPolicy{}, id).Error
+}
+
+// 内存缓存
+
+type ErrorPolicyCacheType struct {
+	sync.RWMutex
+	policies []*ErrorPolicy
+}
+
+var GlobalErrorPolicy = &ErrorPolicyCacheType{}
+
+func (c *ErrorPolicyCacheType) Load() {
+	var policies []*ErrorPolicy
+	if err := DB.Where("enabled = ?", true).Order("sort, id").Find(&policies).Error; err != nil {
+		logger.SysError("failed to load error policies: " + err.Error())
+		return
+	}
+
+	c.Set(policies)
+	logger.SysLog("error policy cache loaded")
+}
+
+// Set 替换缓存中的规则，正则无效的规则会被忽略
+func (c *ErrorPolicyCacheType) Set(policies []*ErrorPolicy) {
+	loaded := make([]*ErrorPolicy, 0, len(policies))
+	for _, policy := range policies {
+		if policy.MessageRegex != "" {
+			messageRegex, err := regexp.Compile(policy.MessageRegex)
+			if err != nil {
+				logger.SysError(fmt.Sprintf("invalid message regex in error policy %s: %s", policy.Label(), err.Error()))
+				continue
+			}
+			policy.messageRegex = messageRegex
+		}
+		loaded = append(loaded, policy)
+	}
+
+	c.Lock()
+	c.policies = loaded
+	c.Unlock()
+}
+
+// Match 返回第一条匹配的规则，没有匹配时返回 nil
+func (c *ErrorPolicyCacheType) Match(channelType int, err *types.OpenAIErrorWithStatusCode) *ErrorPolicy {
+	if err == nil || err.LocalError {
+		return nil
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	for _, policy := range c.policies {
+		if policy.Matches(channelType, err) {
+			return policy
+		}
+	}
+	return nil
+}
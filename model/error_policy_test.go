@@ -0,0 +1,84 @@
+package model
+
+import (
+	"net/http"
+	"testing"
+
+	"czloapi/common/utils"
+	"czloapi/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorPolicyMatch(t *testing.T) {
+	t.Cleanup(func() { GlobalErrorPolicy.Set(nil) })
+
+	GlobalErrorPolicy.Set([]*ErrorPolicy{
+		{Id: 1, ChannelType: 14, StatusCode: http.StatusBadRequest, MessageRegex: "(?i)credit balance", Action: ErrorPolicyActionDisable},
+		{Id: 2, ErrorCode: "rate_limit_exceeded", Action: ErrorPolicyActionCooldown, CooldownSeconds: 30},
+		{Id: 3, StatusCode: http.StatusBadRequest, Action: ErrorPolicyActionPassthrough},
+		{Id: 4, MessageRegex: "(", Action: ErrorPolicyActionRetry},
+	})
+
+	newErr := func(statusCode int, code any, message string) *types.OpenAIErrorWithStatusCode {
+		return &types.OpenAIErrorWithStatusCode{
+			OpenAIError: types.OpenAIError{Code: code, Message: message},
+			StatusCode:  statusCode,
+		}
+	}
+
+	policy := GlobalErrorPolicy.Match(14, newErr(http.StatusBadRequest, nil, "Your Credit Balance is too low"))
+	assert.NotNil(t, policy)
+	assert.Equal(t, 1, policy.Id)
+
+	// 渠道类型不匹配时继续匹配后面的规则
+	policy = GlobalErrorPolicy.Match(1, newErr(http.StatusBadRequest, nil, "Your credit balance is too low"))
+	assert.NotNil(t, policy)
+	assert.Equal(t, 3, policy.Id)
+
+	policy = GlobalErrorPolicy.Match(1, newErr(http.StatusTooManyRequests, "rate_limit_exceeded", "slow down"))
+	assert.NotNil(t, policy)
+	assert.Equal(t, ErrorPolicyActionCooldown, policy.Action)
+
+	// 正则无效的规则被忽略
+	assert.Nil(t, GlobalErrorPolicy.Match(1, newErr(http.StatusInternalServerError, nil, "internal error")))
+
+	localErr := newErr(http.StatusBadRequest, nil, "bad request")
+	localErr.LocalError = true
+	assert.Nil(t, GlobalErrorPolicy.Match(1, localErr))
+}
+
+func TestErrorPolicyValidate(t *testing.T) {
+	assert.NoError(t, (&ErrorPolicy{Action: ErrorPolicyActionRetrySame}).Validate())
+	assert.Error(t, (&ErrorPolicy{Action: ErrorPolicyActionCooldown}).Validate())
+	assert.Error(t, (&ErrorPolicy{Action: ErrorPolicyActionRetry, MessageRegex: "("}).Validate())
+	assert.Error(t, (&ErrorPolicy{Action: "ignore"}).Validate())
+}
+
+func TestCreateDisabledErrorPolicy(t *testing.T) {
+	setupTestDB(t, &ErrorPolicy{})
+	t.Cleanup(func() { GlobalErrorPolicy.Set(nil) })
+
+	disabled := &ErrorPolicy{Name: "disabled", StatusCode: http.StatusBadRequest, Action: ErrorPolicyActionPassthrough, Enabled: utils.GetPointer(false)}
+	require.NoError(t, CreateErrorPolicy(disabled))
+	enabled := &ErrorPolicy{Name: "default", StatusCode: http.StatusTooManyRequests, Action: ErrorPolicyActionRetry}
+	require.NoError(t, CreateErrorPolicy(enabled))
+
+	stored, err := GetErrorPolicyById(disabled.Id)
+	require.NoError(t, err)
+	require.NotNil(t, stored.Enabled)
+	assert.False(t, *stored.Enabled)
+
+	// 未指定时默认启用
+	stored, err = GetErrorPolicyById(enabled.Id)
+	require.NoError(t, err)
+	require.NotNil(t, stored.Enabled)
+	assert.True(t, *stored.Enabled)
+
+	GlobalErrorPolicy.Load()
+	badRequest := &types.OpenAIErrorWithStatusCode{StatusCode: http.StatusBadRequest}
+	assert.Nil(t, GlobalErrorPolicy.Match(1, badRequest))
+	tooManyRequests := &types.OpenAIErrorWithStatusCode{StatusCode: http.StatusTooManyRequests}
+	assert.NotNil(t, GlobalErrorPolicy.Match(1, tooManyRequests))
+}
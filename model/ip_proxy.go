@@ -21,7 +21,7 @@ const proxyLatencyTestURL = "https://www.gstatic.com/generate_204"
 type IPProxy struct {
 	Id          int            `json:"id"`
 	Name        string         `json:"name" form:"name" gorm:"type:varchar(64);index;not null"`
-	Proxy       string         `json:"proxy" form:"proxy" gorm:"column:proxy;type:varchar(512);not null"`
+	Proxy       string         `json:"proxy" form:"proxy" gorm:"column:proxy;type:varchar(1024);not null;serializer:credential"`
 	Remark      *string        `json:"remark" form:"remark" gorm:"type:varchar(255);default:''"`
 	Latency     *int           `json:"latency" form:"latency"`
 	TestTime    int64          `json:"test_time" gorm:"bigint;default:0"`
@@ -142,12 +142,16 @@ func (p *IPProxy) Insert() error {
 
 // Update 更新代理池记录。
 func (p *IPProxy) Update() error {
-	if err := p.Prepare(); err != nil {
+	current, err := GetIPProxyById(p.Id)
+	if err != nil {
 		return err
 	}
 
-	current, err := GetIPProxyById(p.Id)
-	if err != nil {
+	// 后台展示的是脱敏后的地址，未修改时保留原密码
+	if p.Proxy == MaskProxy(current.Proxy) {
+		p.Proxy = current.Proxy
+	}
+	if err := p.Prepare(); err != nil {
 		return err
 	}
 
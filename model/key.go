@@ -81,6 +81,10 @@ type KeySetting struct {
 	Heartbeat      HeartbeatSetting `json:"heartbeat,omitempty"`
 	Limits         LimitsConfig     `json:"limits,omitempty"`
 	FallbackGroups []string         `json:"fallback_groups,omitempty"`
+	Affinity       AffinitySetting  `json:"affinity,omitempty"`
+	Hedging        HedgingSetting   `json:"hedging,omitempty"`
+	// ModelFallbacks 模型回退链，覆盖全局设置中的同名模型
+	ModelFallbacks map[string]ModelFallback `json:"model_fallbacks,omitempty"`
 }
 
 type TokenSetting = KeySetting
@@ -90,6 +94,27 @@ type HeartbeatSetting struct {
 	TimeoutSeconds int  `json:"timeout_seconds"`
 }
 
+const (
+	AffinityModeUser    = "user"    // 按用户
+	AffinityModeKey     = "key"     // 按令牌
+	AffinityModeRequest = "request" // 按请求中的 prompt_cache_key / user 字段
+	AffinityModePrompt  = "prompt"  // 按系统提示词和前几条消息的哈希
+)
+
+// AffinitySetting 会话粘性路由，让相同会话尽量命中同一渠道以提高上游提示词缓存命中率
+type AffinitySetting struct {
+	Enabled    bool   `json:"enabled"`
+	Mode       string `json:"mode"`
+	TTLSeconds int    `json:"ttl_seconds"`
+}
+
+// HedgingSetting 对冲请求，首个渠道在 DelayMs 内没有返回首字节时向另一个渠道发送相同请求，使用先返回的结果
+type HedgingSetting struct {
+	Enabled    bool `json:"enabled"`
+	DelayMs    int  `json:"delay_ms"`
+	ChargeBoth bool `json:"charge_both"` // 两次请求都计费，落选的请求不取消，执行完成后丢弃响应
+}
+
 type LimitsConfig struct {
 	LimitModelSetting LimitModelSetting `json:"limit_model_setting,omitempty"`
 	LimitsIPSetting   LimitsIPSetting   `json:"limits_ip_setting,omitempty"`
@@ -26,7 +26,9 @@ func SetupDB() {
 		logger.FatalLog("failed to initialize database: " + err.Error())
 	}
 	GlobalModelMappingCache.Load()
+	GlobalErrorPolicy.Load()
 	ChannelGroup.Load()
+	StartContextObservationFlusher()
 	GlobalUserGroupRatio.Load()
 	config.RootUserEmail = GetRootUserEmail()
 	NewModelOwnedBys()
@@ -136,6 +138,22 @@ func InitDB() (err error) {
 		if err != nil {
 			return err
 		}
+		err = db.AutoMigrate(&ChannelModelDrift{})
+		if err != nil {
+			return err
+		}
+		err = db.AutoMigrate(&ChannelCapability{})
+		if err != nil {
+			return err
+		}
+		err = db.AutoMigrate(&ChannelAuthenticity{})
+		if err != nil {
+			return err
+		}
+		err = db.AutoMigrate(&ErrorPolicy{})
+		if err != nil {
+			return err
+		}
 		err = db.AutoMigrate(&Key{})
 		if err != nil {
 			return err
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// 触发模型回退的错误类型
+const (
+	FallbackOnNoChannel   = "no_channel"   // 没有可用渠道
+	FallbackOnRateLimit   = "rate_limit"   // 429
+	FallbackOnTimeout     = "timeout"      // 408/504/524
+	FallbackOnServerError = "server_error" // 5xx 及流式错误
+	FallbackOnOther       = "other"        // 其他上游错误，如 401/403/404
+)
+
+var fallbackErrorClasses = map[string]struct{}{
+	FallbackOnNoChannel:   {},
+	FallbackOnRateLimit:   {},
+	FallbackOnTimeout:     {},
+	FallbackOnServerError: {},
+	FallbackOnOther:       {},
+}
+
+// ModelFallback 模型回退链，当前模型的所有渠道都失败后按顺序改用备用模型
+type ModelFallback struct {
+	Models   []string `json:"models"`
+	OnErrors []string `json:"on_errors,omitempty"` // 为空时任意错误类型都会回退
+}
+
+// Allow 判断该错误类型是否触发回退
+func (f *ModelFallback) Allow(errorClass string) bool {
+	if len(f.OnErrors) == 0 {
+		return true
+	}
+
+	for _, onError := range f.OnErrors {
+		if onError == errorClass {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateModelFallbacks 校验回退链配置
+func ValidateModelFallbacks(fallbacks map[string]ModelFallback) error {
+	for modelName, fallback := range fallbacks {
+		if strings.TrimSpace(modelName) == "" {
+			return fmt.Errorf("回退链的模型名称不能为空")
+		}
+		if len(fallback.Models) == 0 {
+			return fmt.Errorf("模型 %s 的回退链为空", modelName)
+		}
+		for _, fallbackModel := range fallback.Models {
+			if strings.TrimSpace(fallbackModel) == "" || fallbackModel == modelName {
+				return fmt.Errorf("模型 %s 的回退模型 %s 无效", modelName, fallbackModel)
+			}
+		}
+		for _, onError := range fallback.OnErrors {
+			if _, ok := fallbackErrorClasses[onError]; !ok {
+				return fmt.Errorf("模型 %s 的回退错误类型 %s 无效", modelName, onError)
+			}
+		}
+	}
+
+	return nil
+}
+
+type ModelFallbackCacheType struct {
+	sync.RWMutex
+	fallbacks map[string]ModelFallback
+}
+
+// GlobalModelFallback 全局模型回退链，令牌设置中的同名模型优先
+var GlobalModelFallback = &ModelFallbackCacheType{
+	fallbacks: map[string]ModelFallback{},
+}
+
+func (m *ModelFallbackCacheType) Load(value string) error {
+	fallbacks := map[string]ModelFallback{}
+	if strings.TrimSpace(value) != "" {
+		if err := json.Unmarshal([]byte(value), &fallbacks); err != nil {
+			return err
+		}
+		if err := ValidateModelFallbacks(fallbacks); err != nil {
+			return err
+		}
+	}
+
+	m.Lock()
+	m.fallbacks = fallbacks
+	m.Unlock()
+	return nil
+}
+
+func (m *ModelFallbackCacheType) JSONString() string {
+	m.RLock()
+	defer m.RUnlock()
+
+	jsonBytes, err := json.Marshal(m.fallbacks)
+	if err != nil {
+		return ""
+	}
+	return string(jsonBytes)
+}
+
+func (m *ModelFallbackCacheType) Get(modelName string) *ModelFallback {
+	m.RLock()
+	defer m.RUnlock()
+
+	fallback, ok := m.fallbacks[modelName]
+	if !ok {
+		return nil
+	}
+	return &fallback
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelFallbackLoad(t *testing.T) {
+	cache := &ModelFallbackCacheType{}
+	err := cache.Load(`{"claude-sonnet":{"models":["gpt-4.1","gemini-2.5-pro"],"on_errors":["no_channel","rate_limit"]}}`)
+	require.NoError(t, err)
+
+	fallback := cache.Get("claude-sonnet")
+	require.NotNil(t, fallback)
+	assert.Equal(t, []string{"gpt-4.1", "gemini-2.5-pro"}, fallback.Models)
+	assert.True(t, fallback.Allow(FallbackOnRateLimit))
+	assert.False(t, fallback.Allow(FallbackOnServerError))
+	assert.Nil(t, cache.Get("gpt-4.1"))
+
+	require.NoError(t, cache.Load(""))
+	assert.Nil(t, cache.Get("claude-sonnet"))
+	assert.Equal(t, "{}", cache.JSONString())
+}
+
+func TestModelFallbackLoadInvalid(t *testing.T) {
+	cache := &ModelFallbackCacheType{}
+	require.NoError(t, cache.Load(`{"a":{"models":["b"]}}`))
+
+	assert.Error(t, cache.Load(`{"a":{"models":[]}}`))
+	assert.Error(t, cache.Load(`{"a":{"models":["a"]}}`))
+	assert.Error(t, cache.Load(`{"a":{"models":["b"],"on_errors":["unknown"]}}`))
+	assert.Error(t, cache.Load(`not json`))
+
+	// 无效配置不覆盖已有配置
+	assert.NotNil(t, cache.Get("a"))
+}
+
+func TestModelFallbackAllowAnyError(t *testing.T) {
+	fallback := &ModelFallback{Models: []string{"b"}}
+	assert.True(t, fallback.Allow(FallbackOnOther))
+	assert.True(t, fallback.Allow(FallbackOnNoChannel))
+}
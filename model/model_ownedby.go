@@ -173,6 +173,16 @@ func GetDefaultModelOwnedBy() []*ModelOwnedBy {
 		{Id: config.ChannelTypeMiniMax, Name: "MiniMax", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/minimax-color.svg"},
 		{Id: config.ChannelTypeDeepseek, Name: "Deepseek", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/deepseek-color.svg"},
 		{Id: config.ChannelTypeMoonshot, Name: "Moonshot", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/moonshot.svg"},
+		{Id: config.ChannelTypeMistral, Name: "Mistral", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/mistral-color.svg"},
+		{Id: config.ChannelTypeOpenRouter, Name: "OpenRouter", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/openrouter.svg"},
+		{Id: config.ChannelTypeHunyuan, Name: "Hunyuan", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/hunyuan-color.svg"},
+		{Id: config.ChannelTypeVolcengine, Name: "Doubao", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/doubao-color.svg"},
+		{Id: config.ChannelTypeJina, Name: "Jina", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/jina.svg"},
+		{Id: config.ChannelTypeVoyage, Name: "Voyage", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/voyage-color.svg"},
+		{Id: config.ChannelTypeElevenLabs, Name: "ElevenLabs", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/elevenlabs.svg"},
+		{Id: config.ChannelTypeStability, Name: "Stability", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/stability-color.svg"},
+		{Id: config.ChannelTypeFlux, Name: "Black Forest Labs", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/bfl.svg"},
+		{Id: config.ChannelTypeIdeogram, Name: "Ideogram", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/ideogram.svg"},
 		{Id: config.ChannelTypeCloudflareAI, Name: "Cloudflare AI", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/cloudflare-color.svg"},
 		{Id: config.ChannelTypeOllama, Name: "Ollama", Icon: "https://registry.npmmirror.com/@lobehub/icons-static-svg/latest/files/icons/ollama.svg"},
 	}
@@ -96,6 +96,14 @@ func InitOptionMap() {
 	config.GlobalOption.RegisterString("ChatLinks", &config.ChatLinks)
 	config.GlobalOption.RegisterInt("RetryTimes", &config.RetryTimes)
 	config.GlobalOption.RegisterInt("RetryCooldownSeconds", &config.RetryCooldownSeconds)
+	config.GlobalOption.RegisterBool("CircuitBreakerEnabled", &config.CircuitBreakerEnabled)
+	config.GlobalOption.RegisterInt("CircuitBreakerFailureThreshold", &config.CircuitBreakerFailureThreshold)
+	config.GlobalOption.RegisterInt("CircuitBreakerWindowSeconds", &config.CircuitBreakerWindowSeconds)
+	config.GlobalOption.RegisterInt("CircuitBreakerOpenSeconds", &config.CircuitBreakerOpenSeconds)
+	config.GlobalOption.RegisterInt("CircuitBreakerHalfOpenProbes", &config.CircuitBreakerHalfOpenProbes)
+	config.GlobalOption.RegisterInt("ChannelQueueSize", &config.ChannelQueueSize)
+	config.GlobalOption.RegisterInt("ChannelQueueTimeout", &config.ChannelQueueTimeout)
+	config.GlobalOption.RegisterCustom("ModelFallback", GlobalModelFallback.JSONString, GlobalModelFallback.Load, "")
 
 	config.GlobalOption.RegisterString("ChatImageRequestProxy", &config.ChatImageRequestProxy)
 	config.GlobalOption.RegisterFloat("PaymentUSDRate", &config.PaymentUSDRate)
@@ -61,6 +61,11 @@ var priceSyncProviders = map[string]PriceSyncProvider{
 	"gemini": newGeminiPriceSyncProvider(),
 }
 
+// RegisterPriceSyncProvider 注册价格同步来源，供应商包在 init 中调用
+func RegisterPriceSyncProvider(provider PriceSyncProvider) {
+	priceSyncProviders[provider.Key()] = provider
+}
+
 func GetPriceSyncProviders() []PriceSyncProviderMeta {
 	metas := make([]PriceSyncProviderMeta, 0, len(priceSyncProviders))
 	for _, provider := range priceSyncProviders {
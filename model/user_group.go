@@ -65,6 +65,7 @@ type UserGroup struct {
 	Max            int                                      `json:"max" form:"max" gorm:"default:0"`
 	Enable         *bool                                    `json:"enable" form:"enable" gorm:"default:true"`
 	IsDefault      bool                                     `json:"is_default" form:"is_default" gorm:"default:false"`
+	BalanceMode    string                                   `json:"balance_mode" form:"balance_mode" gorm:"type:varchar(20);default:''"`
 	ProviderRatios *datatypes.JSONType[[]ProviderRatioRule] `json:"provider_ratios,omitempty" gorm:"type:json"`
 }
 
@@ -116,6 +117,7 @@ func (c *UserGroup) normalize() {
 	c.Symbol = strings.TrimSpace(c.Symbol)
 	c.Name = strings.TrimSpace(c.Name)
 	c.SetProviderRatioRules(c.GetProviderRatioRules())
+	c.BalanceMode = strings.TrimSpace(c.BalanceMode)
 }
 
 func getUserGroupColumnName() string {
@@ -157,6 +159,9 @@ func (c *UserGroup) Create() error {
 	if err := c.ValidateProviderRatios(); err != nil {
 		return err
 	}
+	if !IsValidBalanceMode(c.BalanceMode) {
+		return fmt.Errorf("balance_mode %s 无效", c.BalanceMode)
+	}
 
 	db := DB.Session(&gorm.Session{PrepareStmt: false})
 	err := db.Create(c).Error
@@ -172,6 +177,9 @@ func (c *UserGroup) Update() error {
 	if err := c.ValidateProviderRatios(); err != nil {
 		return err
 	}
+	if !IsValidBalanceMode(c.BalanceMode) {
+		return fmt.Errorf("balance_mode %s 无效", c.BalanceMode)
+	}
 	if c.Id == 0 {
 		return errors.New("id 涓虹┖")
 	}
@@ -258,7 +266,7 @@ func (c *UserGroup) Update() error {
 
 		return tx.Model(&UserGroup{}).
 			Where("id = ?", c.Id).
-			Select("symbol", "name", "ratio", "public", "api_rate", "promotion", "min", "max", "provider_ratios").
+			Select("symbol", "name", "ratio", "public", "api_rate", "promotion", "min", "max", "provider_ratios", "balance_mode").
 			Updates(c).Error
 	})
 	if err == nil {
@@ -389,6 +397,16 @@ func (cgrm *UserGroupRatio) GetAPIRate(symbol string) int {
 	return userGroup.APIRate
 }
 
+// GetBalanceMode 获取分组的负载均衡模式，未配置时使用静态权重
+func (cgrm *UserGroupRatio) GetBalanceMode(symbol string) string {
+	userGroup := cgrm.GetBySymbol(symbol)
+	if userGroup == nil || userGroup.BalanceMode == "" {
+		return BalanceModeWeight
+	}
+
+	return userGroup.BalanceMode
+}
+
 func (cgrm *UserGroupRatio) GetPublicGroupList() []string {
 	cgrm.RLock()
 	defer cgrm.RUnlock()
@@ -32,6 +32,7 @@ type ProviderConfig struct {
 	ChatRealtime        string
 	Responses           string
 	ResponsesWS         string
+	OCR                 string
 }
 
 func (pc *ProviderConfig) SetAPIUri(customMapping map[string]interface{}) {
@@ -48,6 +49,7 @@ func (pc *ProviderConfig) SetAPIUri(customMapping map[string]interface{}) {
 		config.RelayModeImagesVariations:   &pc.ImagesVariations,
 		config.RelayModeResponses:          &pc.Responses,
 		config.RelayModeResponsesWS:        &pc.ResponsesWS,
+		config.RelayModeOCR:                &pc.OCR,
 	}
 
 	for key, value := range customMapping {
@@ -203,6 +205,8 @@ func (p *BaseProvider) GetAPIUri(relayMode int) string {
 		return p.Config.Responses
 	case config.RelayModeResponsesWS:
 		return p.Config.ResponsesWS
+	case config.RelayModeOCR:
+		return p.Config.OCR
 	default:
 		return ""
 	}
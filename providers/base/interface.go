@@ -130,6 +130,12 @@ type RerankInterface interface {
 	CreateRerank(request *types.RerankRequest) (*types.RerankResponse, *types.OpenAIErrorWithStatusCode)
 }
 
+// 文档 OCR 接口，用量中的 PromptTokens 为处理的页数
+type OCRInterface interface {
+	ProviderInterface
+	CreateOCR(request *types.OCRRequest) (*types.OCRResponse, *types.OpenAIErrorWithStatusCode)
+}
+
 type RealtimeInterface interface {
 	ProviderInterface
 	CreateChatRealtime(modelName string) (*websocket.Conn, requester.MessageHandler, *types.OpenAIErrorWithStatusCode)
@@ -0,0 +1,85 @@
+package elevenlabs
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"czloapi/common/requester"
+	"czloapi/model"
+	"czloapi/providers/base"
+	"czloapi/types"
+)
+
+type ElevenLabsProviderFactory struct{}
+
+// 创建 ElevenLabsProvider
+// https://elevenlabs.io/docs/api-reference/text-to-speech/stream
+func (f ElevenLabsProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
+	return &ElevenLabsProvider{
+		BaseProvider: base.BaseProvider{
+			Config:    getConfig(),
+			Channel:   channel,
+			Requester: requester.NewHTTPRequester(*channel.Proxy, requestErrorHandle),
+		},
+	}
+}
+
+type ElevenLabsProvider struct {
+	base.BaseProvider
+}
+
+func getConfig() base.ProviderConfig {
+	return base.ProviderConfig{
+		BaseURL:     "https://api.elevenlabs.io",
+		AudioSpeech: "/v1/text-to-speech/%s/stream",
+	}
+}
+
+// 请求错误处理
+func requestErrorHandle(resp *http.Response) *types.OpenAIError {
+	elevenLabsError := &ElevenLabsError{}
+	err := json.NewDecoder(resp.Body).Decode(elevenLabsError)
+	if err != nil {
+		return nil
+	}
+
+	return errorHandle(elevenLabsError)
+}
+
+// 错误处理，detail 可能是 {"status","message"} 对象，也可能是参数校验的错误列表
+func errorHandle(elevenLabsError *ElevenLabsError) *types.OpenAIError {
+	if elevenLabsError.Detail == nil {
+		return nil
+	}
+
+	openaiError := &types.OpenAIError{
+		Type: "elevenlabs_error",
+	}
+
+	switch detail := elevenLabsError.Detail.(type) {
+	case string:
+		openaiError.Message = detail
+	case map[string]any:
+		openaiError.Message, _ = detail["message"].(string)
+		if status, ok := detail["status"].(string); ok {
+			openaiError.Code = status
+		}
+	}
+
+	if openaiError.Message == "" {
+		detail, _ := json.Marshal(elevenLabsError.Detail)
+		openaiError.Message = string(detail)
+	}
+
+	return openaiError
+}
+
+func (p *ElevenLabsProvider) GetRequestHeaders() (headers map[string]string) {
+	headers = make(map[string]string)
+	p.CommonRequestHeaders(headers)
+	headers["xi-api-key"] = p.Channel.Key
+	headers["Content-Type"] = "application/json"
+	headers["Accept"] = "*/*"
+
+	return headers
+}
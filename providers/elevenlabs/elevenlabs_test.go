@@ -0,0 +1,58 @@
+package elevenlabs
+
+import (
+	"net/http"
+	"testing"
+
+	"czloapi/model"
+	"czloapi/types"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestGetVoiceID(t *testing.T) {
+	plugin := datatypes.NewJSONType(model.PluginType{
+		"voice": {"alloy": "custom-voice-id", "unknown": "ignored"},
+	})
+	provider := &ElevenLabsProvider{}
+	provider.Channel = &model.Channel{Plugin: &plugin}
+
+	assert.Equal(t, "custom-voice-id", provider.getVoiceID("alloy"))
+	assert.Equal(t, "pNInz6obpgDQGcFmaJgB", provider.getVoiceID("echo"))
+	assert.Equal(t, "JBFqnCBsd6RMkjVDRZzb", provider.getVoiceID("JBFqnCBsd6RMkjVDRZzb"))
+}
+
+func TestGetRequestBody(t *testing.T) {
+	provider := &ElevenLabsProvider{}
+	provider.Channel = &model.Channel{}
+
+	speechRequest := provider.getRequestBody(&types.SpeechAudioRequest{Model: "tts-1", Input: "hello", Speed: 2})
+	assert.Equal(t, "hello", speechRequest.Text)
+	assert.Equal(t, "eleven_flash_v2_5", speechRequest.ModelID)
+	assert.Equal(t, maxSpeed, *speechRequest.VoiceSettings.Speed)
+
+	speechRequest = provider.getRequestBody(&types.SpeechAudioRequest{Model: "eleven_v3", Input: "hello", Speed: 1})
+	assert.Equal(t, "eleven_v3", speechRequest.ModelID)
+	assert.Nil(t, speechRequest.VoiceSettings)
+
+	assert.Equal(t, "pcm_24000", getOutputFormat("pcm"))
+	assert.Equal(t, "mp3_44100_128", getOutputFormat("aac"))
+	assert.Equal(t, "mp3_22050_32", getOutputFormat("mp3_22050_32"))
+}
+
+func TestGetCharacterCost(t *testing.T) {
+	assert.Equal(t, 4, getCharacterCost(http.Header{}, "你好世界"))
+
+	header := http.Header{}
+	header.Set("character-cost", "10")
+	assert.Equal(t, 10, getCharacterCost(header, "你好世界"))
+}
+
+func TestErrorHandle(t *testing.T) {
+	openaiError := errorHandle(&ElevenLabsError{Detail: map[string]any{"status": "voice_not_found", "message": "voice not found"}})
+	assert.Equal(t, "voice not found", openaiError.Message)
+	assert.Equal(t, "voice_not_found", openaiError.Code)
+
+	assert.Nil(t, errorHandle(&ElevenLabsError{}))
+}
@@ -0,0 +1,179 @@
+package elevenlabs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/types"
+)
+
+const (
+	defaultModel = "eleven_multilingual_v2"
+
+	// voice_settings.speed 的取值范围
+	minSpeed = 0.7
+	maxSpeed = 1.2
+)
+
+// OpenAI 的 response_format 对应的 ElevenLabs output_format，aac、flac 不支持，使用 mp3
+var outputFormatMap = map[string]string{
+	"mp3":  "mp3_44100_128",
+	"opus": "opus_48000_128",
+	"pcm":  "pcm_24000",
+	"wav":  "wav_44100",
+	"ulaw": "ulaw_8000",
+}
+
+// GetVoiceMap 将 OpenAI 的声音映射到 ElevenLabs 的预置声音 ID，可在插件中自定义
+func (p *ElevenLabsProvider) GetVoiceMap() map[string]string {
+	defaultVoiceMapping := map[string]string{
+		"alloy":   "21m00Tcm4TlvDq8ikWAM", // Rachel
+		"echo":    "pNInz6obpgDQGcFmaJgB", // Adam
+		"fable":   "ErXwobaYiN019PkySvjV", // Antoni
+		"onyx":    "TxGEqnHWrfWFTfGW9XjX", // Josh
+		"nova":    "EXAVITQu4vr4xnAQs1Ek", // Bella
+		"shimmer": "MF3mGyEYCl7XYWbV9V6O", // Elli
+	}
+
+	if p.Channel.Plugin == nil {
+		return defaultVoiceMapping
+	}
+
+	customVoiceMapping, ok := p.Channel.Plugin.Data()["voice"]
+	if !ok {
+		return defaultVoiceMapping
+	}
+
+	for key, value := range customVoiceMapping {
+		if _, exists := defaultVoiceMapping[key]; !exists {
+			continue
+		}
+		customVoiceValue, isString := value.(string)
+		if !isString || customVoiceValue == "" {
+			continue
+		}
+		defaultVoiceMapping[key] = customVoiceValue
+	}
+
+	return defaultVoiceMapping
+}
+
+// getVoiceID 未在映射中的声音视为 ElevenLabs 的声音 ID 直接透传
+func (p *ElevenLabsProvider) getVoiceID(voice string) string {
+	if voiceID, ok := p.GetVoiceMap()[voice]; ok {
+		return voiceID
+	}
+
+	return voice
+}
+
+// getModelID tts-1 使用低延迟的 flash 模型，其余 OpenAI 模型使用默认模型
+func getModelID(model string) string {
+	switch {
+	case strings.HasPrefix(model, "eleven_"):
+		return model
+	case model == "tts-1":
+		return "eleven_flash_v2_5"
+	default:
+		return defaultModel
+	}
+}
+
+// getOutputFormat 已经是 ElevenLabs 格式（如 mp3_22050_32）时直接透传
+func getOutputFormat(responseFormat string) string {
+	if strings.Contains(responseFormat, "_") {
+		return responseFormat
+	}
+	if outputFormat, ok := outputFormatMap[responseFormat]; ok {
+		return outputFormat
+	}
+
+	return outputFormatMap["mp3"]
+}
+
+func convertSpeed(speed float64) *float64 {
+	if speed == 0 || speed == 1 {
+		return nil
+	}
+
+	speed = min(max(speed, minSpeed), maxSpeed)
+	return &speed
+}
+
+func (p *ElevenLabsProvider) getRequestBody(request *types.SpeechAudioRequest) *SpeechRequest {
+	speechRequest := &SpeechRequest{}
+
+	if p.Context != nil {
+		if rawBody, ok := p.GetRawBody(); ok {
+			// language_code、seed、voice_settings 为 ElevenLabs 的扩展参数
+			_ = json.Unmarshal(rawBody, speechRequest)
+		}
+	}
+
+	speechRequest.Text = request.Input
+	speechRequest.ModelID = getModelID(request.Model)
+
+	if speed := convertSpeed(request.Speed); speed != nil {
+		if speechRequest.VoiceSettings == nil {
+			speechRequest.VoiceSettings = &VoiceSettings{}
+		}
+		speechRequest.VoiceSettings.Speed = speed
+	}
+
+	return speechRequest
+}
+
+// CreateSpeech 使用流式接口，音频边生成边返回，按字符计费
+func (p *ElevenLabsProvider) CreateSpeech(request *types.SpeechAudioRequest) (*http.Response, *types.OpenAIErrorWithStatusCode) {
+	uri, errWithCode := p.GetSupportedAPIUri(config.RelayModeAudioSpeech)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	uri = fmt.Sprintf(uri, url.PathEscape(p.getVoiceID(request.Voice)))
+	query := url.Values{}
+	query.Set("output_format", getOutputFormat(request.ResponseFormat))
+	fullRequestURL := p.GetFullRequestURL(uri, request.Model) + "?" + query.Encode()
+	headers := p.GetRequestHeaders()
+
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(p.getRequestBody(request)), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	defer req.Body.Close()
+
+	resp, errWithCode := p.Requester.SendRequestRaw(req)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	// 只返回音频相关的响应头，避免透传上游的计费等信息
+	response := &http.Response{
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Body:       resp.Body,
+		Header:     make(http.Header),
+	}
+	response.Header.Set("Content-Type", resp.Header.Get("Content-Type"))
+
+	p.Usage.PromptTokens = getCharacterCost(resp.Header, request.Input)
+	p.Usage.TotalTokens = p.Usage.PromptTokens
+
+	return response, nil
+}
+
+// getCharacterCost 优先使用响应头中的实际扣费字符数，没有时按输入文本的字符数计算
+func getCharacterCost(header http.Header, input string) int {
+	if characterCost, err := strconv.Atoi(header.Get("character-cost")); err == nil && characterCost > 0 {
+		return characterCost
+	}
+
+	return utf8.RuneCountInString(input)
+}
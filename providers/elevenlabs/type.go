@@ -0,0 +1,21 @@
+package elevenlabs
+
+type ElevenLabsError struct {
+	Detail any `json:"detail,omitempty"`
+}
+
+type SpeechRequest struct {
+	Text          string         `json:"text"`
+	ModelID       string         `json:"model_id,omitempty"`
+	LanguageCode  string         `json:"language_code,omitempty"`
+	VoiceSettings *VoiceSettings `json:"voice_settings,omitempty"`
+	Seed          *int           `json:"seed,omitempty"`
+}
+
+type VoiceSettings struct {
+	Stability       *float64 `json:"stability,omitempty"`
+	SimilarityBoost *float64 `json:"similarity_boost,omitempty"`
+	Style           *float64 `json:"style,omitempty"`
+	UseSpeakerBoost *bool    `json:"use_speaker_boost,omitempty"`
+	Speed           *float64 `json:"speed,omitempty"`
+}
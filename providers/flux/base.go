@@ -0,0 +1,80 @@
+package flux
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"czloapi/common/requester"
+	"czloapi/model"
+	"czloapi/providers/base"
+	"czloapi/types"
+)
+
+type FluxProviderFactory struct{}
+
+// 创建 FluxProvider
+// https://docs.bfl.ai/api-reference
+func (f FluxProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
+	return &FluxProvider{
+		BaseProvider: base.BaseProvider{
+			Config:    getConfig(),
+			Channel:   channel,
+			Requester: requester.NewHTTPRequester(*channel.Proxy, requestErrorHandle),
+		},
+	}
+}
+
+type FluxProvider struct {
+	base.BaseProvider
+}
+
+// 生成和编辑都是提交到 /v1/{模型} 的异步任务
+func getConfig() base.ProviderConfig {
+	return base.ProviderConfig{
+		BaseURL:           "https://api.bfl.ai",
+		ImagesGenerations: "/v1/%s",
+		ImagesEdit:        "/v1/%s",
+	}
+}
+
+// 请求错误处理
+func requestErrorHandle(resp *http.Response) *types.OpenAIError {
+	fluxError := &FluxError{}
+	err := json.NewDecoder(resp.Body).Decode(fluxError)
+	if err != nil {
+		return nil
+	}
+
+	return errorHandle(fluxError)
+}
+
+// 错误处理，参数校验失败时 detail 为错误列表
+func errorHandle(fluxError *FluxError) *types.OpenAIError {
+	if fluxError.Detail == nil {
+		return nil
+	}
+
+	message, ok := fluxError.Detail.(string)
+	if !ok {
+		detail, _ := json.Marshal(fluxError.Detail)
+		message = string(detail)
+	}
+	if message == "" {
+		return nil
+	}
+
+	return &types.OpenAIError{
+		Message: message,
+		Type:    "flux_error",
+	}
+}
+
+func (p *FluxProvider) GetRequestHeaders() (headers map[string]string) {
+	headers = make(map[string]string)
+	p.CommonRequestHeaders(headers)
+	headers["x-key"] = p.Channel.Key
+	headers["Content-Type"] = "application/json"
+	headers["Accept"] = "application/json"
+
+	return headers
+}
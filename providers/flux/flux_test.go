@@ -0,0 +1,57 @@
+package flux
+
+import (
+	"testing"
+
+	"czloapi/model"
+	"czloapi/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewImageRequest(t *testing.T) {
+	provider := &FluxProvider{}
+	provider.Channel = &model.Channel{}
+
+	safetyTolerance := "2"
+	promptUpsampling := "true"
+	fluxRequest := provider.newImageRequest(&types.ImageRequest{
+		Model:            "flux-pro-1.1",
+		Prompt:           "a cat",
+		Size:             "1000x1500",
+		SafetyTolerance:  &safetyTolerance,
+		PromptUpsampling: &promptUpsampling,
+	})
+	assert.Equal(t, 992, fluxRequest.Width)
+	assert.Equal(t, maxImageSize, fluxRequest.Height)
+	assert.Empty(t, fluxRequest.AspectRatio)
+	assert.Equal(t, 2, *fluxRequest.SafetyTolerance)
+	assert.True(t, *fluxRequest.PromptUpsampling)
+
+	fluxRequest = provider.newImageRequest(&types.ImageRequest{
+		Model:  "flux-pro-1.1-ultra",
+		Prompt: "a cat",
+		Size:   "1792x1024",
+	})
+	assert.Equal(t, "16:9", fluxRequest.AspectRatio)
+	assert.Zero(t, fluxRequest.Width)
+}
+
+func TestGetPollingURL(t *testing.T) {
+	provider := &FluxProvider{}
+	provider.Config = getConfig()
+	provider.Channel = &model.Channel{}
+
+	assert.Equal(t, "https://api.us1.bfl.ai/v1/get_result?id=abc", provider.getPollingURL(&TaskResponse{ID: "abc", PollingURL: "https://api.us1.bfl.ai/v1/get_result?id=abc"}))
+	assert.Equal(t, "https://api.bfl.ai/v1/get_result?id=abc", provider.getPollingURL(&TaskResponse{ID: "abc"}))
+}
+
+func TestErrorHandle(t *testing.T) {
+	openaiError := errorHandle(&FluxError{Detail: "Insufficient credits"})
+	assert.Equal(t, "Insufficient credits", openaiError.Message)
+
+	openaiError = errorHandle(&FluxError{Detail: []any{map[string]any{"msg": "field required"}}})
+	assert.Contains(t, openaiError.Message, "field required")
+
+	assert.Nil(t, errorHandle(&FluxError{}))
+}
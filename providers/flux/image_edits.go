@@ -0,0 +1,102 @@
+package flux
+
+import (
+	"encoding/base64"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/types"
+)
+
+// 带 mask 的局部重绘使用 Fill 模型
+const fillModel = "flux-pro-1.0-fill"
+
+// CreateImageEdits Kontext 系列以 input_image 作为参考图，带 mask 时使用 Fill 模型
+func (p *FluxProvider) CreateImageEdits(request *types.ImageEditRequest) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	imageFile := request.Image
+	if imageFile == nil && len(request.Images) > 0 {
+		imageFile = request.Images[0]
+	}
+	if imageFile == nil {
+		return nil, common.StringErrorWrapperLocal("image is required", "invalid_request", http.StatusBadRequest)
+	}
+
+	imageData, err := readFileBase64(imageFile)
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "read_image_failed", http.StatusInternalServerError)
+	}
+
+	fluxRequest := &ImageRequest{
+		Prompt: request.Prompt,
+	}
+
+	modelName := request.Model
+	if request.Mask != nil && !strings.Contains(modelName, "fill") {
+		modelName = fillModel
+	}
+
+	if strings.Contains(modelName, "fill") {
+		fluxRequest.Image = imageData
+		if request.Mask != nil {
+			fluxRequest.Mask, err = readFileBase64(request.Mask)
+			if err != nil {
+				return nil, common.ErrorWrapper(err, "read_mask_failed", http.StatusInternalServerError)
+			}
+		}
+	} else {
+		fluxRequest.InputImage = imageData
+	}
+
+	p.setImageEditOptions(fluxRequest)
+
+	return p.createImages(config.RelayModeImagesEdits, modelName, fluxRequest, request.N, request.ResponseFormat)
+}
+
+// setImageEditOptions 编辑接口为表单请求，扩展参数从表单中读取
+func (p *FluxProvider) setImageEditOptions(fluxRequest *ImageRequest) {
+	if p.Context == nil {
+		return
+	}
+
+	if seed, err := strconv.ParseInt(p.Context.PostForm("seed"), 10, 64); err == nil {
+		fluxRequest.Seed = &seed
+	}
+	if steps, err := strconv.Atoi(p.Context.PostForm("steps")); err == nil {
+		fluxRequest.Steps = &steps
+	}
+	if guidance, err := strconv.ParseFloat(p.Context.PostForm("guidance"), 64); err == nil {
+		fluxRequest.Guidance = &guidance
+	}
+	if safetyTolerance, err := strconv.Atoi(p.Context.PostForm("safety_tolerance")); err == nil {
+		fluxRequest.SafetyTolerance = &safetyTolerance
+	}
+	if promptUpsampling, err := strconv.ParseBool(p.Context.PostForm("prompt_upsampling")); err == nil {
+		fluxRequest.PromptUpsampling = &promptUpsampling
+	}
+	if aspectRatio := p.Context.PostForm("aspect_ratio"); aspectRatio != "" {
+		fluxRequest.AspectRatio = aspectRatio
+	}
+	if outputFormat := p.Context.PostForm("output_format"); outputFormat == "png" || outputFormat == "jpeg" {
+		fluxRequest.OutputFormat = outputFormat
+	}
+}
+
+func readFileBase64(fileHeader *multipart.FileHeader) (string, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
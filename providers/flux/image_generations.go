@@ -0,0 +1,269 @@
+package flux
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/common/image"
+	"czloapi/common/storage"
+	"czloapi/common/utils"
+	"czloapi/types"
+)
+
+const (
+	statusReady            = "Ready"
+	statusPending          = "Pending"
+	statusRequestModerated = "Request Moderated"
+	statusContentModerated = "Content Moderated"
+
+	resultPollInterval = time.Second
+	resultMaxPolls     = 120
+
+	// 宽高需要是 32 的倍数
+	sizeMultiple = 32
+	minImageSize = 256
+	maxImageSize = 1440
+)
+
+var aspectRatios = []string{"21:9", "16:9", "3:2", "4:3", "1:1", "3:4", "2:3", "9:16", "9:21"}
+
+// CreateImageGenerations 每个任务只生成一张图片，n 大于 1 时先全部提交再依次轮询
+func (p *FluxProvider) CreateImageGenerations(request *types.ImageRequest) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	fluxRequest := p.newImageRequest(request)
+	return p.createImages(config.RelayModeImagesGenerations, request.Model, fluxRequest, request.N, request.ResponseFormat)
+}
+
+func (p *FluxProvider) newImageRequest(request *types.ImageRequest) *ImageRequest {
+	fluxRequest := &ImageRequest{
+		Prompt: request.Prompt,
+	}
+
+	if usesAspectRatio(request.Model) {
+		if request.AspectRatio != nil && *request.AspectRatio != "" {
+			fluxRequest.AspectRatio = *request.AspectRatio
+		} else {
+			fluxRequest.AspectRatio = image.GetClosestAspectRatio(request.Size, aspectRatios)
+		}
+	} else {
+		fluxRequest.Width, fluxRequest.Height = convertSize(request.Size)
+	}
+
+	if request.SafetyTolerance != nil {
+		if safetyTolerance, err := strconv.Atoi(*request.SafetyTolerance); err == nil {
+			fluxRequest.SafetyTolerance = &safetyTolerance
+		}
+	}
+	if request.PromptUpsampling != nil {
+		if promptUpsampling, err := strconv.ParseBool(*request.PromptUpsampling); err == nil {
+			fluxRequest.PromptUpsampling = &promptUpsampling
+		}
+	}
+	if request.OutputFormat != nil && (*request.OutputFormat == "png" || *request.OutputFormat == "jpeg") {
+		fluxRequest.OutputFormat = *request.OutputFormat
+	}
+
+	if p.Context == nil {
+		return fluxRequest
+	}
+	rawBody, ok := p.GetRawBody()
+	if !ok {
+		return fluxRequest
+	}
+
+	options := &ImageOptions{}
+	if err := json.Unmarshal(rawBody, options); err == nil {
+		fluxRequest.Seed = options.Seed
+		fluxRequest.Steps = options.Steps
+		fluxRequest.Guidance = options.Guidance
+		fluxRequest.Raw = options.Raw
+	}
+
+	return fluxRequest
+}
+
+// usesAspectRatio Ultra 和 Kontext 系列使用 aspect_ratio，其余模型使用宽高
+func usesAspectRatio(modelName string) bool {
+	return strings.Contains(modelName, "ultra") || strings.Contains(modelName, "kontext")
+}
+
+// convertSize 将 1024x1024 转换为宽高，按 32 取整并限制在允许范围内
+func convertSize(size string) (width, height int) {
+	parts := strings.Split(size, "x")
+	if len(parts) != 2 {
+		return 0, 0
+	}
+
+	width = roundSize(utils.String2Int(parts[0]))
+	height = roundSize(utils.String2Int(parts[1]))
+	if width == 0 || height == 0 {
+		return 0, 0
+	}
+
+	return width, height
+}
+
+func roundSize(size int) int {
+	if size <= 0 {
+		return 0
+	}
+
+	size = (size + sizeMultiple/2) / sizeMultiple * sizeMultiple
+	return min(max(size, minImageSize), maxImageSize)
+}
+
+func (p *FluxProvider) createImages(relayMode int, modelName string, fluxRequest *ImageRequest, n int, responseFormat string) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	if n <= 0 {
+		n = 1
+	}
+
+	tasks := make([]*TaskResponse, 0, n)
+	for i := 0; i < n; i++ {
+		// 指定 seed 时依次递增，避免生成相同的图片
+		taskRequest := *fluxRequest
+		if fluxRequest.Seed != nil {
+			seed := *fluxRequest.Seed + int64(i)
+			taskRequest.Seed = &seed
+		}
+
+		task, errWithCode := p.submitTask(relayMode, modelName, &taskRequest)
+		if errWithCode != nil {
+			return nil, errWithCode
+		}
+		tasks = append(tasks, task)
+	}
+
+	imageResponse := &types.ImageResponse{
+		Created: time.Now().Unix(),
+		Data:    make([]types.ImageResponseDataInner, 0, n),
+	}
+	for _, task := range tasks {
+		result, errWithCode := p.waitTask(task)
+		if errWithCode != nil {
+			return nil, errWithCode
+		}
+
+		item, errWithCode := convertImageData(result.Sample, fluxRequest.OutputFormat, responseFormat)
+		if errWithCode != nil {
+			return nil, errWithCode
+		}
+		imageResponse.Data = append(imageResponse.Data, *item)
+	}
+
+	p.Usage.PromptTokens = len(imageResponse.Data) * 1000
+	p.Usage.TotalTokens = p.Usage.PromptTokens
+
+	return imageResponse, nil
+}
+
+func (p *FluxProvider) submitTask(relayMode int, modelName string, fluxRequest *ImageRequest) (*TaskResponse, *types.OpenAIErrorWithStatusCode) {
+	uri, errWithCode := p.GetSupportedAPIUri(relayMode)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	fullRequestURL := p.GetFullRequestURL(fmt.Sprintf(uri, modelName), modelName)
+
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(fluxRequest), p.Requester.WithHeader(p.GetRequestHeaders()))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	defer req.Body.Close()
+
+	task := &TaskResponse{}
+	_, errWithCode = p.Requester.SendRequest(req, task, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	if task.ID == "" {
+		return nil, common.StringErrorWrapper("flux task id is empty", "flux_error", http.StatusInternalServerError)
+	}
+
+	return task, nil
+}
+
+// getPollingURL 优先使用提交任务时返回的 polling_url，任务可能在其他区域的节点上
+func (p *FluxProvider) getPollingURL(task *TaskResponse) string {
+	if task.PollingURL != "" {
+		return task.PollingURL
+	}
+
+	return p.GetFullRequestURL("/v1/get_result?id="+url.QueryEscape(task.ID), "")
+}
+
+func (p *FluxProvider) waitTask(task *TaskResponse) (*TaskResult, *types.OpenAIErrorWithStatusCode) {
+	pollingURL := p.getPollingURL(task)
+	headers := p.GetRequestHeaders()
+	delete(headers, "Content-Type")
+
+	for i := 0; i < resultMaxPolls; i++ {
+		if p.Context != nil && p.Context.Request != nil {
+			select {
+			case <-p.Context.Request.Context().Done():
+				return nil, common.ErrorWrapper(p.Context.Request.Context().Err(), "request_canceled", http.StatusRequestTimeout)
+			case <-time.After(resultPollInterval):
+			}
+		} else {
+			time.Sleep(resultPollInterval)
+		}
+
+		req, err := p.Requester.NewRequest(http.MethodGet, pollingURL, p.Requester.WithHeader(headers))
+		if err != nil {
+			return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+		}
+
+		result := &ResultResponse{}
+		_, errWithCode := p.Requester.SendRequest(req, result, false)
+		if errWithCode != nil {
+			return nil, errWithCode
+		}
+
+		switch result.Status {
+		case statusReady:
+			if result.Result == nil || result.Result.Sample == "" {
+				return nil, common.StringErrorWrapper("flux result is empty", "flux_error", http.StatusInternalServerError)
+			}
+			return result.Result, nil
+		case statusPending:
+			continue
+		case statusRequestModerated, statusContentModerated:
+			return nil, common.StringErrorWrapper(result.Status, "content_filter", http.StatusBadRequest)
+		default:
+			return nil, common.StringErrorWrapper("flux task failed: "+result.Status, "flux_error", http.StatusInternalServerError)
+		}
+	}
+
+	return nil, common.ErrorWrapper(errors.New("get image timeout"), "get_images_url_failed", http.StatusInternalServerError)
+}
+
+// convertImageData 结果链接只有 10 分钟有效期，url 格式时转存到存储，未配置存储时返回原链接
+func convertImageData(sample, outputFormat, responseFormat string) (*types.ImageResponseDataInner, *types.OpenAIErrorWithStatusCode) {
+	_, b64Image, err := image.GetImageFromUrl(sample)
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "get_image_failed", http.StatusInternalServerError)
+	}
+	if responseFormat == "b64_json" {
+		return &types.ImageResponseDataInner{B64JSON: b64Image}, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(b64Image)
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "decode_image_failed", http.StatusInternalServerError)
+	}
+	if outputFormat == "" {
+		outputFormat = "jpeg"
+	}
+
+	if url := storage.Upload(data, utils.GetUUID()+"."+outputFormat); url != "" {
+		return &types.ImageResponseDataInner{URL: url}, nil
+	}
+
+	return &types.ImageResponseDataInner{URL: sample}, nil
+}
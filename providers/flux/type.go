@@ -0,0 +1,49 @@
+package flux
+
+type FluxError struct {
+	Detail any `json:"detail,omitempty"`
+}
+
+type ImageRequest struct {
+	Prompt           string   `json:"prompt"`
+	Width            int      `json:"width,omitempty"`
+	Height           int      `json:"height,omitempty"`
+	AspectRatio      string   `json:"aspect_ratio,omitempty"`
+	Seed             *int64   `json:"seed,omitempty"`
+	Steps            *int     `json:"steps,omitempty"`
+	Guidance         *float64 `json:"guidance,omitempty"`
+	Raw              *bool    `json:"raw,omitempty"`
+	SafetyTolerance  *int     `json:"safety_tolerance,omitempty"`
+	PromptUpsampling *bool    `json:"prompt_upsampling,omitempty"`
+	OutputFormat     string   `json:"output_format,omitempty"`
+	// Kontext 系列的参考图
+	InputImage string `json:"input_image,omitempty"`
+	// Fill 系列的原图和遮罩
+	Image string `json:"image,omitempty"`
+	Mask  string `json:"mask,omitempty"`
+}
+
+// ImageOptions 是 OpenAI 请求中没有的参数，从原始请求中读取
+type ImageOptions struct {
+	Seed     *int64   `json:"seed"`
+	Steps    *int     `json:"steps"`
+	Guidance *float64 `json:"guidance"`
+	Raw      *bool    `json:"raw"`
+}
+
+type TaskResponse struct {
+	ID         string `json:"id"`
+	PollingURL string `json:"polling_url"`
+}
+
+type ResultResponse struct {
+	ID     string      `json:"id"`
+	Status string      `json:"status"`
+	Result *TaskResult `json:"result,omitempty"`
+}
+
+type TaskResult struct {
+	Sample string `json:"sample"`
+	Prompt string `json:"prompt"`
+	Seed   int64  `json:"seed"`
+}
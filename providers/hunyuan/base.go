@@ -0,0 +1,171 @@
+package hunyuan
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"czloapi/common"
+	"czloapi/common/requester"
+	"czloapi/model"
+	"czloapi/providers/base"
+	"czloapi/providers/hunyuan/tc3"
+	"czloapi/types"
+)
+
+const (
+	hunyuanService = "hunyuan"
+	hunyuanVersion = "2023-09-01"
+)
+
+type HunyuanProviderFactory struct{}
+
+// 创建 HunyuanProvider
+// https://cloud.tencent.com/document/api/1729/101837
+func (f HunyuanProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
+	provider := &HunyuanProvider{
+		BaseProvider: base.BaseProvider{
+			Config:    getConfig(),
+			Channel:   channel,
+			Requester: requester.NewHTTPRequester(*channel.Proxy, requestErrorHandle),
+		},
+	}
+	getKeyConfig(provider)
+
+	return provider
+}
+
+type HunyuanProvider struct {
+	base.BaseProvider
+	SecretID  string
+	SecretKey string
+	Region    string
+}
+
+// 腾讯云 API 的地址固定，这里配置的是各功能对应的 Action
+func getConfig() base.ProviderConfig {
+	return base.ProviderConfig{
+		BaseURL:           "https://hunyuan.tencentcloudapi.com",
+		ChatCompletions:   "ChatCompletions",
+		Embeddings:        "GetEmbedding",
+		ImagesGenerations: "SubmitHunyuanImageJob",
+	}
+}
+
+// 密钥格式为 SecretId|SecretKey|Region，地域可以省略
+func getKeyConfig(hunyuan *HunyuanProvider) {
+	keys := strings.Split(hunyuan.Channel.Key, "|")
+	if len(keys) < 2 {
+		return
+	}
+	hunyuan.SecretID = keys[0]
+	hunyuan.SecretKey = keys[1]
+	if len(keys) > 2 {
+		hunyuan.Region = keys[2]
+	}
+}
+
+// 请求错误处理
+func requestErrorHandle(resp *http.Response) *types.OpenAIError {
+	hunyuanResponse := &struct {
+		Response ResponseMeta `json:"Response"`
+	}{}
+	err := json.NewDecoder(resp.Body).Decode(hunyuanResponse)
+	if err != nil {
+		return nil
+	}
+
+	return errorHandle(&hunyuanResponse.Response)
+}
+
+// 错误处理
+func errorHandle(meta *ResponseMeta) *types.OpenAIError {
+	if meta.Error == nil || meta.Error.Code == "" {
+		return nil
+	}
+	return &types.OpenAIError{
+		Message: meta.Error.Message,
+		Type:    "hunyuan_error",
+		Code:    meta.Error.Code,
+	}
+}
+
+func errorWithStatusCode(openaiErr *types.OpenAIError) *types.OpenAIErrorWithStatusCode {
+	statusCode := http.StatusBadRequest
+	if code, ok := openaiErr.Code.(string); ok {
+		switch {
+		case strings.HasPrefix(code, "AuthFailure"):
+			statusCode = http.StatusUnauthorized
+		case strings.HasPrefix(code, "RequestLimitExceeded"), strings.HasPrefix(code, "LimitExceeded"):
+			statusCode = http.StatusTooManyRequests
+		case strings.HasPrefix(code, "InternalError"):
+			statusCode = http.StatusInternalServerError
+		}
+	}
+	return &types.OpenAIErrorWithStatusCode{
+		OpenAIError: *openaiErr,
+		StatusCode:  statusCode,
+	}
+}
+
+func (p *HunyuanProvider) GetRequestHeaders() (headers map[string]string) {
+	headers = make(map[string]string)
+	p.CommonRequestHeaders(headers)
+	headers["Content-Type"] = "application/json"
+	headers["X-TC-Version"] = hunyuanVersion
+	if p.Region != "" {
+		headers["X-TC-Region"] = p.Region
+	}
+
+	return headers
+}
+
+// newRequest 创建调用 action 的签名请求
+func (p *HunyuanProvider) newRequest(action string, body any) (*http.Request, *types.OpenAIErrorWithStatusCode) {
+	if p.SecretID == "" || p.SecretKey == "" {
+		return nil, common.StringErrorWrapperLocal("invalid hunyuan key, format: SecretId|SecretKey|Region", "invalid_hunyuan_config", http.StatusInternalServerError)
+	}
+
+	fullRequestURL := p.GetFullRequestURL("/", "")
+	headers := p.GetRequestHeaders()
+	headers[tc3.ActionKey] = action
+
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(body), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	if err = p.Sign(req); err != nil {
+		req.Body.Close()
+		return nil, common.ErrorWrapper(err, "sign_request_failed", http.StatusInternalServerError)
+	}
+
+	return req, nil
+}
+
+// newActionRequest 创建 relayMode 对应功能的签名请求
+func (p *HunyuanProvider) newActionRequest(relayMode int, body any) (*http.Request, *types.OpenAIErrorWithStatusCode) {
+	action, errWithCode := p.GetSupportedAPIUri(relayMode)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	return p.newRequest(action, body)
+}
+
+func (p *HunyuanProvider) Sign(req *http.Request) error {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return errors.New("error getting request body: " + err.Error())
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	tc3.New(p.SecretID, p.SecretKey, hunyuanService).Sign(req, body, time.Now())
+	return nil
+}
@@ -0,0 +1,339 @@
+package hunyuan
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/common/requester"
+	"czloapi/types"
+)
+
+type hunyuanStreamHandler struct {
+	Usage   *types.Usage
+	Request *types.ChatCompletionRequest
+}
+
+func (p *HunyuanProvider) CreateChatCompletion(request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	req, errWithCode := p.newActionRequest(config.RelayModeChatCompletions, convertFromChatOpenai(request))
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	hunyuanResponse := &ChatResponse{}
+	// 发送请求
+	_, errWithCode = p.Requester.SendRequest(req, hunyuanResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	return p.convertToChatOpenai(&hunyuanResponse.Response, request)
+}
+
+func (p *HunyuanProvider) CreateChatCompletionStream(request *types.ChatCompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
+	req, errWithCode := p.newActionRequest(config.RelayModeChatCompletions, convertFromChatOpenai(request))
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	// 发送请求
+	resp, errWithCode := p.Requester.SendRequestRaw(req)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	// 参数错误等情况下返回的是 JSON 而不是事件流
+	if strings.Contains(resp.Header.Get("Content-Type"), "application/json") {
+		defer resp.Body.Close()
+		hunyuanResponse := &ChatResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(hunyuanResponse); err != nil {
+			return nil, common.ErrorWrapper(err, "decode_response_failed", http.StatusInternalServerError)
+		}
+		if openaiErr := errorHandle(&hunyuanResponse.Response.ResponseMeta); openaiErr != nil {
+			return nil, errorWithStatusCode(openaiErr)
+		}
+		return nil, common.StringErrorWrapper("unexpected hunyuan stream response", "hunyuan_error", http.StatusInternalServerError)
+	}
+
+	chatHandler := &hunyuanStreamHandler{
+		Usage:   p.Usage,
+		Request: request,
+	}
+
+	return requester.RequestStream(p.Requester, resp, chatHandler.handlerStream)
+}
+
+// convertFromChatOpenai 转换为混元的请求体
+func convertFromChatOpenai(request *types.ChatCompletionRequest) *ChatRequest {
+	hunyuanRequest := &ChatRequest{
+		Model:       request.Model,
+		Messages:    make([]*Message, 0, len(request.Messages)),
+		Stream:      request.Stream,
+		TopP:        request.TopP,
+		Temperature: request.Temperature,
+		Seed:        request.Seed,
+	}
+
+	for _, message := range request.Messages {
+		hunyuanRequest.Messages = append(hunyuanRequest.Messages, convertMessage(message))
+	}
+
+	// 兼容旧版 functions 参数
+	tools := request.Tools
+	if len(tools) == 0 && len(request.Functions) > 0 {
+		for _, function := range request.Functions {
+			tools = append(tools, &types.ChatCompletionTool{
+				Type:     "function",
+				Function: *function,
+			})
+		}
+	}
+	for _, tool := range tools {
+		parameters, _ := json.Marshal(tool.Function.Parameters)
+		hunyuanRequest.Tools = append(hunyuanRequest.Tools, &Tool{
+			Type: "function",
+			Function: &ToolFunction{
+				Name:        tool.Function.Name,
+				Parameters:  string(parameters),
+				Description: tool.Function.Description,
+			},
+		})
+	}
+
+	if len(hunyuanRequest.Tools) > 0 && request.ToolChoice != nil {
+		// 混元没有 required，指定函数时使用 custom
+		toolType, toolFunc := request.ParseToolChoice()
+		switch toolType {
+		case types.ToolChoiceTypeNone:
+			hunyuanRequest.ToolChoice = "none"
+		case types.ToolChoiceTypeFunction:
+			for _, tool := range hunyuanRequest.Tools {
+				if tool.Function.Name == toolFunc {
+					hunyuanRequest.ToolChoice = "custom"
+					hunyuanRequest.CustomTool = tool
+					break
+				}
+			}
+		default:
+			hunyuanRequest.ToolChoice = "auto"
+		}
+	}
+
+	return hunyuanRequest
+}
+
+// convertMessage 只有文本时使用 Content，包含图片时使用 Contents
+func convertMessage(message types.ChatCompletionMessage) *Message {
+	message.FuncToToolCalls()
+	hunyuanMessage := &Message{
+		Role:       message.Role,
+		ToolCallId: message.ToolCallID,
+	}
+
+	if content, ok := message.Content.(string); ok {
+		hunyuanMessage.Content = content
+	} else if message.Content != nil {
+		var texts []string
+		hasImage := false
+		for _, part := range message.ParseContent() {
+			switch part.Type {
+			case types.ContentTypeText:
+				texts = append(texts, part.Text)
+				hunyuanMessage.Contents = append(hunyuanMessage.Contents, &Content{Type: "text", Text: part.Text})
+			case types.ContentTypeImageURL:
+				if part.ImageURL == nil {
+					continue
+				}
+				hasImage = true
+				hunyuanMessage.Contents = append(hunyuanMessage.Contents, &Content{Type: "image_url", ImageUrl: &ImageUrl{Url: part.ImageURL.URL}})
+			}
+		}
+		if !hasImage {
+			hunyuanMessage.Contents = nil
+			hunyuanMessage.Content = strings.Join(texts, "\n")
+		}
+	}
+
+	for _, toolCall := range message.ToolCalls {
+		if toolCall.Function == nil {
+			continue
+		}
+		hunyuanMessage.ToolCalls = append(hunyuanMessage.ToolCalls, &ToolCall{
+			Id:   toolCall.Id,
+			Type: "function",
+			Function: &ToolCallFunction{
+				Name:      toolCall.Function.Name,
+				Arguments: toolCall.Function.Arguments,
+			},
+		})
+	}
+
+	return hunyuanMessage
+}
+
+func (p *HunyuanProvider) convertToChatOpenai(response *ChatResult, request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	if openaiErr := errorHandle(&response.ResponseMeta); openaiErr != nil {
+		return nil, errorWithStatusCode(openaiErr)
+	}
+
+	openaiResponse := &types.ChatCompletionResponse{
+		ID:      response.Id,
+		Object:  "chat.completion",
+		Created: response.Created,
+		Model:   request.Model,
+		Choices: make([]types.ChatCompletionChoice, 0, len(response.Choices)),
+	}
+
+	for index, choice := range response.Choices {
+		if choice.Message == nil {
+			continue
+		}
+		openaiChoice := types.ChatCompletionChoice{
+			Index: index,
+			Message: types.ChatCompletionMessage{
+				Role:             types.ChatMessageRoleAssistant,
+				Content:          choice.Message.Content,
+				ReasoningContent: choice.Message.ReasoningContent,
+				ToolCalls:        convertToolCalls(choice.Message.ToolCalls),
+			},
+			FinishReason: convertFinishReason(choice.FinishReason),
+		}
+		openaiChoice.CheckChoice(request)
+		openaiResponse.Choices = append(openaiResponse.Choices, openaiChoice)
+	}
+
+	if response.Usage == nil || response.Usage.CompletionTokens == 0 {
+		openaiResponse.Usage = &types.Usage{
+			PromptTokens:     p.Usage.PromptTokens,
+			CompletionTokens: common.CountTokenText(openaiResponse.GetContent(), request.Model),
+		}
+		openaiResponse.Usage.TotalTokens = openaiResponse.Usage.PromptTokens + openaiResponse.Usage.CompletionTokens
+	} else {
+		openaiResponse.Usage = response.Usage.ToOpenAIUsage()
+	}
+
+	*p.Usage = *openaiResponse.Usage
+
+	return openaiResponse, nil
+}
+
+func (u *Usage) ToOpenAIUsage() *types.Usage {
+	return &types.Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
+
+func convertToolCalls(toolCalls []*ToolCall) []*types.ChatCompletionToolCalls {
+	if len(toolCalls) == 0 {
+		return nil
+	}
+
+	openaiToolCalls := make([]*types.ChatCompletionToolCalls, 0, len(toolCalls))
+	for index, toolCall := range toolCalls {
+		if toolCall.Function == nil {
+			continue
+		}
+		openaiToolCall := &types.ChatCompletionToolCalls{
+			Id:   toolCall.Id,
+			Type: types.ChatMessageRoleFunction,
+			Function: &types.ChatCompletionToolCallsFunction{
+				Name:      toolCall.Function.Name,
+				Arguments: toolCall.Function.Arguments,
+			},
+			Index: index,
+		}
+		if toolCall.Index != nil {
+			openaiToolCall.Index = *toolCall.Index
+		}
+		openaiToolCalls = append(openaiToolCalls, openaiToolCall)
+	}
+	return openaiToolCalls
+}
+
+func convertFinishReason(finishReason string) string {
+	switch finishReason {
+	case "length":
+		return types.FinishReasonLength
+	case "tool_calls":
+		return types.FinishReasonToolCalls
+	case "sensitive":
+		return types.FinishReasonContentFilter
+	default:
+		return types.FinishReasonStop
+	}
+}
+
+// 转换为OpenAI聊天流式请求体，混元的事件流没有 [DONE]，读取到结尾时结束
+func (h *hunyuanStreamHandler) handlerStream(rawLine *[]byte, dataChan chan string, errChan chan error) {
+	// 如果rawLine 前缀不为data:，则直接返回
+	if !strings.HasPrefix(string(*rawLine), "data:") {
+		*rawLine = nil
+		return
+	}
+
+	*rawLine = bytes.TrimSpace((*rawLine)[5:])
+
+	var hunyuanResponse ChatResult
+	if err := json.Unmarshal(*rawLine, &hunyuanResponse); err != nil {
+		errChan <- common.ErrorToOpenAIError(err)
+		return
+	}
+
+	if hunyuanResponse.ErrorMsg != nil && hunyuanResponse.ErrorMsg.Msg != "" {
+		errChan <- &types.OpenAIError{
+			Message: hunyuanResponse.ErrorMsg.Msg,
+			Type:    "hunyuan_error",
+			Code:    hunyuanResponse.ErrorMsg.Code,
+		}
+		return
+	}
+
+	chatCompletion := types.ChatCompletionStreamResponse{
+		ID:      hunyuanResponse.Id,
+		Object:  "chat.completion.chunk",
+		Created: hunyuanResponse.Created,
+		Model:   h.Request.Model,
+		Choices: make([]types.ChatCompletionStreamChoice, 0, len(hunyuanResponse.Choices)),
+	}
+
+	for index, choice := range hunyuanResponse.Choices {
+		if choice.Delta == nil {
+			continue
+		}
+		streamChoice := types.ChatCompletionStreamChoice{
+			Index: index,
+			Delta: types.ChatCompletionStreamChoiceDelta{
+				Role:             choice.Delta.Role,
+				Content:          choice.Delta.Content,
+				ReasoningContent: choice.Delta.ReasoningContent,
+				ToolCalls:        convertToolCalls(choice.Delta.ToolCalls),
+			},
+		}
+		if choice.FinishReason != "" {
+			streamChoice.FinishReason = convertFinishReason(choice.FinishReason)
+		}
+		streamChoice.CheckChoice(h.Request)
+		chatCompletion.Choices = append(chatCompletion.Choices, streamChoice)
+
+		h.Usage.TextBuilder.WriteString(choice.Delta.Content)
+	}
+
+	// 每个数据块都带有累计用量
+	if hunyuanResponse.Usage != nil && hunyuanResponse.Usage.CompletionTokens > 0 {
+		h.Usage.PromptTokens = hunyuanResponse.Usage.PromptTokens
+		h.Usage.CompletionTokens = hunyuanResponse.Usage.CompletionTokens
+		h.Usage.TotalTokens = hunyuanResponse.Usage.TotalTokens
+	} else if h.Usage.TotalTokens == 0 {
+		h.Usage.TotalTokens = h.Usage.PromptTokens
+	}
+
+	responseBody, _ := json.Marshal(chatCompletion)
+	dataChan <- string(responseBody)
+}
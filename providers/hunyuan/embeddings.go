@@ -0,0 +1,55 @@
+package hunyuan
+
+import (
+	"czloapi/common/config"
+	"czloapi/types"
+)
+
+// CreateEmbeddings 混元向量化只有一个模型，不需要传递模型名称
+func (p *HunyuanProvider) CreateEmbeddings(request *types.EmbeddingRequest) (*types.EmbeddingResponse, *types.OpenAIErrorWithStatusCode) {
+	embeddingRequest := &EmbeddingRequest{}
+	if input, ok := request.Input.(string); ok {
+		embeddingRequest.Input = input
+	} else {
+		embeddingRequest.InputList = request.ParseInput()
+	}
+
+	req, errWithCode := p.newActionRequest(config.RelayModeEmbeddings, embeddingRequest)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	hunyuanResponse := &EmbeddingResponse{}
+	// 发送请求
+	_, errWithCode = p.Requester.SendRequest(req, hunyuanResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	if openaiErr := errorHandle(&hunyuanResponse.Response.ResponseMeta); openaiErr != nil {
+		return nil, errorWithStatusCode(openaiErr)
+	}
+
+	openaiResponse := &types.EmbeddingResponse{
+		Object: "list",
+		Model:  request.Model,
+		Data:   make([]types.Embedding, 0, len(hunyuanResponse.Response.Data)),
+	}
+	for _, item := range hunyuanResponse.Response.Data {
+		openaiResponse.Data = append(openaiResponse.Data, types.Embedding{
+			Object:    "embedding",
+			Embedding: item.Embedding,
+			Index:     item.Index,
+		})
+	}
+
+	if hunyuanResponse.Response.Usage != nil {
+		*p.Usage = *hunyuanResponse.Response.Usage.ToOpenAIUsage()
+	} else {
+		p.Usage.TotalTokens = p.Usage.PromptTokens
+	}
+	openaiResponse.Usage = p.Usage
+
+	return openaiResponse, nil
+}
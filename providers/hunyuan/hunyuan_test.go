@@ -0,0 +1,77 @@
+package hunyuan
+
+import (
+	"encoding/json"
+	"testing"
+
+	"czloapi/model"
+	"czloapi/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetKeyConfig(t *testing.T) {
+	provider := &HunyuanProvider{}
+	provider.Channel = &model.Channel{Key: "AKIDxxx|secret|ap-guangzhou"}
+	getKeyConfig(provider)
+	assert.Equal(t, "AKIDxxx", provider.SecretID)
+	assert.Equal(t, "secret", provider.SecretKey)
+	assert.Equal(t, "ap-guangzhou", provider.Region)
+
+	provider = &HunyuanProvider{}
+	provider.Channel = &model.Channel{Key: "AKIDxxx|secret"}
+	getKeyConfig(provider)
+	assert.Equal(t, "secret", provider.SecretKey)
+	assert.Empty(t, provider.Region)
+}
+
+func TestConvertFromChatOpenai(t *testing.T) {
+	request := &types.ChatCompletionRequest{
+		Model: "hunyuan-vision",
+		Messages: []types.ChatCompletionMessage{
+			{Role: "system", Content: "be brief"},
+			{Role: "user", Content: []any{
+				map[string]any{"type": "text", "text": "what is this"},
+				map[string]any{"type": "image_url", "image_url": map[string]any{"url": "https://example.com/a.png"}},
+			}},
+			{Role: "user", Content: []any{map[string]any{"type": "text", "text": "only text"}}},
+		},
+		Tools: []*types.ChatCompletionTool{
+			{Type: "function", Function: types.ChatCompletionFunction{Name: "get_weather", Parameters: map[string]any{"type": "object"}}},
+		},
+		ToolChoice: map[string]any{"type": "function", "function": map[string]any{"name": "get_weather"}},
+	}
+
+	hunyuanRequest := convertFromChatOpenai(request)
+	assert.Equal(t, "be brief", hunyuanRequest.Messages[0].Content)
+	assert.Len(t, hunyuanRequest.Messages[1].Contents, 2)
+	assert.Equal(t, "https://example.com/a.png", hunyuanRequest.Messages[1].Contents[1].ImageUrl.Url)
+	assert.Nil(t, hunyuanRequest.Messages[2].Contents)
+	assert.Equal(t, "only text", hunyuanRequest.Messages[2].Content)
+	assert.Equal(t, `{"type":"object"}`, hunyuanRequest.Tools[0].Function.Parameters)
+	assert.Equal(t, "custom", hunyuanRequest.ToolChoice)
+	assert.Equal(t, "get_weather", hunyuanRequest.CustomTool.Function.Name)
+}
+
+func TestHandlerStream(t *testing.T) {
+	usage := &types.Usage{PromptTokens: 5}
+	handler := &hunyuanStreamHandler{
+		Usage:   usage,
+		Request: &types.ChatCompletionRequest{Model: "hunyuan-turbos-latest"},
+	}
+
+	dataChan := make(chan string, 1)
+	errChan := make(chan error, 1)
+	line := []byte(`data: {"Id":"abc","Created":1,"Choices":[{"FinishReason":"stop","Delta":{"Role":"assistant","Content":"hi"}}],"Usage":{"PromptTokens":5,"CompletionTokens":1,"TotalTokens":6}}`)
+	handler.handlerStream(&line, dataChan, errChan)
+
+	var chunk types.ChatCompletionStreamResponse
+	assert.NoError(t, json.Unmarshal([]byte(<-dataChan), &chunk))
+	assert.Equal(t, "hi", chunk.Choices[0].Delta.Content)
+	assert.Equal(t, types.FinishReasonStop, chunk.Choices[0].FinishReason)
+	assert.Equal(t, 6, usage.TotalTokens)
+
+	line = []byte(`data: {"ErrorMsg":{"Msg":"content blocked","Code":2001}}`)
+	handler.handlerStream(&line, dataChan, errChan)
+	assert.Error(t, <-errChan)
+}
@@ -0,0 +1,158 @@
+package hunyuan
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/common/image"
+	"czloapi/types"
+)
+
+const (
+	queryImageJobAction = "QueryHunyuanImageJob"
+
+	// JobStatusCode：1 等待中、2 运行中、4 处理失败、5 处理完成
+	imageJobFailed  = "4"
+	imageJobSuccess = "5"
+
+	imageJobPollInterval = 2 * time.Second
+	imageJobMaxPolls     = 60
+)
+
+// CreateImageGenerations 提交混元生图任务并轮询结果，返回的图片链接有效期为 1 小时
+// https://cloud.tencent.com/document/api/1729/105970
+func (p *HunyuanProvider) CreateImageGenerations(request *types.ImageRequest) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	req, errWithCode := p.newActionRequest(config.RelayModeImagesGenerations, p.newImageJobRequest(request))
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	submitResponse := &ImageJobSubmitResponse{}
+	_, errWithCode = p.Requester.SendRequest(req, submitResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	if openaiErr := errorHandle(&submitResponse.Response.ResponseMeta); openaiErr != nil {
+		return nil, errorWithStatusCode(openaiErr)
+	}
+
+	result, errWithCode := p.waitImageJob(submitResponse.Response.JobId)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	imageResponse := &types.ImageResponse{
+		Created: time.Now().Unix(),
+		Data:    make([]types.ImageResponseDataInner, 0, len(result.Response.ResultImage)),
+	}
+	for index, url := range result.Response.ResultImage {
+		item := types.ImageResponseDataInner{URL: url}
+		if index < len(result.Response.RevisedPrompt) {
+			item.RevisedPrompt = result.Response.RevisedPrompt[index]
+		}
+		if request.ResponseFormat == "b64_json" {
+			_, data, err := image.GetImageFromUrl(url)
+			if err != nil {
+				return nil, common.ErrorWrapper(err, "get_image_failed", http.StatusInternalServerError)
+			}
+			item.URL = ""
+			item.B64JSON = data
+		}
+		imageResponse.Data = append(imageResponse.Data, item)
+	}
+
+	p.Usage.PromptTokens = len(imageResponse.Data) * 1000
+	p.Usage.TotalTokens = p.Usage.PromptTokens
+
+	return imageResponse, nil
+}
+
+// newImageJobRequest 尺寸由 1024x1024 转换为 1024:1024，negative_prompt、seed、revise 从原始请求中读取
+func (p *HunyuanProvider) newImageJobRequest(request *types.ImageRequest) *ImageJobRequest {
+	jobRequest := &ImageJobRequest{
+		Prompt:     request.Prompt,
+		Style:      request.Style,
+		Resolution: strings.Replace(request.Size, "x", ":", 1),
+		Num:        request.N,
+	}
+
+	if p.Context == nil {
+		return jobRequest
+	}
+	rawBody, ok := p.GetRawBody()
+	if !ok {
+		return jobRequest
+	}
+
+	var rawRequest struct {
+		NegativePrompt string `json:"negative_prompt"`
+		Seed           *int   `json:"seed"`
+		Revise         *bool  `json:"revise"`
+	}
+	if err := json.Unmarshal(rawBody, &rawRequest); err != nil {
+		return jobRequest
+	}
+
+	jobRequest.NegativePrompt = rawRequest.NegativePrompt
+	jobRequest.Seed = rawRequest.Seed
+	if rawRequest.Revise != nil {
+		revise := 0
+		if *rawRequest.Revise {
+			revise = 1
+		}
+		jobRequest.Revise = &revise
+	}
+
+	return jobRequest
+}
+
+func (p *HunyuanProvider) waitImageJob(jobId string) (*ImageJobQueryResponse, *types.OpenAIErrorWithStatusCode) {
+	for i := 0; i < imageJobMaxPolls; i++ {
+		if p.Context != nil && p.Context.Request != nil {
+			select {
+			case <-p.Context.Request.Context().Done():
+				return nil, common.ErrorWrapper(p.Context.Request.Context().Err(), "request_canceled", http.StatusRequestTimeout)
+			case <-time.After(imageJobPollInterval):
+			}
+		} else {
+			time.Sleep(imageJobPollInterval)
+		}
+
+		req, errWithCode := p.newRequest(queryImageJobAction, &ImageJobQueryRequest{JobId: jobId})
+		if errWithCode != nil {
+			return nil, errWithCode
+		}
+
+		queryResponse := &ImageJobQueryResponse{}
+		_, errWithCode = p.Requester.SendRequest(req, queryResponse, false)
+		req.Body.Close()
+		if errWithCode != nil {
+			return nil, errWithCode
+		}
+		if openaiErr := errorHandle(&queryResponse.Response.ResponseMeta); openaiErr != nil {
+			return nil, errorWithStatusCode(openaiErr)
+		}
+
+		switch queryResponse.Response.JobStatusCode {
+		case imageJobSuccess:
+			return queryResponse, nil
+		case imageJobFailed:
+			return nil, &types.OpenAIErrorWithStatusCode{
+				OpenAIError: types.OpenAIError{
+					Message: queryResponse.Response.JobErrorMsg,
+					Type:    "hunyuan_error",
+					Code:    queryResponse.Response.JobErrorCode,
+				},
+				StatusCode: http.StatusBadRequest,
+			}
+		}
+	}
+
+	return nil, common.ErrorWrapper(errors.New("get image timeout"), "get_images_url_failed", http.StatusInternalServerError)
+}
@@ -0,0 +1,109 @@
+package tc3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 腾讯云 API 3.0 签名方法 v3
+// https://cloud.tencent.com/document/api/1729/101843
+const (
+	SigningAlgorithm = "TC3-HMAC-SHA256"
+	TimestampKey     = "X-TC-Timestamp"
+	ActionKey        = "X-TC-Action"
+
+	// 参与签名的请求头，content-type 和 host 必须签名，action 防止请求被改为其他接口
+	signedHeaders = "content-type;host;x-tc-action"
+)
+
+type Signer struct {
+	SecretID  string
+	SecretKey string
+	Service   string
+}
+
+func New(secretID, secretKey, service string) *Signer {
+	return &Signer{
+		SecretID:  secretID,
+		SecretKey: secretKey,
+		Service:   service,
+	}
+}
+
+// Sign 为请求设置 X-TC-Timestamp 和 Authorization 请求头，body 为请求体原文
+func (s *Signer) Sign(req *http.Request, body []byte, signTime time.Time) {
+	signTime = signTime.UTC()
+	req.Header.Set(TimestampKey, strconv.FormatInt(signTime.Unix(), 10))
+	req.Header.Set("Authorization", s.Authorization(CanonicalRequest(req, body), signTime))
+}
+
+// Authorization 根据规范请求串计算 Authorization 请求头的值
+func (s *Signer) Authorization(canonicalRequest string, signTime time.Time) string {
+	signTime = signTime.UTC()
+	date := signTime.Format("2006-01-02")
+	credentialScope := fmt.Sprintf("%s/%s/tc3_request", date, s.Service)
+
+	stringToSign := StringToSign(canonicalRequest, credentialScope, signTime)
+
+	secretDate := hmacSHA256([]byte("TC3"+s.SecretKey), date)
+	secretService := hmacSHA256(secretDate, s.Service)
+	secretSigning := hmacSHA256(secretService, "tc3_request")
+	signature := hex.EncodeToString(hmacSHA256(secretSigning, stringToSign))
+
+	return fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		SigningAlgorithm, s.SecretID, credentialScope, signedHeaders, signature)
+}
+
+// CanonicalRequest 拼接规范请求串，请求头的值需要转为小写
+func CanonicalRequest(req *http.Request, body []byte) string {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalHeaders := fmt.Sprintf("content-type:%s\nhost:%s\nx-tc-action:%s\n",
+		strings.ToLower(req.Header.Get("Content-Type")),
+		strings.ToLower(host),
+		strings.ToLower(req.Header.Get(ActionKey)),
+	)
+
+	return strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+}
+
+// StringToSign 拼接待签名字符串
+func StringToSign(canonicalRequest, credentialScope string, signTime time.Time) string {
+	return strings.Join([]string{
+		SigningAlgorithm,
+		strconv.FormatInt(signTime.Unix(), 10),
+		credentialScope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+}
+
+func sha256Hex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
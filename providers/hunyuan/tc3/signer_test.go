@@ -0,0 +1,54 @@
+package tc3
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRequest(body []byte) *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, "https://hunyuan.tencentcloudapi.com/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(ActionKey, "ChatCompletions")
+	return req
+}
+
+func TestCanonicalRequest(t *testing.T) {
+	body := []byte(`{"Model":"hunyuan-lite"}`)
+	canonical := CanonicalRequest(newTestRequest(body), body)
+
+	assert.Equal(t, "POST\n/\n\n"+
+		"content-type:application/json\nhost:hunyuan.tencentcloudapi.com\nx-tc-action:chatcompletions\n\n"+
+		"content-type;host;x-tc-action\n"+
+		sha256Hex(body), canonical)
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"Model":"hunyuan-lite"}`)
+	req := newTestRequest(body)
+	// 2023-12-31 23:30:00 UTC，北京时间已是 2024-01-01，日期取 UTC 时间
+	signTime := time.Unix(1704065400, 0).In(time.FixedZone("CST", 8*3600))
+
+	New("AKIDEXAMPLE", "secret", "hunyuan").Sign(req, body, signTime)
+	assert.Equal(t, "1704065400", req.Header.Get(TimestampKey))
+
+	// 按文档步骤独立计算签名
+	stringToSign := "TC3-HMAC-SHA256\n1704065400\n2023-12-31/hunyuan/tc3_request\n" + sha256Hex([]byte(CanonicalRequest(req, body)))
+	key := []byte("TC3secret")
+	for _, data := range []string{"2023-12-31", "hunyuan", "tc3_request"} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(data))
+		key = mac.Sum(nil)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(t, "TC3-HMAC-SHA256 Credential=AKIDEXAMPLE/2023-12-31/hunyuan/tc3_request, SignedHeaders=content-type;host;x-tc-action, Signature="+signature, req.Header.Get("Authorization"))
+}
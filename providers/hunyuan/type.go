@@ -0,0 +1,154 @@
+package hunyuan
+
+// 腾讯云 API 的参数均为大驼峰命名，错误时 HTTP 状态码仍为 200，错误信息在 Response.Error 中
+
+type HunyuanError struct {
+	Code    string `json:"Code"`
+	Message string `json:"Message"`
+}
+
+type ResponseMeta struct {
+	RequestId string        `json:"RequestId,omitempty"`
+	Error     *HunyuanError `json:"Error,omitempty"`
+}
+
+type ChatRequest struct {
+	Model             string     `json:"Model"`
+	Messages          []*Message `json:"Messages"`
+	Stream            bool       `json:"Stream,omitempty"`
+	TopP              *float64   `json:"TopP,omitempty"`
+	Temperature       *float64   `json:"Temperature,omitempty"`
+	EnableEnhancement *bool      `json:"EnableEnhancement,omitempty"`
+	Tools             []*Tool    `json:"Tools,omitempty"`
+	ToolChoice        string     `json:"ToolChoice,omitempty"`
+	CustomTool        *Tool      `json:"CustomTool,omitempty"`
+	Seed              *int       `json:"Seed,omitempty"`
+}
+
+type Message struct {
+	Role             string      `json:"Role"`
+	Content          string      `json:"Content,omitempty"`
+	Contents         []*Content  `json:"Contents,omitempty"`
+	ReasoningContent string      `json:"ReasoningContent,omitempty"`
+	ToolCallId       string      `json:"ToolCallId,omitempty"`
+	ToolCalls        []*ToolCall `json:"ToolCalls,omitempty"`
+}
+
+type Content struct {
+	Type     string    `json:"Type"`
+	Text     string    `json:"Text,omitempty"`
+	ImageUrl *ImageUrl `json:"ImageUrl,omitempty"`
+}
+
+type ImageUrl struct {
+	Url string `json:"Url"`
+}
+
+// Tool Parameters 为 JSON 字符串
+type Tool struct {
+	Type     string        `json:"Type"`
+	Function *ToolFunction `json:"Function"`
+}
+
+type ToolFunction struct {
+	Name        string `json:"Name"`
+	Parameters  string `json:"Parameters"`
+	Description string `json:"Description,omitempty"`
+}
+
+type ToolCall struct {
+	Id       string            `json:"Id"`
+	Type     string            `json:"Type"`
+	Function *ToolCallFunction `json:"Function"`
+	Index    *int              `json:"Index,omitempty"`
+}
+
+type ToolCallFunction struct {
+	Name      string `json:"Name"`
+	Arguments string `json:"Arguments"`
+}
+
+type Usage struct {
+	PromptTokens     int `json:"PromptTokens"`
+	CompletionTokens int `json:"CompletionTokens"`
+	TotalTokens      int `json:"TotalTokens"`
+}
+
+type Choice struct {
+	Index        int      `json:"Index"`
+	FinishReason string   `json:"FinishReason"`
+	Message      *Message `json:"Message,omitempty"`
+	Delta        *Message `json:"Delta,omitempty"`
+}
+
+// ChatResult 非流式响应在 Response 中，流式响应的每个数据块直接是该结构
+type ChatResult struct {
+	Id       string    `json:"Id"`
+	Created  int64     `json:"Created"`
+	Choices  []*Choice `json:"Choices"`
+	Usage    *Usage    `json:"Usage,omitempty"`
+	ErrorMsg *ErrorMsg `json:"ErrorMsg,omitempty"`
+	ResponseMeta
+}
+
+// ErrorMsg 流式输出过程中出现的错误
+type ErrorMsg struct {
+	Msg  string `json:"Msg"`
+	Code int    `json:"Code"`
+}
+
+type ChatResponse struct {
+	Response ChatResult `json:"Response"`
+}
+
+type EmbeddingRequest struct {
+	Input     string   `json:"Input,omitempty"`
+	InputList []string `json:"InputList,omitempty"`
+}
+
+type EmbeddingData struct {
+	Embedding []float64 `json:"Embedding"`
+	Index     int       `json:"Index"`
+	Object    string    `json:"Object"`
+}
+
+type EmbeddingResponse struct {
+	Response struct {
+		Data  []EmbeddingData `json:"Data"`
+		Usage *Usage          `json:"Usage,omitempty"`
+		ResponseMeta
+	} `json:"Response"`
+}
+
+type ImageJobRequest struct {
+	Prompt         string `json:"Prompt"`
+	NegativePrompt string `json:"NegativePrompt,omitempty"`
+	Style          string `json:"Style,omitempty"`
+	Resolution     string `json:"Resolution,omitempty"`
+	Num            int    `json:"Num,omitempty"`
+	Seed           *int   `json:"Seed,omitempty"`
+	Revise         *int   `json:"Revise,omitempty"`
+	LogoAdd        int    `json:"LogoAdd"`
+}
+
+type ImageJobSubmitResponse struct {
+	Response struct {
+		JobId string `json:"JobId"`
+		ResponseMeta
+	} `json:"Response"`
+}
+
+type ImageJobQueryRequest struct {
+	JobId string `json:"JobId"`
+}
+
+type ImageJobQueryResponse struct {
+	Response struct {
+		JobStatusCode string   `json:"JobStatusCode"`
+		JobErrorCode  string   `json:"JobErrorCode"`
+		JobErrorMsg   string   `json:"JobErrorMsg"`
+		ResultImage   []string `json:"ResultImage"`
+		RevisedPrompt []string `json:"RevisedPrompt"`
+		ResponseMeta
+	} `json:"Response"`
+}
@@ -0,0 +1,80 @@
+package ideogram
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"czloapi/common/requester"
+	"czloapi/model"
+	"czloapi/providers/base"
+	"czloapi/types"
+)
+
+type IdeogramProviderFactory struct{}
+
+// 创建 IdeogramProvider
+// https://developer.ideogram.ai/api-reference
+func (f IdeogramProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
+	return &IdeogramProvider{
+		BaseProvider: base.BaseProvider{
+			Config:    getConfig(),
+			Channel:   channel,
+			Requester: requester.NewHTTPRequester(*channel.Proxy, requestErrorHandle),
+		},
+	}
+}
+
+type IdeogramProvider struct {
+	base.BaseProvider
+}
+
+func getConfig() base.ProviderConfig {
+	return base.ProviderConfig{
+		BaseURL:           "https://api.ideogram.ai",
+		ImagesGenerations: "/v1/ideogram-v3/generate",
+		ImagesEdit:        "/v1/ideogram-v3/edit",
+	}
+}
+
+// 请求错误处理
+func requestErrorHandle(resp *http.Response) *types.OpenAIError {
+	ideogramError := &IdeogramError{}
+	err := json.NewDecoder(resp.Body).Decode(ideogramError)
+	if err != nil {
+		return nil
+	}
+
+	return errorHandle(ideogramError)
+}
+
+// 错误处理，不同错误分别使用 detail、error、message 字段
+func errorHandle(ideogramError *IdeogramError) *types.OpenAIError {
+	message := ideogramError.Error
+	if message == "" {
+		message = ideogramError.Message
+	}
+	if message == "" && ideogramError.Detail != nil {
+		var ok bool
+		if message, ok = ideogramError.Detail.(string); !ok {
+			detail, _ := json.Marshal(ideogramError.Detail)
+			message = string(detail)
+		}
+	}
+	if message == "" {
+		return nil
+	}
+
+	return &types.OpenAIError{
+		Message: message,
+		Type:    "ideogram_error",
+	}
+}
+
+func (p *IdeogramProvider) GetRequestHeaders() (headers map[string]string) {
+	headers = make(map[string]string)
+	p.CommonRequestHeaders(headers)
+	headers["Api-Key"] = p.Channel.Key
+	headers["Accept"] = "application/json"
+
+	return headers
+}
@@ -0,0 +1,46 @@
+package ideogram
+
+import (
+	"testing"
+
+	"czloapi/model"
+	"czloapi/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetImageOptions(t *testing.T) {
+	provider := &IdeogramProvider{}
+	provider.Channel = &model.Channel{}
+
+	promptUpsampling := "false"
+	options := provider.getImageOptions(&types.ImageRequest{
+		Model:            "ideogram-v3-turbo",
+		Style:            "natural",
+		Quality:          "hd",
+		PromptUpsampling: &promptUpsampling,
+	})
+	assert.Equal(t, "TURBO", options.RenderingSpeed)
+	assert.Equal(t, "REALISTIC", options.StyleType)
+	assert.Equal(t, "OFF", options.MagicPrompt)
+
+	seed := int64(7)
+	options = &ImageOptions{RenderingSpeed: "quality", Seed: &seed, StyleType: "design"}
+	fields := options.toFields("a poster", 12)
+	assert.Equal(t, "QUALITY", fields["rendering_speed"])
+	assert.Equal(t, "DESIGN", fields["style_type"])
+	assert.Equal(t, "8", fields["num_images"])
+	assert.Equal(t, "7", fields["seed"])
+}
+
+func TestGetRenderingSpeed(t *testing.T) {
+	assert.Equal(t, "DEFAULT", getRenderingSpeed("ideogram-v3", ""))
+	assert.Equal(t, "QUALITY", getRenderingSpeed("ideogram-v3", "high"))
+	assert.Equal(t, "FLASH", getRenderingSpeed("ideogram-v3-flash", "high"))
+}
+
+func TestErrorHandle(t *testing.T) {
+	assert.Equal(t, "Invalid api key", errorHandle(&IdeogramError{Detail: "Invalid api key"}).Message)
+	assert.Equal(t, "prompt failed safety check", errorHandle(&IdeogramError{Error: "prompt failed safety check"}).Message)
+	assert.Nil(t, errorHandle(&IdeogramError{}))
+}
@@ -0,0 +1,74 @@
+package ideogram
+
+import (
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/types"
+)
+
+const remixEndpoint = "/v1/ideogram-v3/remix"
+
+// CreateImageEdits 带 mask 时使用局部编辑接口，否则使用 remix 以原图为参考重新生成
+func (p *IdeogramProvider) CreateImageEdits(request *types.ImageEditRequest) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	imageFile := request.Image
+	if imageFile == nil && len(request.Images) > 0 {
+		imageFile = request.Images[0]
+	}
+	if imageFile == nil {
+		return nil, common.StringErrorWrapperLocal("image is required", "invalid_request", http.StatusBadRequest)
+	}
+
+	options := p.getImageEditOptions(request.Model)
+	fields := options.toFields(request.Prompt, request.N)
+	files := map[string]*multipart.FileHeader{
+		"image": imageFile,
+	}
+
+	if request.Mask != nil {
+		uri, errWithCode := p.GetSupportedAPIUri(config.RelayModeImagesEdits)
+		if errWithCode != nil {
+			return nil, errWithCode
+		}
+		files["mask"] = request.Mask
+		// 局部编辑不支持负面提示词
+		delete(fields, "negative_prompt")
+
+		return p.createImages(uri, fields, files, request.ResponseFormat)
+	}
+
+	if p.Context != nil {
+		if imageWeight := p.Context.PostForm("image_weight"); imageWeight != "" {
+			fields["image_weight"] = imageWeight
+		}
+		if aspectRatio := p.Context.PostForm("aspect_ratio"); aspectRatio != "" {
+			fields["aspect_ratio"] = strings.Replace(aspectRatio, ":", "x", 1)
+		}
+	}
+
+	return p.createImages(remixEndpoint, fields, files, request.ResponseFormat)
+}
+
+// getImageEditOptions 编辑接口为表单请求，扩展参数从表单中读取
+func (p *IdeogramProvider) getImageEditOptions(modelName string) *ImageOptions {
+	options := &ImageOptions{}
+	if p.Context != nil {
+		options.NegativePrompt = p.Context.PostForm("negative_prompt")
+		options.MagicPrompt = p.Context.PostForm("magic_prompt")
+		options.StyleType = convertStyle(p.Context.PostForm("style_type"))
+		options.RenderingSpeed = strings.ToUpper(p.Context.PostForm("rendering_speed"))
+		if seed, err := strconv.ParseInt(p.Context.PostForm("seed"), 10, 64); err == nil {
+			options.Seed = &seed
+		}
+	}
+
+	if options.RenderingSpeed == "" {
+		options.RenderingSpeed = getRenderingSpeed(modelName, "")
+	}
+
+	return options
+}
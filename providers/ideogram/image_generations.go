@@ -0,0 +1,235 @@
+package ideogram
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/common/image"
+	"czloapi/common/storage"
+	"czloapi/common/utils"
+	"czloapi/types"
+)
+
+// 单次请求最多生成 8 张图片
+const maxNumImages = 8
+
+var aspectRatios = []string{
+	"1:3", "3:1", "1:2", "2:1", "9:16", "16:9", "10:16", "16:10",
+	"2:3", "3:2", "3:4", "4:3", "4:5", "5:4", "1:1",
+}
+
+var styleTypes = map[string]bool{
+	"AUTO": true, "GENERAL": true, "REALISTIC": true, "DESIGN": true, "FICTION": true,
+}
+
+func (p *IdeogramProvider) CreateImageGenerations(request *types.ImageRequest) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	uri, errWithCode := p.GetSupportedAPIUri(config.RelayModeImagesGenerations)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	options := p.getImageOptions(request)
+	fields := options.toFields(request.Prompt, request.N)
+
+	// aspect_ratio 使用 16x9 的形式
+	if request.AspectRatio != nil && *request.AspectRatio != "" {
+		fields["aspect_ratio"] = strings.Replace(*request.AspectRatio, ":", "x", 1)
+	} else if aspectRatio := image.GetClosestAspectRatio(request.Size, aspectRatios); aspectRatio != "" {
+		fields["aspect_ratio"] = strings.Replace(aspectRatio, ":", "x", 1)
+	}
+
+	return p.createImages(uri, fields, nil, request.ResponseFormat)
+}
+
+func (p *IdeogramProvider) getImageOptions(request *types.ImageRequest) *ImageOptions {
+	options := &ImageOptions{}
+	if p.Context != nil {
+		if rawBody, ok := p.GetRawBody(); ok {
+			_ = json.Unmarshal(rawBody, options)
+		}
+	}
+
+	if options.RenderingSpeed == "" {
+		options.RenderingSpeed = getRenderingSpeed(request.Model, request.Quality)
+	}
+	if options.StyleType == "" {
+		options.StyleType = convertStyle(request.Style)
+	}
+	if options.MagicPrompt == "" && request.PromptUpsampling != nil {
+		if promptUpsampling, err := strconv.ParseBool(*request.PromptUpsampling); err == nil {
+			options.MagicPrompt = "OFF"
+			if promptUpsampling {
+				options.MagicPrompt = "ON"
+			}
+		}
+	}
+
+	return options
+}
+
+func (o *ImageOptions) toFields(prompt string, n int) map[string]string {
+	fields := map[string]string{
+		"prompt":          prompt,
+		"rendering_speed": strings.ToUpper(o.RenderingSpeed),
+		"num_images":      strconv.Itoa(min(max(n, 1), maxNumImages)),
+	}
+	if o.NegativePrompt != "" {
+		fields["negative_prompt"] = o.NegativePrompt
+	}
+	if o.Seed != nil {
+		fields["seed"] = strconv.FormatInt(*o.Seed, 10)
+	}
+	if o.MagicPrompt != "" {
+		fields["magic_prompt"] = strings.ToUpper(o.MagicPrompt)
+	}
+	if o.StyleType != "" {
+		fields["style_type"] = strings.ToUpper(o.StyleType)
+	}
+
+	return fields
+}
+
+// getRenderingSpeed 模型名后缀优先，例如 ideogram-v3-turbo，其次使用 quality
+func getRenderingSpeed(modelName, quality string) string {
+	switch {
+	case strings.HasSuffix(modelName, "flash"):
+		return "FLASH"
+	case strings.HasSuffix(modelName, "turbo"):
+		return "TURBO"
+	case strings.HasSuffix(modelName, "quality"):
+		return "QUALITY"
+	}
+
+	switch quality {
+	case "low":
+		return "TURBO"
+	case "hd", "high":
+		return "QUALITY"
+	default:
+		return "DEFAULT"
+	}
+}
+
+// convertStyle OpenAI 的 natural 对应 REALISTIC，vivid 对应 GENERAL
+func convertStyle(style string) string {
+	switch style {
+	case "natural":
+		return "REALISTIC"
+	case "vivid":
+		return "GENERAL"
+	}
+
+	style = strings.ToUpper(style)
+	if styleTypes[style] {
+		return style
+	}
+
+	return ""
+}
+
+func (p *IdeogramProvider) createImages(uri string, fields map[string]string, files map[string]*multipart.FileHeader, responseFormat string) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	ideogramResponse, errWithCode := p.sendForm(uri, fields, files)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	imageResponse := &types.ImageResponse{
+		Created: time.Now().Unix(),
+		Data:    make([]types.ImageResponseDataInner, 0, len(ideogramResponse.Data)),
+	}
+	for _, data := range ideogramResponse.Data {
+		// 未通过安全检查的图片没有链接
+		if !data.IsImageSafe || data.URL == "" {
+			continue
+		}
+		item, errWithCode := convertImageData(data.URL, responseFormat)
+		if errWithCode != nil {
+			return nil, errWithCode
+		}
+		imageResponse.Data = append(imageResponse.Data, *item)
+	}
+
+	if len(imageResponse.Data) == 0 {
+		return nil, common.StringErrorWrapper("image was filtered by content moderation", "content_filter", http.StatusBadRequest)
+	}
+
+	p.Usage.PromptTokens = len(imageResponse.Data) * 1000
+	p.Usage.TotalTokens = p.Usage.PromptTokens
+
+	return imageResponse, nil
+}
+
+func (p *IdeogramProvider) sendForm(uri string, fields map[string]string, files map[string]*multipart.FileHeader) (*ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	var formBody bytes.Buffer
+	builder := p.Requester.CreateFormBuilder(&formBody)
+	for name, file := range files {
+		if err := builder.CreateFormFile(name, file); err != nil {
+			return nil, common.ErrorWrapper(err, "create_form_builder_failed", http.StatusInternalServerError)
+		}
+	}
+	for name, value := range fields {
+		if err := builder.WriteField(name, value); err != nil {
+			return nil, common.ErrorWrapper(err, "create_form_builder_failed", http.StatusInternalServerError)
+		}
+	}
+	if err := builder.Close(); err != nil {
+		return nil, common.ErrorWrapper(err, "create_form_builder_failed", http.StatusInternalServerError)
+	}
+
+	req, err := p.Requester.NewRequest(
+		http.MethodPost,
+		p.GetFullRequestURL(uri, ""),
+		p.Requester.WithBody(&formBody),
+		p.Requester.WithHeader(p.GetRequestHeaders()),
+		p.Requester.WithContentType(builder.FormDataContentType()))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	req.ContentLength = int64(formBody.Len())
+	defer req.Body.Close()
+
+	ideogramResponse := &ImageResponse{}
+	_, errWithCode := p.Requester.SendRequest(req, ideogramResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	if openaiErr := errorHandle(&ideogramResponse.IdeogramError); openaiErr != nil {
+		return nil, &types.OpenAIErrorWithStatusCode{
+			OpenAIError: *openaiErr,
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+
+	return ideogramResponse, nil
+}
+
+// convertImageData 图片链接会过期，url 格式时转存到存储，未配置存储时返回原链接
+func convertImageData(imageURL, responseFormat string) (*types.ImageResponseDataInner, *types.OpenAIErrorWithStatusCode) {
+	_, b64Image, err := image.GetImageFromUrl(imageURL)
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "get_image_failed", http.StatusInternalServerError)
+	}
+	if responseFormat == "b64_json" {
+		return &types.ImageResponseDataInner{B64JSON: b64Image}, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(b64Image)
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "decode_image_failed", http.StatusInternalServerError)
+	}
+
+	if url := storage.Upload(data, utils.GetUUID()+".png"); url != "" {
+		return &types.ImageResponseDataInner{URL: url}, nil
+	}
+
+	return &types.ImageResponseDataInner{URL: imageURL}, nil
+}
@@ -0,0 +1,31 @@
+package ideogram
+
+type IdeogramError struct {
+	Detail  any    `json:"detail,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type ImageResponse struct {
+	IdeogramError
+	Created string       `json:"created"`
+	Data    []*ImageData `json:"data"`
+}
+
+type ImageData struct {
+	Prompt      string `json:"prompt"`
+	Resolution  string `json:"resolution"`
+	IsImageSafe bool   `json:"is_image_safe"`
+	Seed        int64  `json:"seed"`
+	URL         string `json:"url"`
+	StyleType   string `json:"style_type"`
+}
+
+// ImageOptions 是 OpenAI 请求中没有的参数，从原始请求中读取
+type ImageOptions struct {
+	NegativePrompt string `json:"negative_prompt"`
+	Seed           *int64 `json:"seed"`
+	MagicPrompt    string `json:"magic_prompt"`
+	StyleType      string `json:"style_type"`
+	RenderingSpeed string `json:"rendering_speed"`
+}
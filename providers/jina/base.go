@@ -0,0 +1,79 @@
+package jina
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"czloapi/common/requester"
+	"czloapi/model"
+	"czloapi/providers/base"
+	"czloapi/types"
+)
+
+type JinaProviderFactory struct{}
+
+// 创建 JinaProvider
+// https://jina.ai/embeddings/
+func (f JinaProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
+	return &JinaProvider{
+		BaseProvider: base.BaseProvider{
+			Config:    getConfig(),
+			Channel:   channel,
+			Requester: requester.NewHTTPRequester(*channel.Proxy, requestErrorHandle),
+		},
+	}
+}
+
+type JinaProvider struct {
+	base.BaseProvider
+}
+
+func getConfig() base.ProviderConfig {
+	return base.ProviderConfig{
+		BaseURL:    "https://api.jina.ai",
+		Embeddings: "/v1/embeddings",
+		Rerank:     "/v1/rerank",
+	}
+}
+
+// 请求错误处理
+func requestErrorHandle(resp *http.Response) *types.OpenAIError {
+	jinaError := &JinaError{}
+	err := json.NewDecoder(resp.Body).Decode(jinaError)
+	if err != nil {
+		return nil
+	}
+
+	return errorHandle(jinaError)
+}
+
+// 错误处理，参数校验失败时 detail 为错误列表
+func errorHandle(jinaError *JinaError) *types.OpenAIError {
+	if jinaError.Detail == nil {
+		return nil
+	}
+
+	message, ok := jinaError.Detail.(string)
+	if !ok {
+		detail, _ := json.Marshal(jinaError.Detail)
+		message = string(detail)
+	}
+	if message == "" {
+		return nil
+	}
+
+	return &types.OpenAIError{
+		Message: message,
+		Type:    "jina_error",
+	}
+}
+
+func (p *JinaProvider) GetRequestHeaders() (headers map[string]string) {
+	headers = make(map[string]string)
+	p.CommonRequestHeaders(headers)
+	headers["Authorization"] = fmt.Sprintf("Bearer %s", p.Channel.Key)
+	headers["Accept"] = "application/json"
+
+	return headers
+}
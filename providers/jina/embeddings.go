@@ -0,0 +1,89 @@
+package jina
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/types"
+)
+
+func (p *JinaProvider) CreateEmbeddings(request *types.EmbeddingRequest) (*types.EmbeddingResponse, *types.OpenAIErrorWithStatusCode) {
+	url, errWithCode := p.GetSupportedAPIUri(config.RelayModeEmbeddings)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	fullRequestURL := p.GetFullRequestURL(url, request.Model)
+	headers := p.GetRequestHeaders()
+
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(convertEmbeddingRequest(request, p.getEmbeddingOptions())), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	defer req.Body.Close()
+
+	jinaResponse := &EmbeddingResponse{}
+	_, errWithCode = p.Requester.SendRequest(req, jinaResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	response := &types.EmbeddingResponse{
+		Object: "list",
+		Model:  request.Model,
+		Data:   jinaResponse.Data,
+	}
+	if jinaResponse.Usage != nil {
+		*p.Usage = *jinaResponse.Usage.ToOpenAIUsage()
+	} else {
+		p.Usage.TotalTokens = p.Usage.PromptTokens
+	}
+	response.Usage = p.Usage
+
+	return response, nil
+}
+
+// getEmbeddingOptions 从原始请求中读取 input_type、task 等扩展参数
+func (p *JinaProvider) getEmbeddingOptions() *EmbeddingOptions {
+	options := &EmbeddingOptions{}
+	if p.Context == nil {
+		return options
+	}
+	if rawBody, ok := p.GetRawBody(); ok {
+		_ = json.Unmarshal(rawBody, options)
+	}
+	return options
+}
+
+// convertEmbeddingRequest 没有指定 task 时根据 input_type 选择检索任务，output_dimension 优先于 dimensions
+// https://api.jina.ai/redoc#tag/embeddings
+func convertEmbeddingRequest(request *types.EmbeddingRequest, options *EmbeddingOptions) *EmbeddingRequest {
+	jinaRequest := &EmbeddingRequest{
+		Model:      request.Model,
+		Input:      request.Input,
+		Task:       options.Task,
+		Dimensions: request.Dimensions,
+		Truncate:   options.Truncation,
+	}
+
+	if options.OutputDimension > 0 {
+		jinaRequest.Dimensions = options.OutputDimension
+	}
+
+	if jinaRequest.Task == "" {
+		switch options.InputType {
+		case "query":
+			jinaRequest.Task = "retrieval.query"
+		case "document":
+			jinaRequest.Task = "retrieval.passage"
+		}
+	}
+
+	switch request.EncodingFormat {
+	case "float", "base64":
+		jinaRequest.EmbeddingType = request.EncodingFormat
+	}
+
+	return jinaRequest
+}
@@ -0,0 +1,46 @@
+package jina
+
+import (
+	"testing"
+
+	"czloapi/common/utils"
+	"czloapi/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertEmbeddingRequest(t *testing.T) {
+	jinaRequest := convertEmbeddingRequest(&types.EmbeddingRequest{
+		Model:          "jina-embeddings-v3",
+		Input:          []any{"hello"},
+		Dimensions:     512,
+		EncodingFormat: "base64",
+	}, &EmbeddingOptions{
+		InputType:       "query",
+		OutputDimension: 256,
+		Truncation:      utils.GetPointer(true),
+	})
+	assert.Equal(t, "retrieval.query", jinaRequest.Task)
+	assert.Equal(t, 256, jinaRequest.Dimensions)
+	assert.Equal(t, "base64", jinaRequest.EmbeddingType)
+	assert.True(t, *jinaRequest.Truncate)
+
+	// 明确指定的 task 优先于 input_type
+	jinaRequest = convertEmbeddingRequest(&types.EmbeddingRequest{
+		Model: "jina-embeddings-v3",
+		Input: "hello",
+	}, &EmbeddingOptions{
+		InputType: "document",
+		Task:      "text-matching",
+	})
+	assert.Equal(t, "text-matching", jinaRequest.Task)
+	assert.Empty(t, jinaRequest.EmbeddingType)
+}
+
+func TestErrorHandle(t *testing.T) {
+	assert.Nil(t, errorHandle(&JinaError{}))
+	assert.Equal(t, "invalid model", errorHandle(&JinaError{Detail: "invalid model"}).Message)
+
+	openaiErr := errorHandle(&JinaError{Detail: []any{map[string]any{"msg": "field required"}}})
+	assert.Contains(t, openaiErr.Message, "field required")
+}
@@ -0,0 +1,68 @@
+package jina
+
+import (
+	"net/http"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/common/utils"
+	"czloapi/types"
+)
+
+// CreateRerank 文档可以是字符串，也可以是 jina-reranker-m0 支持的 {"text"}、{"image"} 对象
+func (p *JinaProvider) CreateRerank(request *types.RerankRequest) (*types.RerankResponse, *types.OpenAIErrorWithStatusCode) {
+	url, errWithCode := p.GetSupportedAPIUri(config.RelayModeRerank)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	fullRequestURL := p.GetFullRequestURL(url, request.Model)
+	headers := p.GetRequestHeaders()
+
+	rerankRequest := &RerankRequest{
+		Model:           request.Model,
+		Query:           request.Query,
+		Documents:       request.Documents,
+		TopN:            request.TopN,
+		ReturnDocuments: request.ReturnDocuments,
+		Truncation:      request.Truncation,
+	}
+	if rerankRequest.ReturnDocuments == nil {
+		rerankRequest.ReturnDocuments = utils.GetPointer(true)
+	}
+
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(rerankRequest), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	defer req.Body.Close()
+
+	jinaResponse := &RerankResponse{}
+	_, errWithCode = p.Requester.SendRequest(req, jinaResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	response := &types.RerankResponse{
+		Model:   request.Model,
+		Results: make([]types.RerankResult, 0, len(jinaResponse.Results)),
+	}
+	for _, result := range jinaResponse.Results {
+		rerankResult := types.RerankResult{
+			Index:          result.Index,
+			RelevanceScore: result.RelevanceScore,
+		}
+		if result.Document != nil {
+			rerankResult.Document.Text = result.Document.Text
+		}
+		response.Results = append(response.Results, rerankResult)
+	}
+
+	if jinaResponse.Usage != nil {
+		*p.Usage = *jinaResponse.Usage.ToOpenAIUsage()
+	} else {
+		p.Usage.TotalTokens = p.Usage.PromptTokens
+	}
+	response.Usage = p.Usage
+
+	return response, nil
+}
@@ -0,0 +1,71 @@
+package jina
+
+import "czloapi/types"
+
+type JinaError struct {
+	Detail any `json:"detail"`
+}
+
+type EmbeddingRequest struct {
+	Model         string `json:"model"`
+	Input         any    `json:"input"`
+	Task          string `json:"task,omitempty"`
+	Dimensions    int    `json:"dimensions,omitempty"`
+	EmbeddingType string `json:"embedding_type,omitempty"`
+	Truncate      *bool  `json:"truncate,omitempty"`
+}
+
+// EmbeddingOptions 从原始请求中读取的扩展参数，不放在通用请求中以免转发给 OpenAI 兼容的上游
+type EmbeddingOptions struct {
+	InputType       string `json:"input_type"`       // query 或 document
+	Task            string `json:"task"`             // 任务类型，如 retrieval.query
+	Truncation      *bool  `json:"truncation"`       // 超长时是否截断
+	OutputDimension int    `json:"output_dimension"` // 输出维度，与 dimensions 含义相同
+}
+
+type EmbeddingResponse struct {
+	Model string            `json:"model"`
+	Data  []types.Embedding `json:"data"`
+	Usage *Usage            `json:"usage,omitempty"`
+}
+
+type RerankRequest struct {
+	Model           string `json:"model"`
+	Query           string `json:"query"`
+	Documents       []any  `json:"documents"`
+	TopN            int    `json:"top_n,omitempty"`
+	ReturnDocuments *bool  `json:"return_documents,omitempty"`
+	Truncation      *bool  `json:"truncation,omitempty"`
+}
+
+type RerankResponse struct {
+	Model   string         `json:"model"`
+	Results []RerankResult `json:"results"`
+	Usage   *Usage         `json:"usage,omitempty"`
+}
+
+// RerankResult 文档为多模态对象时 document 中可能没有 text
+type RerankResult struct {
+	Index          int     `json:"index"`
+	RelevanceScore float64 `json:"relevance_score"`
+	Document       *struct {
+		Text string `json:"text"`
+	} `json:"document,omitempty"`
+}
+
+type Usage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// ToOpenAIUsage Jina 只返回输入 token 数
+func (u *Usage) ToOpenAIUsage() *types.Usage {
+	promptTokens := u.PromptTokens
+	if promptTokens == 0 {
+		promptTokens = u.TotalTokens
+	}
+	return &types.Usage{
+		PromptTokens: promptTokens,
+		TotalTokens:  promptTokens,
+	}
+}
@@ -0,0 +1,122 @@
+package mistral
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"czloapi/common/requester"
+	"czloapi/model"
+	"czloapi/providers/base"
+	"czloapi/types"
+)
+
+type MistralProviderFactory struct{}
+
+// 创建 MistralProvider
+// https://docs.mistral.ai/api/
+func (f MistralProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
+	return &MistralProvider{
+		BaseProvider: base.BaseProvider{
+			Config:    getConfig(),
+			Channel:   channel,
+			Requester: requester.NewHTTPRequester(*channel.Proxy, requestErrorHandle),
+		},
+	}
+}
+
+type MistralProvider struct {
+	base.BaseProvider
+}
+
+func getConfig() base.ProviderConfig {
+	return base.ProviderConfig{
+		BaseURL:         "https://api.mistral.ai",
+		Completions:     "/v1/fim/completions",
+		ChatCompletions: "/v1/chat/completions",
+		Embeddings:      "/v1/embeddings",
+		Moderation:      "/v1/moderations",
+		OCR:             "/v1/ocr",
+		ModelList:       "/v1/models",
+	}
+}
+
+// 请求错误处理
+func requestErrorHandle(resp *http.Response) *types.OpenAIError {
+	mistralError := &MistralError{}
+	err := json.NewDecoder(resp.Body).Decode(mistralError)
+	if err != nil {
+		return nil
+	}
+
+	return errorHandle(mistralError)
+}
+
+// 错误处理，参数校验失败时错误信息在 detail 中
+func errorHandle(mistralError *MistralError) *types.OpenAIError {
+	message := errorMessage(mistralError.Message)
+	if message == "" {
+		message = errorMessage(mistralError.Detail)
+	}
+	if message == "" {
+		return nil
+	}
+
+	errorType := mistralError.Type
+	if errorType == "" {
+		errorType = "mistral_error"
+	}
+
+	return &types.OpenAIError{
+		Message: message,
+		Type:    errorType,
+		Code:    mistralError.Code,
+	}
+}
+
+func errorMessage(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		messages := make([]string, 0, len(v))
+		for _, item := range v {
+			detail, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			msg, _ := detail["msg"].(string)
+			if loc, ok := detail["loc"].([]any); ok && len(loc) > 0 {
+				msg = fmt.Sprintf("%v: %s", loc[len(loc)-1], msg)
+			}
+			messages = append(messages, msg)
+		}
+		return strings.Join(messages, "; ")
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// 获取请求头
+func (p *MistralProvider) GetRequestHeaders() (headers map[string]string) {
+	headers = make(map[string]string)
+	p.CommonRequestHeaders(headers)
+	headers["Authorization"] = fmt.Sprintf("Bearer %s", p.Channel.Key)
+
+	return headers
+}
+
+func convertFinishReason(finishReason string) string {
+	switch finishReason {
+	case "length", "model_length":
+		return types.FinishReasonLength
+	case "tool_calls":
+		return types.FinishReasonToolCalls
+	default:
+		return types.FinishReasonStop
+	}
+}
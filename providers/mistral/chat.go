@@ -0,0 +1,232 @@
+package mistral
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/common/requester"
+	"czloapi/common/utils"
+	"czloapi/types"
+)
+
+type mistralStreamHandler struct {
+	Usage   *types.Usage
+	Request *types.ChatCompletionRequest
+}
+
+func (p *MistralProvider) CreateChatCompletion(request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	req, errWithCode := p.getChatRequest(request)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	mistralResponse := &ChatResponse{}
+	// 发送请求
+	_, errWithCode = p.Requester.SendRequest(req, mistralResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	return p.convertToChatOpenai(mistralResponse, request)
+}
+
+func (p *MistralProvider) CreateChatCompletionStream(request *types.ChatCompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
+	req, errWithCode := p.getChatRequest(request)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	// 发送请求
+	resp, errWithCode := p.Requester.SendRequestRaw(req)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	chatHandler := &mistralStreamHandler{
+		Usage:   p.Usage,
+		Request: request,
+	}
+
+	return requester.RequestStream(p.Requester, resp, chatHandler.handlerStream)
+}
+
+func (p *MistralProvider) getChatRequest(request *types.ChatCompletionRequest) (*http.Request, *types.OpenAIErrorWithStatusCode) {
+	url, errWithCode := p.GetSupportedAPIUri(config.RelayModeChatCompletions)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	// 获取请求地址
+	fullRequestURL := p.GetFullRequestURL(url, request.Model)
+	if fullRequestURL == "" {
+		return nil, common.ErrorWrapper(nil, "invalid_mistral_config", http.StatusInternalServerError)
+	}
+
+	headers := p.GetRequestHeaders()
+	if request.Stream {
+		headers["Accept"] = "text/event-stream"
+	}
+
+	// 创建请求
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(convertFromChatOpenai(request)), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+
+	return req, nil
+}
+
+// convertFromChatOpenai 转换为 Mistral 的请求体，Mistral 不接受未知字段，只传递支持的参数
+func convertFromChatOpenai(request *types.ChatCompletionRequest) *ChatRequest {
+	mistralRequest := &ChatRequest{
+		Model:            request.Model,
+		Messages:         request.Messages,
+		Temperature:      request.Temperature,
+		TopP:             request.TopP,
+		MaxTokens:        request.MaxTokens,
+		Stream:           request.Stream,
+		Stop:             request.Stop,
+		RandomSeed:       request.Seed,
+		ResponseFormat:   request.ResponseFormat,
+		Tools:            request.Tools,
+		ToolChoice:       request.ToolChoice,
+		PresencePenalty:  request.PresencePenalty,
+		FrequencyPenalty: request.FrequencyPenalty,
+		N:                request.N,
+		Prediction:       request.Prediction,
+	}
+
+	if request.MaxCompletionTokens > 0 {
+		mistralRequest.MaxTokens = request.MaxCompletionTokens
+	}
+
+	if request.ParallelToolCalls {
+		mistralRequest.ParallelToolCalls = utils.GetPointer(true)
+	}
+
+	// 兼容旧版 functions 参数
+	if len(mistralRequest.Tools) == 0 && len(request.Functions) > 0 {
+		for _, function := range request.Functions {
+			mistralRequest.Tools = append(mistralRequest.Tools, &types.ChatCompletionTool{
+				Type:     "function",
+				Function: *function,
+			})
+		}
+	}
+
+	// Mistral 使用 any 表示必须调用工具
+	if toolChoice, ok := mistralRequest.ToolChoice.(string); ok && toolChoice == "required" {
+		mistralRequest.ToolChoice = "any"
+	}
+
+	return mistralRequest
+}
+
+func (p *MistralProvider) convertToChatOpenai(response *ChatResponse, request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	openaiResponse := &types.ChatCompletionResponse{
+		ID:      response.ID,
+		Object:  "chat.completion",
+		Created: response.Created,
+		Model:   request.Model,
+		Choices: make([]types.ChatCompletionChoice, 0, len(response.Choices)),
+	}
+
+	for _, choice := range response.Choices {
+		content, reasoning := choice.Message.ParseContent()
+		openaiChoice := types.ChatCompletionChoice{
+			Index: choice.Index,
+			Message: types.ChatCompletionMessage{
+				Role:             types.ChatMessageRoleAssistant,
+				Content:          content,
+				ReasoningContent: reasoning,
+				ToolCalls:        choice.Message.GetToolCalls(),
+			},
+			FinishReason: convertFinishReason(choice.FinishReason),
+		}
+		openaiChoice.CheckChoice(request)
+		openaiResponse.Choices = append(openaiResponse.Choices, openaiChoice)
+	}
+
+	if response.Usage == nil {
+		response.Usage = &types.Usage{
+			PromptTokens:     p.Usage.PromptTokens,
+			CompletionTokens: common.CountTokenText(openaiResponse.GetContent(), request.Model),
+		}
+		response.Usage.TotalTokens = response.Usage.PromptTokens + response.Usage.CompletionTokens
+	}
+	openaiResponse.Usage = response.Usage
+
+	*p.Usage = *response.Usage
+
+	return openaiResponse, nil
+}
+
+// 转换为OpenAI聊天流式请求体
+func (h *mistralStreamHandler) handlerStream(rawLine *[]byte, dataChan chan string, errChan chan error) {
+	// 如果rawLine 前缀不为data:，则直接返回
+	if !strings.HasPrefix(string(*rawLine), "data:") {
+		*rawLine = nil
+		return
+	}
+
+	*rawLine = bytes.TrimSpace((*rawLine)[5:])
+
+	// 如果等于 DONE 则结束
+	if string(*rawLine) == "[DONE]" {
+		errChan <- io.EOF
+		*rawLine = requester.StreamClosed
+		return
+	}
+
+	var mistralResponse ChatStreamResponse
+	if err := json.Unmarshal(*rawLine, &mistralResponse); err != nil {
+		errChan <- common.ErrorToOpenAIError(err)
+		return
+	}
+
+	chatCompletion := types.ChatCompletionStreamResponse{
+		ID:      mistralResponse.ID,
+		Object:  "chat.completion.chunk",
+		Created: mistralResponse.Created,
+		Model:   h.Request.Model,
+		Choices: make([]types.ChatCompletionStreamChoice, 0, len(mistralResponse.Choices)),
+	}
+
+	for _, choice := range mistralResponse.Choices {
+		content, reasoning := choice.Delta.ParseContent()
+		streamChoice := types.ChatCompletionStreamChoice{
+			Index: choice.Index,
+			Delta: types.ChatCompletionStreamChoiceDelta{
+				Role:             choice.Delta.Role,
+				Content:          content,
+				ReasoningContent: reasoning,
+				ToolCalls:        choice.Delta.GetToolCalls(),
+			},
+		}
+		if choice.FinishReason != nil {
+			streamChoice.FinishReason = convertFinishReason(*choice.FinishReason)
+		}
+		streamChoice.CheckChoice(h.Request)
+		chatCompletion.Choices = append(chatCompletion.Choices, streamChoice)
+
+		h.Usage.TextBuilder.WriteString(content)
+	}
+
+	// 最后一个数据块带有用量
+	if mistralResponse.Usage != nil {
+		*h.Usage = *mistralResponse.Usage
+		chatCompletion.Usage = mistralResponse.Usage
+	} else if h.Usage.TotalTokens == 0 {
+		h.Usage.TotalTokens = h.Usage.PromptTokens
+	}
+
+	responseBody, _ := json.Marshal(chatCompletion)
+	dataChan <- string(responseBody)
+}
@@ -0,0 +1,89 @@
+package mistral
+
+import (
+	"encoding/json"
+	"testing"
+
+	"czloapi/common/utils"
+	"czloapi/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertFromChatOpenai(t *testing.T) {
+	request := &types.ChatCompletionRequest{
+		Model:               "mistral-large-latest",
+		Messages:            []types.ChatCompletionMessage{{Role: "user", Content: "hi"}},
+		MaxCompletionTokens: 512,
+		Seed:                utils.GetPointer(42),
+		ToolChoice:          "required",
+		Functions:           []*types.ChatCompletionFunction{{Name: "get_weather"}},
+		User:                "u1",
+	}
+
+	data, err := json.Marshal(convertFromChatOpenai(request))
+	assert.NoError(t, err)
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(data, &body))
+	assert.Equal(t, float64(512), body["max_tokens"])
+	assert.Equal(t, float64(42), body["random_seed"])
+	assert.Equal(t, "any", body["tool_choice"])
+	assert.Len(t, body["tools"], 1)
+	// Mistral 不接受的字段不会透传
+	assert.NotContains(t, body, "user")
+	assert.NotContains(t, body, "seed")
+	assert.NotContains(t, body, "max_completion_tokens")
+}
+
+func TestChatMessageParseContent(t *testing.T) {
+	message := ChatMessage{Content: json.RawMessage(`"hello"`)}
+	text, reasoning := message.ParseContent()
+	assert.Equal(t, "hello", text)
+	assert.Empty(t, reasoning)
+
+	message = ChatMessage{Content: json.RawMessage(`[{"type":"thinking","thinking":[{"type":"text","text":"let me think"}]},{"type":"text","text":"42"}]`)}
+	text, reasoning = message.ParseContent()
+	assert.Equal(t, "42", text)
+	assert.Equal(t, "let me think", reasoning)
+}
+
+func TestHandlerStream(t *testing.T) {
+	usage := &types.Usage{PromptTokens: 10}
+	handler := &mistralStreamHandler{
+		Usage:   usage,
+		Request: &types.ChatCompletionRequest{Model: "mistral-small"},
+	}
+
+	dataChan := make(chan string, 2)
+	errChan := make(chan error, 1)
+
+	line := []byte(`data: {"id":"1","object":"chat.completion.chunk","created":1,"model":"mistral-small-latest","choices":[{"index":0,"delta":{"content":"Hi"},"finish_reason":null}]}`)
+	handler.handlerStream(&line, dataChan, errChan)
+	line = []byte(`data: {"id":"1","object":"chat.completion.chunk","created":1,"model":"mistral-small-latest","choices":[{"index":0,"delta":{"content":""},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`)
+	handler.handlerStream(&line, dataChan, errChan)
+
+	var chunk types.ChatCompletionStreamResponse
+	assert.NoError(t, json.Unmarshal([]byte(<-dataChan), &chunk))
+	assert.Equal(t, "mistral-small", chunk.Model)
+	assert.Equal(t, "Hi", chunk.Choices[0].Delta.Content)
+
+	assert.NoError(t, json.Unmarshal([]byte(<-dataChan), &chunk))
+	assert.Equal(t, types.FinishReasonStop, chunk.Choices[0].FinishReason)
+	assert.Equal(t, 15, usage.TotalTokens)
+	assert.Equal(t, 3, usage.CompletionTokens)
+}
+
+func TestErrorHandle(t *testing.T) {
+	var mistralError MistralError
+	assert.NoError(t, json.Unmarshal([]byte(`{"object":"error","message":"Invalid model: foo","type":"invalid_model","code":"1500"}`), &mistralError))
+	err := errorHandle(&mistralError)
+	assert.Equal(t, "Invalid model: foo", err.Message)
+	assert.Equal(t, "invalid_model", err.Type)
+
+	mistralError = MistralError{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"detail":[{"type":"extra_forbidden","loc":["body","user"],"msg":"Extra inputs are not permitted"}]}`), &mistralError))
+	err = errorHandle(&mistralError)
+	assert.Equal(t, "user: Extra inputs are not permitted", err.Message)
+	assert.Equal(t, "mistral_error", err.Type)
+}
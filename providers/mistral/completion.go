@@ -0,0 +1,202 @@
+package mistral
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/common/requester"
+	"czloapi/types"
+)
+
+// FIM 补全通过旧版 /v1/completions 提供，prompt 为光标前的代码，suffix 为光标后的代码
+// https://docs.mistral.ai/api/#tag/fim
+
+type mistralCompletionStreamHandler struct {
+	Usage     *types.Usage
+	ModelName string
+}
+
+func (p *MistralProvider) CreateCompletion(request *types.CompletionRequest) (*types.CompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	req, errWithCode := p.getFIMRequest(request)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	mistralResponse := &ChatResponse{}
+	// 发送请求
+	_, errWithCode = p.Requester.SendRequest(req, mistralResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	response := &types.CompletionResponse{
+		ID:      mistralResponse.ID,
+		Object:  "text_completion",
+		Created: mistralResponse.Created,
+		Model:   request.Model,
+		Choices: make([]types.CompletionChoice, 0, len(mistralResponse.Choices)),
+	}
+
+	var responseText strings.Builder
+	for _, choice := range mistralResponse.Choices {
+		text, _ := choice.Message.ParseContent()
+		responseText.WriteString(text)
+		response.Choices = append(response.Choices, types.CompletionChoice{
+			Text:         text,
+			Index:        choice.Index,
+			FinishReason: convertFinishReason(choice.FinishReason),
+		})
+	}
+
+	if mistralResponse.Usage == nil {
+		mistralResponse.Usage = &types.Usage{
+			PromptTokens:     p.Usage.PromptTokens,
+			CompletionTokens: common.CountTokenText(responseText.String(), request.Model),
+		}
+		mistralResponse.Usage.TotalTokens = mistralResponse.Usage.PromptTokens + mistralResponse.Usage.CompletionTokens
+	}
+	response.Usage = mistralResponse.Usage
+
+	*p.Usage = *mistralResponse.Usage
+
+	return response, nil
+}
+
+func (p *MistralProvider) CreateCompletionStream(request *types.CompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
+	req, errWithCode := p.getFIMRequest(request)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	// 发送请求
+	resp, errWithCode := p.Requester.SendRequestRaw(req)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	completionHandler := &mistralCompletionStreamHandler{
+		Usage:     p.Usage,
+		ModelName: request.Model,
+	}
+
+	return requester.RequestStream(p.Requester, resp, completionHandler.handlerStream)
+}
+
+func (p *MistralProvider) getFIMRequest(request *types.CompletionRequest) (*http.Request, *types.OpenAIErrorWithStatusCode) {
+	url, errWithCode := p.GetSupportedAPIUri(config.RelayModeCompletions)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	prompt, ok := fimPrompt(request.Prompt)
+	if !ok {
+		return nil, common.StringErrorWrapperLocal("prompt must be a string", "invalid_request_error", http.StatusBadRequest)
+	}
+
+	// 获取请求地址
+	fullRequestURL := p.GetFullRequestURL(url, request.Model)
+	if fullRequestURL == "" {
+		return nil, common.ErrorWrapper(nil, "invalid_mistral_config", http.StatusInternalServerError)
+	}
+
+	headers := p.GetRequestHeaders()
+	if request.Stream {
+		headers["Accept"] = "text/event-stream"
+	}
+
+	fimRequest := &FIMRequest{
+		Model:       request.Model,
+		Prompt:      prompt,
+		Suffix:      request.Suffix,
+		MaxTokens:   request.MaxTokens,
+		Temperature: request.Temperature,
+		TopP:        request.TopP,
+		Stream:      request.Stream,
+		Stop:        request.Stop,
+	}
+
+	// 创建请求
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(fimRequest), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+
+	return req, nil
+}
+
+// fimPrompt FIM 只支持单个 prompt
+func fimPrompt(prompt any) (string, bool) {
+	switch v := prompt.(type) {
+	case string:
+		return v, true
+	case []any:
+		if len(v) == 1 {
+			text, ok := v[0].(string)
+			return text, ok
+		}
+	}
+	return "", false
+}
+
+// 转换为OpenAI补全流式请求体
+func (h *mistralCompletionStreamHandler) handlerStream(rawLine *[]byte, dataChan chan string, errChan chan error) {
+	// 如果rawLine 前缀不为data:，则直接返回
+	if !strings.HasPrefix(string(*rawLine), "data:") {
+		*rawLine = nil
+		return
+	}
+
+	*rawLine = bytes.TrimSpace((*rawLine)[5:])
+
+	// 如果等于 DONE 则结束
+	if string(*rawLine) == "[DONE]" {
+		errChan <- io.EOF
+		*rawLine = requester.StreamClosed
+		return
+	}
+
+	var mistralResponse ChatStreamResponse
+	if err := json.Unmarshal(*rawLine, &mistralResponse); err != nil {
+		errChan <- common.ErrorToOpenAIError(err)
+		return
+	}
+
+	completion := types.CompletionResponse{
+		ID:      mistralResponse.ID,
+		Object:  "text_completion",
+		Created: mistralResponse.Created,
+		Model:   h.ModelName,
+		Choices: make([]types.CompletionChoice, 0, len(mistralResponse.Choices)),
+	}
+
+	for _, choice := range mistralResponse.Choices {
+		text, _ := choice.Delta.ParseContent()
+		completionChoice := types.CompletionChoice{
+			Text:  text,
+			Index: choice.Index,
+		}
+		if choice.FinishReason != nil {
+			completionChoice.FinishReason = convertFinishReason(*choice.FinishReason)
+		}
+		completion.Choices = append(completion.Choices, completionChoice)
+
+		h.Usage.TextBuilder.WriteString(text)
+	}
+
+	if mistralResponse.Usage != nil {
+		*h.Usage = *mistralResponse.Usage
+		completion.Usage = mistralResponse.Usage
+	} else if h.Usage.TotalTokens == 0 {
+		h.Usage.TotalTokens = h.Usage.PromptTokens
+	}
+
+	responseBody, _ := json.Marshal(completion)
+	dataChan <- string(responseBody)
+}
@@ -0,0 +1,64 @@
+package mistral
+
+import (
+	"net/http"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/types"
+)
+
+func (p *MistralProvider) CreateEmbeddings(request *types.EmbeddingRequest) (*types.EmbeddingResponse, *types.OpenAIErrorWithStatusCode) {
+	url, errWithCode := p.GetSupportedAPIUri(config.RelayModeEmbeddings)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	// 获取请求地址
+	fullRequestURL := p.GetFullRequestURL(url, request.Model)
+	if fullRequestURL == "" {
+		return nil, common.ErrorWrapper(nil, "invalid_mistral_config", http.StatusInternalServerError)
+	}
+
+	// 获取请求头
+	headers := p.GetRequestHeaders()
+
+	mistralRequest := &EmbeddingRequest{
+		Model:           request.Model,
+		Input:           request.Input,
+		OutputDimension: request.Dimensions,
+		EncodingFormat:  request.EncodingFormat,
+	}
+
+	// 创建请求
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(mistralRequest), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	defer req.Body.Close()
+
+	mistralResponse := &EmbeddingResponse{}
+
+	// 发送请求
+	_, errWithCode = p.Requester.SendRequest(req, mistralResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	response := &types.EmbeddingResponse{
+		Object: "list",
+		Model:  request.Model,
+		Data:   mistralResponse.Data,
+		Usage:  mistralResponse.Usage,
+	}
+
+	if response.Usage == nil {
+		response.Usage = &types.Usage{
+			PromptTokens: p.Usage.PromptTokens,
+			TotalTokens:  p.Usage.PromptTokens,
+		}
+	}
+
+	*p.Usage = *response.Usage
+
+	return response, nil
+}
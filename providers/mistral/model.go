@@ -0,0 +1,33 @@
+package mistral
+
+import (
+	"errors"
+	"net/http"
+)
+
+func (p *MistralProvider) GetModelList() ([]string, error) {
+	fullRequestURL := p.GetFullRequestURL(p.Config.ModelList, "")
+	headers := p.GetRequestHeaders()
+
+	req, err := p.Requester.NewRequest(http.MethodGet, fullRequestURL, p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, errors.New("new_request_failed")
+	}
+
+	response := &ModelListResponse{}
+	_, errWithCode := p.Requester.SendRequest(req, response, false)
+	if errWithCode != nil {
+		return nil, errors.New(errWithCode.Message)
+	}
+
+	var modelList []string
+	for _, model := range response.Data {
+		// 跳过已宣布弃用的模型
+		if model.Deprecation != nil {
+			continue
+		}
+		modelList = append(modelList, model.ID)
+	}
+
+	return modelList, nil
+}
@@ -0,0 +1,66 @@
+package mistral
+
+import (
+	"net/http"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/types"
+)
+
+// CreateModeration Mistral 的审查分类与 OpenAI 不同（如 hate_and_discrimination、pii），分类名称原样返回，flagged 由分类结果汇总
+func (p *MistralProvider) CreateModeration(request *types.ModerationRequest) (*types.ModerationResponse, *types.OpenAIErrorWithStatusCode) {
+	url, errWithCode := p.GetSupportedAPIUri(config.RelayModeModerations)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	// 获取请求地址
+	fullRequestURL := p.GetFullRequestURL(url, request.Model)
+	if fullRequestURL == "" {
+		return nil, common.ErrorWrapper(nil, "invalid_mistral_config", http.StatusInternalServerError)
+	}
+
+	// 获取请求头
+	headers := p.GetRequestHeaders()
+
+	mistralRequest := &ModerationRequest{
+		Model: request.Model,
+		Input: request.Input,
+	}
+
+	// 创建请求
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(mistralRequest), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	defer req.Body.Close()
+
+	mistralResponse := &ModerationResponse{}
+
+	// 发送请求
+	_, errWithCode = p.Requester.SendRequest(req, mistralResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	for index, result := range mistralResponse.Results {
+		for _, flagged := range result.Categories {
+			if flagged {
+				mistralResponse.Results[index].Flagged = true
+				break
+			}
+		}
+	}
+
+	if mistralResponse.Usage != nil {
+		*p.Usage = *mistralResponse.Usage
+	} else {
+		p.Usage.TotalTokens = p.Usage.PromptTokens
+	}
+
+	return &types.ModerationResponse{
+		ID:      mistralResponse.ID,
+		Model:   request.Model,
+		Results: mistralResponse.Results,
+	}, nil
+}
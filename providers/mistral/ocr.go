@@ -0,0 +1,46 @@
+package mistral
+
+import (
+	"net/http"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/types"
+)
+
+// CreateOCR 文档 OCR，用量按处理的页数记为输入 tokens
+func (p *MistralProvider) CreateOCR(request *types.OCRRequest) (*types.OCRResponse, *types.OpenAIErrorWithStatusCode) {
+	url, errWithCode := p.GetSupportedAPIUri(config.RelayModeOCR)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	// 获取请求地址
+	fullRequestURL := p.GetFullRequestURL(url, request.Model)
+	if fullRequestURL == "" {
+		return nil, common.ErrorWrapper(nil, "invalid_mistral_config", http.StatusInternalServerError)
+	}
+
+	// 获取请求头
+	headers := p.GetRequestHeaders()
+
+	// 创建请求
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(request), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	defer req.Body.Close()
+
+	response := &types.OCRResponse{}
+
+	// 发送请求
+	_, errWithCode = p.Requester.SendRequest(req, response, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	p.Usage.PromptTokens = response.UsageInfo.PagesProcessed
+	p.Usage.CompletionTokens = 0
+	p.Usage.TotalTokens = p.Usage.PromptTokens
+
+	return response, nil
+}
@@ -0,0 +1,47 @@
+package mistral
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"czloapi/model"
+	"czloapi/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateOCR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/ocr", r.URL.Path)
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "mistral-ocr-latest", body["model"])
+		assert.Equal(t, map[string]any{"type": "document_url", "document_url": "https://example.com/a.pdf"}, body["document"])
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"model":"mistral-ocr-2505","pages":[{"index":0,"markdown":"# A"},{"index":1,"markdown":"B"}],"usage_info":{"pages_processed":2,"doc_size_bytes":1024}}`))
+	}))
+	defer server.Close()
+
+	baseURL := server.URL
+	proxy := ""
+	provider := MistralProviderFactory{}.Create(&model.Channel{Key: "sk-test", BaseURL: &baseURL, Proxy: &proxy}).(*MistralProvider)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/v1/ocr", nil)
+	provider.SetContext(ctx)
+	provider.SetUsage(&types.Usage{})
+
+	response, errWithCode := provider.CreateOCR(&types.OCRRequest{
+		Model:    "mistral-ocr-latest",
+		Document: types.OCRDocument{Type: "document_url", DocumentURL: "https://example.com/a.pdf"},
+	})
+	assert.Nil(t, errWithCode)
+	assert.Len(t, response.Pages, 2)
+	assert.Equal(t, 2, provider.GetUsage().PromptTokens)
+	assert.Equal(t, 2, provider.GetUsage().TotalTokens)
+}
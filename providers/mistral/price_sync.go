@@ -0,0 +1,113 @@
+package mistral
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"czloapi/common/config"
+	"czloapi/model"
+)
+
+func init() {
+	model.RegisterPriceSyncProvider(&mistralPriceSyncProvider{})
+}
+
+// mistralPrices Mistral 没有提供可解析的价格接口，价格随本包维护，单位为美元/百万 tokens
+var mistralPrices = []model.PriceSyncDraftRow{
+	{SourceModel: "mistral-large-latest", Input: 2, Output: 6},
+	{SourceModel: "mistral-medium-latest", Input: 0.4, Output: 2},
+	{SourceModel: "mistral-small-latest", Input: 0.1, Output: 0.3},
+	{SourceModel: "magistral-medium-latest", Input: 2, Output: 5},
+	{SourceModel: "magistral-small-latest", Input: 0.5, Output: 1.5},
+	{SourceModel: "codestral-latest", Input: 0.3, Output: 0.9},
+	{SourceModel: "devstral-medium-latest", Input: 0.4, Output: 2},
+	{SourceModel: "devstral-small-latest", Input: 0.1, Output: 0.3},
+	{SourceModel: "ministral-8b-latest", Input: 0.1, Output: 0.1},
+	{SourceModel: "ministral-3b-latest", Input: 0.04, Output: 0.04},
+	{SourceModel: "pixtral-large-latest", Input: 2, Output: 6},
+	{SourceModel: "pixtral-12b-latest", Input: 0.15, Output: 0.15},
+	{SourceModel: "open-mistral-nemo", Input: 0.15, Output: 0.15},
+	{SourceModel: "mistral-embed", Input: 0.1},
+	{SourceModel: "codestral-embed", Input: 0.15},
+	{SourceModel: "mistral-moderation-latest", Input: 0.1},
+	// OCR 按页计费（每千页 1 美元），用量中以页数作为输入 tokens
+	{SourceModel: "mistral-ocr-latest", Input: 1000},
+}
+
+var mistralModelPrefixes = []string{"mistral-", "magistral-", "codestral-", "devstral-", "ministral-", "pixtral-", "open-mistral-", "open-mixtral-"}
+
+type mistralPriceSyncProvider struct{}
+
+func (p *mistralPriceSyncProvider) Key() string {
+	return "mistral"
+}
+
+func (p *mistralPriceSyncProvider) Name() string {
+	return "Mistral AI"
+}
+
+func (p *mistralPriceSyncProvider) ChannelType() int {
+	return config.ChannelTypeMistral
+}
+
+func (p *mistralPriceSyncProvider) SourceURL() string {
+	return "https://mistral.ai/pricing#api-pricing"
+}
+
+func (p *mistralPriceSyncProvider) Fetch(_ context.Context) ([]byte, error) {
+	return json.Marshal(mistralPrices)
+}
+
+func (p *mistralPriceSyncProvider) Parse(raw []byte) ([]model.PriceSyncDraftRow, error) {
+	var rows []model.PriceSyncDraftRow
+	if err := json.Unmarshal(raw, &rows); err != nil {
+		return nil, err
+	}
+
+	for index := range rows {
+		rows[index].Type = model.TokensPriceType
+		rows[index].ChannelType = p.ChannelType()
+	}
+
+	return rows, nil
+}
+
+func (p *mistralPriceSyncProvider) FilterModelOptions(options []string) []string {
+	filtered := make([]string, 0)
+	for _, option := range options {
+		for _, prefix := range mistralModelPrefixes {
+			if strings.HasPrefix(option, prefix) {
+				filtered = append(filtered, option)
+				break
+			}
+		}
+	}
+
+	sort.Strings(filtered)
+	return filtered
+}
+
+// SuggestModel 优先完全匹配，否则匹配去掉 -latest 后唯一的同系列模型
+func (p *mistralPriceSyncProvider) SuggestModel(sourceModel string, options []string) string {
+	for _, option := range options {
+		if option == sourceModel {
+			return option
+		}
+	}
+
+	family := strings.TrimSuffix(sourceModel, "-latest")
+	candidates := make([]string, 0)
+	for _, option := range options {
+		if option == family || strings.HasPrefix(option, family+"-") {
+			candidates = append(candidates, option)
+		}
+	}
+
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	return ""
+}
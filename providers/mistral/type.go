@@ -0,0 +1,177 @@
+package mistral
+
+import (
+	"encoding/json"
+	"strings"
+
+	"czloapi/types"
+)
+
+type MistralError struct {
+	Object  string `json:"object,omitempty"`
+	Message any    `json:"message,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Code    any    `json:"code,omitempty"`
+	Detail  any    `json:"detail,omitempty"`
+}
+
+type ChatRequest struct {
+	Model             string                              `json:"model"`
+	Messages          []types.ChatCompletionMessage       `json:"messages"`
+	Temperature       *float64                            `json:"temperature,omitempty"`
+	TopP              *float64                            `json:"top_p,omitempty"`
+	MaxTokens         int                                 `json:"max_tokens,omitempty"`
+	Stream            bool                                `json:"stream,omitempty"`
+	Stop              any                                 `json:"stop,omitempty"`
+	RandomSeed        *int                                `json:"random_seed,omitempty"`
+	ResponseFormat    *types.ChatCompletionResponseFormat `json:"response_format,omitempty"`
+	Tools             []*types.ChatCompletionTool         `json:"tools,omitempty"`
+	ToolChoice        any                                 `json:"tool_choice,omitempty"`
+	PresencePenalty   *float64                            `json:"presence_penalty,omitempty"`
+	FrequencyPenalty  *float64                            `json:"frequency_penalty,omitempty"`
+	N                 *int                                `json:"n,omitempty"`
+	Prediction        any                                 `json:"prediction,omitempty"`
+	ParallelToolCalls *bool                               `json:"parallel_tool_calls,omitempty"`
+}
+
+type ChatResponse struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []ChatChoice `json:"choices"`
+	Usage   *types.Usage `json:"usage,omitempty"`
+}
+
+type ChatChoice struct {
+	Index        int         `json:"index"`
+	Message      ChatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+type ChatStreamResponse struct {
+	ID      string             `json:"id"`
+	Object  string             `json:"object"`
+	Created int64              `json:"created"`
+	Model   string             `json:"model"`
+	Choices []ChatStreamChoice `json:"choices"`
+	Usage   *types.Usage       `json:"usage,omitempty"`
+}
+
+type ChatStreamChoice struct {
+	Index        int         `json:"index"`
+	Delta        ChatMessage `json:"delta"`
+	FinishReason *string     `json:"finish_reason"`
+}
+
+type ChatMessage struct {
+	Role      string                           `json:"role,omitempty"`
+	Content   json.RawMessage                  `json:"content,omitempty"`
+	ToolCalls []*types.ChatCompletionToolCalls `json:"tool_calls,omitempty"`
+}
+
+// ContentChunk 推理模型（magistral）返回的内容为分块数组，思考过程在 thinking 块中
+type ContentChunk struct {
+	Type     string         `json:"type"`
+	Text     string         `json:"text,omitempty"`
+	Thinking []ContentChunk `json:"thinking,omitempty"`
+}
+
+// ParseContent 返回正文和思考内容
+func (m *ChatMessage) ParseContent() (text string, reasoning string) {
+	if len(m.Content) == 0 || string(m.Content) == "null" {
+		return "", ""
+	}
+
+	var content string
+	if err := json.Unmarshal(m.Content, &content); err == nil {
+		return content, ""
+	}
+
+	var chunks []ContentChunk
+	if err := json.Unmarshal(m.Content, &chunks); err != nil {
+		return "", ""
+	}
+
+	var textBuilder, reasoningBuilder strings.Builder
+	for _, chunk := range chunks {
+		switch chunk.Type {
+		case "text":
+			textBuilder.WriteString(chunk.Text)
+		case "thinking":
+			for _, thinking := range chunk.Thinking {
+				reasoningBuilder.WriteString(thinking.Text)
+			}
+		}
+	}
+
+	return textBuilder.String(), reasoningBuilder.String()
+}
+
+// GetToolCalls 补全工具调用的类型和序号
+func (m *ChatMessage) GetToolCalls() []*types.ChatCompletionToolCalls {
+	for index, toolCall := range m.ToolCalls {
+		if toolCall.Type == "" {
+			toolCall.Type = "function"
+		}
+		if len(m.ToolCalls) > 1 && toolCall.Index == 0 {
+			toolCall.Index = index
+		}
+	}
+
+	return m.ToolCalls
+}
+
+type FIMRequest struct {
+	Model       string   `json:"model"`
+	Prompt      string   `json:"prompt"`
+	Suffix      string   `json:"suffix,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	Temperature float32  `json:"temperature,omitempty"`
+	TopP        float32  `json:"top_p,omitempty"`
+	Stream      bool     `json:"stream,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+type EmbeddingRequest struct {
+	Model           string `json:"model"`
+	Input           any    `json:"input"`
+	OutputDimension int    `json:"output_dimension,omitempty"`
+	EncodingFormat  string `json:"encoding_format,omitempty"`
+}
+
+type EmbeddingResponse struct {
+	ID     string            `json:"id"`
+	Object string            `json:"object"`
+	Data   []types.Embedding `json:"data"`
+	Model  string            `json:"model"`
+	Usage  *types.Usage      `json:"usage,omitempty"`
+}
+
+type ModerationRequest struct {
+	Model string `json:"model"`
+	Input any    `json:"input"`
+}
+
+type ModerationResponse struct {
+	ID      string             `json:"id"`
+	Model   string             `json:"model"`
+	Results []ModerationResult `json:"results"`
+	Usage   *types.Usage       `json:"usage,omitempty"`
+}
+
+type ModerationResult struct {
+	Flagged        bool               `json:"flagged"`
+	Categories     map[string]bool    `json:"categories"`
+	CategoryScores map[string]float64 `json:"category_scores"`
+}
+
+type ModelListResponse struct {
+	Object string      `json:"object"`
+	Data   []ModelCard `json:"data"`
+}
+
+type ModelCard struct {
+	ID          string `json:"id"`
+	Deprecation any    `json:"deprecation,omitempty"`
+}
@@ -29,6 +29,8 @@ type OpenAIProvider struct {
 
 	UsageHandler        UsageHandler
 	RequestHandleBefore RequestHandleBefore
+	// ExtraBody 渠道在 RequestHandleBefore 中设置的私有参数，发送时合并到请求体，不放在通用请求中以免转发给其他上游
+	ExtraBody map[string]any
 
 	responsesWSTools      []types.ResponsesTools
 	responsesWSBilledKeys map[string]bool
@@ -233,7 +235,7 @@ func (p *OpenAIProvider) GetRequestTextBody(relayMode int, ModelName string, req
 	}
 
 	// 检查是否需要合并额外字段（来自用户请求中的 extra_body）
-	needMerge := p.Channel.AllowExtraBody
+	needMerge := p.Channel.AllowExtraBody || len(p.ExtraBody) > 0
 
 	if needMerge {
 		// 将请求体转换为 map，以便保留用户请求中的额外字段
@@ -252,6 +254,9 @@ func (p *OpenAIProvider) GetRequestTextBody(relayMode int, ModelName string, req
 		if p.Channel.AllowExtraBody {
 			requestMap = p.mergeExtraBodyFromRawRequest(requestMap)
 		}
+		for key, value := range p.ExtraBody {
+			requestMap[key] = value
+		}
 
 		if relayMode == config.RelayModeResponses {
 			convertChat := false
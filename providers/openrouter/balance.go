@@ -0,0 +1,29 @@
+package openrouter
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Balance 剩余额度为已购买额度减去已使用额度
+// https://openrouter.ai/docs/api-reference/get-credits
+func (p *OpenRouterProvider) Balance() (float64, error) {
+	fullRequestURL := p.GetFullRequestURL("/v1/credits", "")
+	headers := p.GetRequestHeaders()
+
+	req, err := p.Requester.NewRequest(http.MethodGet, fullRequestURL, p.Requester.WithHeader(headers))
+	if err != nil {
+		return 0, err
+	}
+
+	// 发送请求
+	var credits CreditsResponse
+	_, errWithCode := p.Requester.SendRequest(req, &credits, false)
+	if errWithCode != nil {
+		return 0, errors.New(errWithCode.OpenAIError.Message)
+	}
+
+	balance := credits.Data.TotalCredits - credits.Data.TotalUsage
+	p.Channel.UpdateBalance(balance)
+	return balance, nil
+}
@@ -0,0 +1,102 @@
+package openrouter
+
+import (
+	"strings"
+
+	"czloapi/common/requester"
+	"czloapi/model"
+	"czloapi/providers/base"
+	"czloapi/providers/openai"
+)
+
+type OpenRouterProviderFactory struct{}
+
+// 创建 OpenRouterProvider
+// https://openrouter.ai/docs/api-reference/overview
+func (f OpenRouterProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
+	provider := &OpenRouterProvider{
+		OpenAIProvider: openai.OpenAIProvider{
+			BaseProvider: base.BaseProvider{
+				Config:    getConfig(),
+				Channel:   channel,
+				Requester: requester.NewHTTPRequester(*channel.Proxy, openai.RequestErrorHandle),
+			},
+			SupportStreamOptions: true,
+		},
+	}
+	provider.loadPlugin()
+
+	return provider
+}
+
+func getConfig() base.ProviderConfig {
+	return base.ProviderConfig{
+		BaseURL:         "https://openrouter.ai/api",
+		Completions:     "/v1/completions",
+		ChatCompletions: "/v1/chat/completions",
+		Embeddings:      "/v1/embeddings",
+		ModelList:       "/v1/models",
+	}
+}
+
+type OpenRouterProvider struct {
+	openai.OpenAIProvider
+
+	// 渠道默认的供应商路由偏好和消息转换，请求中带有同名参数时以请求为准
+	Preferences map[string]any
+	Transforms  []string
+}
+
+// loadPlugin 读取渠道插件中配置的路由偏好
+// https://openrouter.ai/docs/features/provider-routing
+func (p *OpenRouterProvider) loadPlugin() {
+	if p.Channel.Plugin == nil {
+		return
+	}
+	plugin := p.Channel.Plugin.Data()
+
+	if routing, ok := plugin["provider"]; ok {
+		preferences := make(map[string]any)
+		for _, key := range []string{"order", "only", "ignore", "quantizations"} {
+			if list := splitList(routing[key]); len(list) > 0 {
+				preferences[key] = list
+			}
+		}
+		if sort, ok := routing["sort"].(string); ok && sort != "" {
+			preferences["sort"] = sort
+		}
+		if disable, ok := routing["disable_fallbacks"].(bool); ok && disable {
+			preferences["allow_fallbacks"] = false
+		}
+		if require, ok := routing["require_parameters"].(bool); ok && require {
+			preferences["require_parameters"] = true
+		}
+		if deny, ok := routing["deny_data_collection"].(bool); ok && deny {
+			preferences["data_collection"] = "deny"
+		}
+		if len(preferences) > 0 {
+			p.Preferences = preferences
+		}
+	}
+
+	if transforms, ok := plugin["transforms"]; ok {
+		if enable, ok := transforms["middle_out"].(bool); ok && enable {
+			p.Transforms = []string{"middle-out"}
+		}
+	}
+}
+
+func splitList(value any) []string {
+	text, ok := value.(string)
+	if !ok {
+		return nil
+	}
+
+	var list []string
+	for _, item := range strings.Split(text, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,156 @@
+package openrouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/common/requester"
+	"czloapi/providers/openai"
+	"czloapi/types"
+)
+
+type openRouterStreamHandler struct {
+	openai.OpenAIStreamHandler
+	cost float64
+}
+
+func (p *OpenRouterProvider) CreateChatCompletion(request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	req, errWithCode := p.GetRequestTextBody(config.RelayModeChatCompletions, request.Model, p.newChatRequest(request))
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	response := &ChatResponse{}
+	// 发送请求
+	_, errWithCode = p.Requester.SendRequest(req, response, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	// 检测是否错误
+	openaiErr := openai.ErrorHandle(&response.OpenAIErrorResponse)
+	if openaiErr != nil {
+		return nil, &types.OpenAIErrorWithStatusCode{
+			OpenAIError: *openaiErr,
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+
+	for index, choice := range response.Choices {
+		if choice.Message.ReasoningContent == "" && choice.Message.Reasoning != "" {
+			response.Choices[index].Message.ReasoningContent = choice.Message.Reasoning
+			response.Choices[index].Message.Reasoning = ""
+		}
+	}
+
+	if response.Usage == nil || response.Usage.CompletionTokens == 0 {
+		usage := &types.Usage{
+			PromptTokens:     p.Usage.PromptTokens,
+			CompletionTokens: common.CountTokenText(response.GetContent(), request.Model),
+		}
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+		response.ChatCompletionResponse.Usage = usage
+	} else {
+		response.ChatCompletionResponse.Usage = &response.Usage.Usage
+		response.Usage.Usage.UpstreamCost = response.Usage.Cost
+	}
+
+	*p.Usage = *response.ChatCompletionResponse.Usage
+
+	return &response.ChatCompletionResponse, nil
+}
+
+func (p *OpenRouterProvider) CreateChatCompletionStream(request *types.ChatCompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
+	streamOptions := request.StreamOptions
+	request.StreamOptions = &types.StreamOptions{
+		IncludeUsage: true,
+	}
+	req, errWithCode := p.GetRequestTextBody(config.RelayModeChatCompletions, request.Model, p.newChatRequest(request))
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	// 恢复原来的配置
+	request.StreamOptions = streamOptions
+
+	// 发送请求
+	resp, errWithCode := p.Requester.SendRequestRaw(req)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	chatHandler := &openRouterStreamHandler{
+		OpenAIStreamHandler: openai.OpenAIStreamHandler{
+			Usage:            p.Usage,
+			ModelName:        request.Model,
+			ReasoningHandler: true,
+		},
+	}
+
+	return requester.RequestStream(p.Requester, resp, chatHandler.handlerChatStream)
+}
+
+// newChatRequest 附加路由偏好、消息转换，并要求返回实际费用
+// 请求中已带有 provider、transforms 时以请求为准
+func (p *OpenRouterProvider) newChatRequest(request *types.ChatCompletionRequest) *ChatRequest {
+	chatRequest := &ChatRequest{
+		ChatCompletionRequest: request,
+		Provider:              p.Preferences,
+		Transforms:            p.Transforms,
+		Usage:                 &UsageOption{Include: true},
+	}
+
+	if p.Context == nil {
+		return chatRequest
+	}
+	rawBody, ok := p.GetRawBody()
+	if !ok {
+		return chatRequest
+	}
+
+	var rawRequest struct {
+		Provider   map[string]any `json:"provider"`
+		Transforms []string       `json:"transforms"`
+	}
+	if err := json.Unmarshal(rawBody, &rawRequest); err != nil {
+		return chatRequest
+	}
+
+	if len(rawRequest.Provider) > 0 {
+		preferences := make(map[string]any, len(p.Preferences)+len(rawRequest.Provider))
+		for key, value := range p.Preferences {
+			preferences[key] = value
+		}
+		for key, value := range rawRequest.Provider {
+			preferences[key] = value
+		}
+		chatRequest.Provider = preferences
+	}
+	if rawRequest.Transforms != nil {
+		chatRequest.Transforms = rawRequest.Transforms
+	}
+
+	return chatRequest
+}
+
+// handlerChatStream 在 OpenAI 流处理的基础上记录最后一个数据块中的实际费用
+func (h *openRouterStreamHandler) handlerChatStream(rawLine *[]byte, dataChan chan string, errChan chan error) {
+	if bytes.Contains(*rawLine, []byte(`"cost"`)) {
+		var response StreamUsageResponse
+		data := bytes.TrimSpace(bytes.TrimPrefix(*rawLine, []byte("data:")))
+		if err := json.Unmarshal(data, &response); err == nil && response.Usage != nil {
+			h.cost = response.Usage.Cost
+		}
+	}
+
+	h.OpenAIStreamHandler.HandlerChatStream(rawLine, dataChan, errChan)
+
+	if h.cost > 0 {
+		h.Usage.UpstreamCost = h.cost
+	}
+}
@@ -0,0 +1,97 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"czloapi/common/config"
+	"czloapi/model"
+	"czloapi/providers/base"
+	"czloapi/providers/openai"
+	"czloapi/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestNewChatRequest(t *testing.T) {
+	plugin := datatypes.NewJSONType(model.PluginType{
+		"provider": {
+			"order":                "anthropic, openai",
+			"disable_fallbacks":    true,
+			"require_parameters":   false,
+			"deny_data_collection": true,
+		},
+		"transforms": {"middle_out": true},
+	})
+	provider := &OpenRouterProvider{
+		OpenAIProvider: openai.OpenAIProvider{
+			BaseProvider: base.BaseProvider{Channel: &model.Channel{Plugin: &plugin}},
+		},
+	}
+	provider.loadPlugin()
+
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Set(config.GinRequestBodyKey, []byte(`{"model":"openai/gpt-4o","provider":{"sort":"price"},"transforms":[]}`))
+	provider.SetContext(ctx)
+
+	data, err := json.Marshal(provider.newChatRequest(&types.ChatCompletionRequest{Model: "openai/gpt-4o"}))
+	assert.NoError(t, err)
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(data, &body))
+	assert.Equal(t, "openai/gpt-4o", body["model"])
+	assert.Equal(t, map[string]any{
+		"order":           []any{"anthropic", "openai"},
+		"allow_fallbacks": false,
+		"data_collection": "deny",
+		"sort":            "price",
+	}, body["provider"])
+	// 请求中明确传入空的 transforms 时关闭渠道默认的压缩
+	assert.NotContains(t, body, "transforms")
+	assert.Equal(t, map[string]any{"include": true}, body["usage"])
+}
+
+func TestHandlerChatStreamCost(t *testing.T) {
+	usage := &types.Usage{PromptTokens: 10}
+	handler := &openRouterStreamHandler{
+		OpenAIStreamHandler: openai.OpenAIStreamHandler{Usage: usage, ModelName: "openai/gpt-4o"},
+	}
+
+	dataChan := make(chan string, 2)
+	errChan := make(chan error, 1)
+
+	line := []byte(`data: {"id":"1","object":"chat.completion.chunk","created":1,"model":"openai/gpt-4o","choices":[{"index":0,"delta":{"content":"Hi"},"finish_reason":null}]}`)
+	handler.handlerChatStream(&line, dataChan, errChan)
+	line = []byte(`data: {"id":"1","object":"chat.completion.chunk","created":1,"model":"openai/gpt-4o","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15,"cost":0.000123}}`)
+	handler.handlerChatStream(&line, dataChan, errChan)
+
+	assert.Len(t, dataChan, 1)
+	assert.Equal(t, 15, usage.TotalTokens)
+	assert.Equal(t, 0.000123, usage.UpstreamCost)
+}
+
+func TestPriceSyncParse(t *testing.T) {
+	raw := []byte(`{"data":[
+		{"id":"anthropic/claude-sonnet-4","pricing":{"prompt":"0.000003","completion":"0.000015","input_cache_read":"0.0000003","input_cache_write":"0.00000375"}},
+		{"id":"openrouter/auto","pricing":{"prompt":"-1","completion":"-1"}},
+		{"id":"meta-llama/llama-3.3-70b-instruct:free","pricing":{"prompt":"0","completion":"0"}}
+	]}`)
+
+	provider := &openRouterPriceSyncProvider{}
+	rows, err := provider.Parse(raw)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+
+	assert.Equal(t, "anthropic/claude-sonnet-4", rows[0].SourceModel)
+	assert.Equal(t, 3.0, rows[0].Input)
+	assert.Equal(t, 15.0, rows[0].Output)
+	assert.Equal(t, 0.3, rows[0].ExtraRatios[config.UsageExtraCachedRead])
+	assert.Equal(t, 3.75, rows[0].ExtraRatios[config.UsageExtraCachedWrite])
+	assert.Equal(t, config.ChannelTypeOpenRouter, rows[0].ChannelType)
+
+	assert.Equal(t, "claude-sonnet-4", provider.SuggestModel("anthropic/claude-sonnet-4", []string{"claude-sonnet-4", "gpt-4o"}))
+	assert.Empty(t, provider.SuggestModel("meta-llama/llama-3.3-70b-instruct:free", []string{"llama-3.3-70b-instruct"}))
+}
@@ -0,0 +1,168 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"czloapi/common/config"
+	"czloapi/model"
+
+	"github.com/shopspring/decimal"
+)
+
+func init() {
+	model.RegisterPriceSyncProvider(&openRouterPriceSyncProvider{})
+}
+
+type openRouterPriceSyncProvider struct{}
+
+func (p *openRouterPriceSyncProvider) Key() string {
+	return "openrouter"
+}
+
+func (p *openRouterPriceSyncProvider) Name() string {
+	return "OpenRouter"
+}
+
+func (p *openRouterPriceSyncProvider) ChannelType() int {
+	return config.ChannelTypeOpenRouter
+}
+
+func (p *openRouterPriceSyncProvider) SourceURL() string {
+	return "https://openrouter.ai/api/v1/models"
+}
+
+func (p *openRouterPriceSyncProvider) Fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.SourceURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "czloapi-price-sync/1.0")
+
+	client := &http.Client{Timeout: 20 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, p.SourceURL())
+	}
+
+	return body, nil
+}
+
+// Parse 将每 token 的价格换算为每百万 tokens，只有按次计费的模型使用按次价格，价格不固定的模型（如 openrouter/auto）会被跳过
+func (p *openRouterPriceSyncProvider) Parse(raw []byte) ([]model.PriceSyncDraftRow, error) {
+	var response ModelListResponse
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, err
+	}
+
+	rows := make([]model.PriceSyncDraftRow, 0, len(response.Data))
+	for _, info := range response.Data {
+		prompt, ok := perMillion(info.Pricing.Prompt)
+		if !ok {
+			continue
+		}
+		completion, ok := perMillion(info.Pricing.Completion)
+		if !ok {
+			continue
+		}
+
+		row := model.PriceSyncDraftRow{
+			SourceModel: info.ID,
+			Type:        model.TokensPriceType,
+			ChannelType: p.ChannelType(),
+			Input:       prompt,
+			Output:      completion,
+		}
+
+		request, _ := decimalPrice(info.Pricing.Request)
+		if prompt == 0 && completion == 0 && request.IsPositive() {
+			row.Type = model.TimesPriceType
+			row.Input = request.InexactFloat64()
+			row.Output = 0
+		}
+
+		extraRatios := make(map[string]float64)
+		if value, ok := perMillion(info.Pricing.InputCacheRead); ok && value > 0 {
+			extraRatios[config.UsageExtraCachedRead] = value
+		}
+		if value, ok := perMillion(info.Pricing.InputCacheWrite); ok && value > 0 {
+			extraRatios[config.UsageExtraCachedWrite] = value
+		}
+		if value, ok := perMillion(info.Pricing.InternalReasoning); ok && value > 0 {
+			extraRatios[config.UsageExtraReasoning] = value
+		}
+		if len(extraRatios) > 0 && row.Type == model.TokensPriceType {
+			row.ExtraRatios = extraRatios
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("no priced models found in provider response")
+	}
+
+	return rows, nil
+}
+
+func (p *openRouterPriceSyncProvider) FilterModelOptions(options []string) []string {
+	filtered := append([]string(nil), options...)
+	sort.Strings(filtered)
+	return filtered
+}
+
+// SuggestModel OpenRouter 的模型名称带有厂商前缀，如 anthropic/claude-sonnet-4，优先完全匹配，否则匹配去掉前缀后的名称
+func (p *openRouterPriceSyncProvider) SuggestModel(sourceModel string, options []string) string {
+	for _, option := range options {
+		if option == sourceModel {
+			return option
+		}
+	}
+
+	_, name, ok := strings.Cut(sourceModel, "/")
+	if !ok || strings.Contains(name, ":") {
+		return ""
+	}
+	for _, option := range options {
+		if option == name {
+			return option
+		}
+	}
+
+	return ""
+}
+
+func decimalPrice(value string) (decimal.Decimal, bool) {
+	if value == "" {
+		return decimal.Zero, true
+	}
+	price, err := decimal.NewFromString(value)
+	if err != nil || price.IsNegative() {
+		return decimal.Zero, false
+	}
+	return price, true
+}
+
+func perMillion(value string) (float64, bool) {
+	price, ok := decimalPrice(value)
+	if !ok {
+		return 0, false
+	}
+	return price.Mul(decimal.NewFromInt(1000000)).InexactFloat64(), true
+}
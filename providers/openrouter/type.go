@@ -0,0 +1,59 @@
+package openrouter
+
+import (
+	"czloapi/providers/openai"
+	"czloapi/types"
+)
+
+type ChatRequest struct {
+	*types.ChatCompletionRequest
+	Provider   map[string]any `json:"provider,omitempty"`
+	Transforms []string       `json:"transforms,omitempty"`
+	Usage      *UsageOption   `json:"usage,omitempty"`
+}
+
+type UsageOption struct {
+	Include bool `json:"include"`
+}
+
+// Usage OpenRouter 在用量中返回本次请求的实际费用
+type Usage struct {
+	types.Usage
+	Cost float64 `json:"cost,omitempty"`
+}
+
+type ChatResponse struct {
+	openai.OpenAIProviderChatResponse
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+type StreamUsageResponse struct {
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+type CreditsResponse struct {
+	Data struct {
+		TotalCredits float64 `json:"total_credits"`
+		TotalUsage   float64 `json:"total_usage"`
+	} `json:"data"`
+}
+
+type ModelListResponse struct {
+	Data []ModelInfo `json:"data"`
+}
+
+type ModelInfo struct {
+	ID      string       `json:"id"`
+	Name    string       `json:"name"`
+	Pricing ModelPricing `json:"pricing"`
+}
+
+// ModelPricing 价格单位为美元/token（request 为美元/次），-1 表示价格不固定
+type ModelPricing struct {
+	Prompt            string `json:"prompt"`
+	Completion        string `json:"completion"`
+	Request           string `json:"request,omitempty"`
+	InputCacheRead    string `json:"input_cache_read,omitempty"`
+	InputCacheWrite   string `json:"input_cache_write,omitempty"`
+	InternalReasoning string `json:"internal_reasoning,omitempty"`
+}
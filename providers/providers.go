@@ -14,15 +14,26 @@ import (
 	"czloapi/providers/cloudflareAI"
 	"czloapi/providers/cohere"
 	"czloapi/providers/deepseek"
+	"czloapi/providers/elevenlabs"
+	"czloapi/providers/flux"
 	"czloapi/providers/gemini"
 	"czloapi/providers/groq"
+	"czloapi/providers/hunyuan"
+	"czloapi/providers/ideogram"
+	"czloapi/providers/jina"
 	"czloapi/providers/minimax"
+	"czloapi/providers/mistral"
 	"czloapi/providers/moonshot"
 	"czloapi/providers/ollama"
 	"czloapi/providers/openai"
+	"czloapi/providers/openrouter"
+	"czloapi/providers/stability"
 	"czloapi/providers/vertexai"
+	"czloapi/providers/volcengine"
+	"czloapi/providers/voyage"
 	"czloapi/providers/xAI"
 	"czloapi/providers/zhipu"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
@@ -56,11 +67,29 @@ func init() {
 		config.ChannelTypeAzureDatabricks: azuredatabricks.AzureDatabricksProviderFactory{},
 		config.ChannelTypeAzureV1:         azure_v1.AzureV1ProviderFactory{},
 		config.ChannelTypeXAI:             xAI.XAIProviderFactory{},
+		config.ChannelTypeMistral:         mistral.MistralProviderFactory{},
+		config.ChannelTypeOpenRouter:      openrouter.OpenRouterProviderFactory{},
+		config.ChannelTypeVolcengine:      volcengine.VolcengineProviderFactory{},
+		config.ChannelTypeHunyuan:         hunyuan.HunyuanProviderFactory{},
+		config.ChannelTypeJina:            jina.JinaProviderFactory{},
+		config.ChannelTypeVoyage:          voyage.VoyageProviderFactory{},
+		config.ChannelTypeElevenLabs:      elevenlabs.ElevenLabsProviderFactory{},
+		config.ChannelTypeStability:       stability.StabilityProviderFactory{},
+		config.ChannelTypeFlux:            flux.FluxProviderFactory{},
+		config.ChannelTypeIdeogram:        ideogram.IdeogramProviderFactory{},
 	}
 }
 
 // 获取供应商
 func GetProvider(channel *model.Channel, c *gin.Context) base.ProviderInterface {
+	if err := channel.ResolveSecrets(); err != nil {
+		if c != nil && c.Request != nil {
+			logger.LogError(c.Request.Context(), "resolve channel secret failed: "+err.Error())
+		} else {
+			logger.SysError("resolve channel secret failed: " + err.Error())
+		}
+		return nil
+	}
 	if err := channel.SetProxy(); err != nil {
 		if c != nil && c.Request != nil {
 			logger.LogError(c.Request.Context(), "resolve channel proxy failed: "+err.Error())
@@ -85,5 +114,11 @@ func GetProvider(channel *model.Channel, c *gin.Context) base.ProviderInterface
 	}
 	provider.SetContext(c)
 
+	if len(channel.GetOverrideRules()) > 0 && provider.GetRequester() != nil {
+		provider.GetRequester().RequestOverride = func(header http.Header, body any) (any, error) {
+			return channel.ApplyOverrides(provider.GetOriginalModel(), header, body)
+		}
+	}
+
 	return provider
 }
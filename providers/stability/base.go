@@ -0,0 +1,78 @@
+package stability
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"czloapi/common/requester"
+	"czloapi/model"
+	"czloapi/providers/base"
+	"czloapi/types"
+)
+
+type StabilityProviderFactory struct{}
+
+// 创建 StabilityProvider
+// https://platform.stability.ai/docs/api-reference
+func (f StabilityProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
+	return &StabilityProvider{
+		BaseProvider: base.BaseProvider{
+			Config:    getConfig(),
+			Channel:   channel,
+			Requester: requester.NewHTTPRequester(*channel.Proxy, requestErrorHandle),
+		},
+	}
+}
+
+type StabilityProvider struct {
+	base.BaseProvider
+}
+
+func getConfig() base.ProviderConfig {
+	return base.ProviderConfig{
+		BaseURL:           "https://api.stability.ai",
+		ImagesGenerations: "/v2beta/stable-image/generate",
+		ImagesEdit:        "/v2beta/stable-image/edit",
+	}
+}
+
+// 请求错误处理
+func requestErrorHandle(resp *http.Response) *types.OpenAIError {
+	stabilityError := &StabilityError{}
+	err := json.NewDecoder(resp.Body).Decode(stabilityError)
+	if err != nil {
+		return nil
+	}
+
+	return errorHandle(stabilityError)
+}
+
+// 错误处理
+func errorHandle(stabilityError *StabilityError) *types.OpenAIError {
+	if len(stabilityError.Errors) == 0 && stabilityError.Message == "" {
+		return nil
+	}
+
+	message := stabilityError.Message
+	if len(stabilityError.Errors) > 0 {
+		message = strings.Join(stabilityError.Errors, "; ")
+	}
+
+	return &types.OpenAIError{
+		Message: message,
+		Type:    "stability_error",
+		Code:    stabilityError.Name,
+	}
+}
+
+func (p *StabilityProvider) GetRequestHeaders() (headers map[string]string) {
+	headers = make(map[string]string)
+	p.CommonRequestHeaders(headers)
+	headers["Authorization"] = fmt.Sprintf("Bearer %s", p.Channel.Key)
+	// 以 JSON 返回 base64 图片，同时带有 seed 和 finish_reason
+	headers["Accept"] = "application/json"
+
+	return headers
+}
@@ -0,0 +1,220 @@
+package stability
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/common/image"
+	"czloapi/common/storage"
+	"czloapi/common/utils"
+	"czloapi/types"
+)
+
+const finishReasonContentFiltered = "CONTENT_FILTERED"
+
+var aspectRatios = []string{"21:9", "16:9", "3:2", "5:4", "1:1", "4:5", "2:3", "9:16", "9:21"}
+
+var stylePresets = map[string]bool{
+	"3d-model": true, "analog-film": true, "anime": true, "cinematic": true, "comic-book": true,
+	"digital-art": true, "enhance": true, "fantasy-art": true, "isometric": true, "line-art": true,
+	"low-poly": true, "modeling-compound": true, "neon-punk": true, "origami": true, "photographic": true,
+	"pixel-art": true, "tile-texture": true,
+}
+
+// CreateImageGenerations 每次请求只生成一张图片，n 大于 1 时多次请求
+func (p *StabilityProvider) CreateImageGenerations(request *types.ImageRequest) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	uri, errWithCode := p.GetSupportedAPIUri(config.RelayModeImagesGenerations)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	options := p.getImageOptions(request)
+	fields := map[string]string{
+		"prompt":        request.Prompt,
+		"output_format": options.OutputFormat,
+	}
+	if options.NegativePrompt != "" {
+		fields["negative_prompt"] = options.NegativePrompt
+	}
+	if options.StylePreset != "" {
+		fields["style_preset"] = options.StylePreset
+	}
+	if request.AspectRatio != nil && *request.AspectRatio != "" {
+		fields["aspect_ratio"] = *request.AspectRatio
+	} else if aspectRatio := image.GetClosestAspectRatio(request.Size, aspectRatios); aspectRatio != "" {
+		fields["aspect_ratio"] = aspectRatio
+	}
+
+	endpoint, modelName := getGenerateEndpoint(request.Model)
+	if modelName != "" {
+		fields["model"] = modelName
+	}
+
+	return p.createImages(uri+endpoint, fields, nil, request.N, options, request.ResponseFormat)
+}
+
+// getGenerateEndpoint Ultra、Core 各自有独立接口，SD3 系列共用一个接口并通过 model 指定模型
+func getGenerateEndpoint(modelName string) (endpoint, sd3Model string) {
+	switch {
+	case strings.Contains(modelName, "ultra"):
+		return "/ultra", ""
+	case strings.Contains(modelName, "core"):
+		return "/core", ""
+	case strings.HasPrefix(modelName, "sd3"):
+		return "/sd3", modelName
+	default:
+		return "/sd3", ""
+	}
+}
+
+// convertStyle OpenAI 的 natural 对应 photographic，vivid 使用默认风格
+func convertStyle(style string) string {
+	if style == "natural" {
+		return "photographic"
+	}
+	if stylePresets[style] {
+		return style
+	}
+
+	return ""
+}
+
+func convertOutputFormat(outputFormat *string) string {
+	if outputFormat != nil {
+		switch *outputFormat {
+		case "jpeg", "webp":
+			return *outputFormat
+		}
+	}
+
+	return "png"
+}
+
+func (p *StabilityProvider) getImageOptions(request *types.ImageRequest) *ImageOptions {
+	options := &ImageOptions{}
+	if p.Context != nil {
+		if rawBody, ok := p.GetRawBody(); ok {
+			_ = json.Unmarshal(rawBody, options)
+		}
+	}
+
+	if options.StylePreset == "" {
+		options.StylePreset = convertStyle(request.Style)
+	}
+	if options.OutputFormat == "" {
+		options.OutputFormat = convertOutputFormat(request.OutputFormat)
+	}
+
+	return options
+}
+
+// createImages 按 n 多次请求，指定 seed 时依次递增，避免生成相同的图片
+func (p *StabilityProvider) createImages(uri string, fields map[string]string, files map[string]*multipart.FileHeader, n int, options *ImageOptions, responseFormat string) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	if n <= 0 {
+		n = 1
+	}
+
+	imageResponse := &types.ImageResponse{
+		Created: time.Now().Unix(),
+		Data:    make([]types.ImageResponseDataInner, 0, n),
+	}
+
+	for i := 0; i < n; i++ {
+		if options.Seed != nil {
+			fields["seed"] = strconv.FormatInt(*options.Seed+int64(i), 10)
+		}
+
+		stabilityResponse, errWithCode := p.sendForm(uri, fields, files)
+		if errWithCode != nil {
+			return nil, errWithCode
+		}
+
+		item, errWithCode := convertImageData(stabilityResponse.Image, options.OutputFormat, responseFormat)
+		if errWithCode != nil {
+			return nil, errWithCode
+		}
+		imageResponse.Data = append(imageResponse.Data, *item)
+	}
+
+	p.Usage.PromptTokens = len(imageResponse.Data) * 1000
+	p.Usage.TotalTokens = p.Usage.PromptTokens
+
+	return imageResponse, nil
+}
+
+func (p *StabilityProvider) sendForm(uri string, fields map[string]string, files map[string]*multipart.FileHeader) (*ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	var formBody bytes.Buffer
+	builder := p.Requester.CreateFormBuilder(&formBody)
+	for name, file := range files {
+		if err := builder.CreateFormFile(name, file); err != nil {
+			return nil, common.ErrorWrapper(err, "create_form_builder_failed", http.StatusInternalServerError)
+		}
+	}
+	for name, value := range fields {
+		if err := builder.WriteField(name, value); err != nil {
+			return nil, common.ErrorWrapper(err, "create_form_builder_failed", http.StatusInternalServerError)
+		}
+	}
+	if err := builder.Close(); err != nil {
+		return nil, common.ErrorWrapper(err, "create_form_builder_failed", http.StatusInternalServerError)
+	}
+
+	req, err := p.Requester.NewRequest(
+		http.MethodPost,
+		p.GetFullRequestURL(uri, ""),
+		p.Requester.WithBody(&formBody),
+		p.Requester.WithHeader(p.GetRequestHeaders()),
+		p.Requester.WithContentType(builder.FormDataContentType()))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	req.ContentLength = int64(formBody.Len())
+	defer req.Body.Close()
+
+	stabilityResponse := &ImageResponse{}
+	_, errWithCode := p.Requester.SendRequest(req, stabilityResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	if openaiErr := errorHandle(&stabilityResponse.StabilityError); openaiErr != nil {
+		return nil, &types.OpenAIErrorWithStatusCode{
+			OpenAIError: *openaiErr,
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+
+	// 审核不通过时仍会返回模糊处理后的图片并计费，这里直接返回错误
+	if stabilityResponse.FinishReason == finishReasonContentFiltered {
+		return nil, common.StringErrorWrapper("image was filtered by content moderation", "content_filter", http.StatusBadRequest)
+	}
+
+	return stabilityResponse, nil
+}
+
+// convertImageData url 格式时上传到存储，未配置存储时返回 b64_json
+func convertImageData(b64Image, outputFormat, responseFormat string) (*types.ImageResponseDataInner, *types.OpenAIErrorWithStatusCode) {
+	if responseFormat == "b64_json" {
+		return &types.ImageResponseDataInner{B64JSON: b64Image}, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(b64Image)
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "decode_image_failed", http.StatusInternalServerError)
+	}
+
+	url := storage.Upload(data, utils.GetUUID()+"."+outputFormat)
+	if url == "" {
+		return &types.ImageResponseDataInner{B64JSON: b64Image}, nil
+	}
+
+	return &types.ImageResponseDataInner{URL: url}, nil
+}
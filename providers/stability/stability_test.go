@@ -0,0 +1,53 @@
+package stability
+
+import (
+	"testing"
+
+	"czloapi/model"
+	"czloapi/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGenerateEndpoint(t *testing.T) {
+	endpoint, modelName := getGenerateEndpoint("stable-image-ultra")
+	assert.Equal(t, "/ultra", endpoint)
+	assert.Empty(t, modelName)
+
+	endpoint, _ = getGenerateEndpoint("stable-image-core")
+	assert.Equal(t, "/core", endpoint)
+
+	endpoint, modelName = getGenerateEndpoint("sd3.5-large-turbo")
+	assert.Equal(t, "/sd3", endpoint)
+	assert.Equal(t, "sd3.5-large-turbo", modelName)
+}
+
+func TestGetImageOptions(t *testing.T) {
+	provider := &StabilityProvider{}
+	provider.Channel = &model.Channel{}
+
+	options := provider.getImageOptions(&types.ImageRequest{Style: "natural"})
+	assert.Equal(t, "photographic", options.StylePreset)
+	assert.Equal(t, "png", options.OutputFormat)
+
+	outputFormat := "webp"
+	options = provider.getImageOptions(&types.ImageRequest{Style: "vivid", OutputFormat: &outputFormat})
+	assert.Empty(t, options.StylePreset)
+	assert.Equal(t, "webp", options.OutputFormat)
+
+	assert.Equal(t, "anime", convertStyle("anime"))
+}
+
+func TestErrorHandle(t *testing.T) {
+	openaiError := errorHandle(&StabilityError{Name: "bad_request", Errors: []string{"prompt: is required", "seed: invalid"}})
+	assert.Equal(t, "prompt: is required; seed: invalid", openaiError.Message)
+	assert.Equal(t, "bad_request", openaiError.Code)
+
+	assert.Nil(t, errorHandle(&StabilityError{}))
+}
+
+func TestConvertImageData(t *testing.T) {
+	item, errWithCode := convertImageData("aGVsbG8=", "png", "b64_json")
+	assert.Nil(t, errWithCode)
+	assert.Equal(t, "aGVsbG8=", item.B64JSON)
+}
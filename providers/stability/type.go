@@ -0,0 +1,25 @@
+package stability
+
+type StabilityError struct {
+	ID      string   `json:"id,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	Errors  []string `json:"errors,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+type ImageResponse struct {
+	StabilityError
+	Image        string `json:"image"`
+	FinishReason string `json:"finish_reason"`
+	Seed         int64  `json:"seed"`
+}
+
+// ImageOptions 是 OpenAI 请求中没有的参数，从原始请求中读取
+type ImageOptions struct {
+	NegativePrompt string `json:"negative_prompt"`
+	Seed           *int64 `json:"seed"`
+	StylePreset    string `json:"style_preset"`
+	OutputFormat   string `json:"output_format"`
+	// 图生图的参考强度，只在编辑接口的表单中使用
+	Strength string `json:"-"`
+}
@@ -0,0 +1,97 @@
+package volcengine
+
+import (
+	"strings"
+
+	"czloapi/common/requester"
+	"czloapi/model"
+	"czloapi/providers/base"
+	"czloapi/providers/openai"
+	"czloapi/types"
+)
+
+const (
+	// 使用上下文缓存时的对话接口，请求中需要带上 context_id
+	contextChatCompletions = "/api/v3/context/chat/completions"
+	// 批量推理接入点的对话接口，不支持流式
+	batchChatCompletions = "/api/v3/batch/chat/completions"
+	// 图文向量化接口
+	multimodalEmbeddings = "/api/v3/embeddings/multimodal"
+)
+
+type VolcengineProviderFactory struct{}
+
+// 创建 VolcengineProvider
+// https://www.volcengine.com/docs/82379/1298454
+func (f VolcengineProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
+	provider := &VolcengineProvider{
+		OpenAIProvider: openai.OpenAIProvider{
+			BaseProvider: base.BaseProvider{
+				Config:    getConfig(),
+				Channel:   channel,
+				Requester: requester.NewHTTPRequester(*channel.Proxy, openai.RequestErrorHandle),
+			},
+			SupportStreamOptions: true,
+			UsageHandler:         usageHandler,
+		},
+	}
+	provider.RequestHandleBefore = provider.requestHandler
+	provider.loadPlugin()
+
+	return provider
+}
+
+func getConfig() base.ProviderConfig {
+	return base.ProviderConfig{
+		BaseURL:           "https://ark.cn-beijing.volces.com",
+		ChatCompletions:   "/api/v3/chat/completions",
+		Embeddings:        "/api/v3/embeddings",
+		ImagesGenerations: "/api/v3/images/generations",
+	}
+}
+
+type VolcengineProvider struct {
+	openai.OpenAIProvider
+
+	// 批量推理接入点，这些模型的非流式请求发送到批量推理接口
+	BatchModels []string
+}
+
+// loadPlugin 读取渠道插件中配置的批量推理接入点
+func (p *VolcengineProvider) loadPlugin() {
+	if p.Channel.Plugin == nil {
+		return
+	}
+	plugin := p.Channel.Plugin.Data()
+
+	if batch, ok := plugin["batch"]; ok {
+		if models, ok := batch["models"].(string); ok {
+			for _, item := range strings.Split(models, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					p.BatchModels = append(p.BatchModels, item)
+				}
+			}
+		}
+	}
+}
+
+func (p *VolcengineProvider) isBatchModel(modelName string) bool {
+	for _, item := range p.BatchModels {
+		if item == modelName {
+			return true
+		}
+	}
+	return false
+}
+
+// usageHandler 上下文缓存命中的 token 包含在 prompt_tokens 中，通过 cached_tokens 单独计费
+func usageHandler(usage *types.Usage) (ForcedFormatting bool) {
+	if usage.PromptTokensDetails.CachedTokens > usage.PromptTokens {
+		usage.PromptTokensDetails.CachedTokens = usage.PromptTokens
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+
+	return false
+}
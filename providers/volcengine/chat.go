@@ -0,0 +1,81 @@
+package volcengine
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"czloapi/common"
+	"czloapi/types"
+)
+
+// requestHandler 统一思考开关的格式，并根据上下文缓存和批量推理选择对话接口
+// https://www.volcengine.com/docs/82379/1494384
+func (p *VolcengineProvider) requestHandler(request *types.ChatCompletionRequest) (errWithCode *types.OpenAIErrorWithStatusCode) {
+	convertThinking(request)
+
+	if contextID := p.getContextID(); contextID != "" {
+		p.Config.ChatCompletions = contextChatCompletions
+		p.ExtraBody = map[string]any{"context_id": contextID}
+		return nil
+	}
+
+	if p.isBatchModel(request.Model) {
+		if request.Stream {
+			return common.StringErrorWrapperLocal("batch inference endpoint does not support stream", "invalid_request_error", http.StatusBadRequest)
+		}
+		p.Config.ChatCompletions = batchChatCompletions
+	}
+
+	return nil
+}
+
+// getContextID 从原始请求中读取上下文缓存 ID，context_id 是火山引擎私有参数，不放在通用请求中
+func (p *VolcengineProvider) getContextID() string {
+	if p.Context == nil {
+		return ""
+	}
+	rawBody, ok := p.GetRawBody()
+	if !ok {
+		return ""
+	}
+
+	var rawRequest struct {
+		ContextID string `json:"context_id"`
+	}
+	if err := json.Unmarshal(rawBody, &rawRequest); err != nil {
+		return ""
+	}
+	return rawRequest.ContextID
+}
+
+// convertThinking 将 enable_thinking 以及字符串、布尔形式的 thinking 转换为 {"type": "enabled|disabled|auto"}
+func convertThinking(request *types.ChatCompletionRequest) {
+	var thinkingType string
+	if request.Thinking != nil {
+		switch value := (*request.Thinking).(type) {
+		case string:
+			thinkingType = value
+		case bool:
+			thinkingType = thinkingTypeFromBool(value)
+		}
+	} else if request.EnableThinking != nil {
+		thinkingType = thinkingTypeFromBool(*request.EnableThinking)
+	}
+
+	// 火山引擎不支持 qwen 的思考参数
+	request.EnableThinking = nil
+	request.ThinkingBudget = nil
+
+	if thinkingType == "" {
+		return
+	}
+	var thinking interface{} = map[string]string{"type": thinkingType}
+	request.Thinking = &thinking
+}
+
+func thinkingTypeFromBool(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
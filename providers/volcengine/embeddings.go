@@ -0,0 +1,90 @@
+package volcengine
+
+import (
+	"net/http"
+	"strings"
+
+	"czloapi/common"
+	"czloapi/providers/openai"
+	"czloapi/types"
+)
+
+// CreateEmbeddings 文本向量化与 OpenAI 兼容，图文向量化模型或包含图片、视频的输入使用多模态接口
+// https://www.volcengine.com/docs/82379/1409291
+func (p *VolcengineProvider) CreateEmbeddings(request *types.EmbeddingRequest) (*types.EmbeddingResponse, *types.OpenAIErrorWithStatusCode) {
+	input, multimodal := convertMultimodalInput(request)
+	if !multimodal {
+		return p.OpenAIProvider.CreateEmbeddings(request)
+	}
+
+	embeddingRequest := &MultimodalEmbeddingRequest{
+		Model:          request.Model,
+		Input:          input,
+		EncodingFormat: request.EncodingFormat,
+		Dimensions:     request.Dimensions,
+	}
+
+	fullRequestURL := p.GetFullRequestURL(multimodalEmbeddings, request.Model)
+	headers := p.GetRequestHeaders()
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(embeddingRequest), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	defer req.Body.Close()
+
+	response := &MultimodalEmbeddingResponse{}
+	_, errWithCode := p.Requester.SendRequest(req, response, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	openaiErr := openai.ErrorHandle(&response.OpenAIErrorResponse)
+	if openaiErr != nil {
+		return nil, &types.OpenAIErrorWithStatusCode{
+			OpenAIError: *openaiErr,
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+
+	if response.Usage != nil {
+		*p.Usage = *response.Usage
+	}
+
+	response.Data.Object = "embedding"
+	return &types.EmbeddingResponse{
+		Object: "list",
+		Data:   []types.Embedding{response.Data},
+		Model:  request.Model,
+		Usage:  p.Usage,
+	}, nil
+}
+
+// convertMultimodalInput 将输入转换为多模态格式，字符串转换为 text 类型，已经是对象的内容原样传递
+func convertMultimodalInput(request *types.EmbeddingRequest) ([]any, bool) {
+	multimodal := strings.Contains(request.Model, "vision")
+
+	var items []any
+	switch input := request.Input.(type) {
+	case string:
+		items = []any{input}
+	case []any:
+		items = input
+	default:
+		return nil, false
+	}
+
+	converted := make([]any, 0, len(items))
+	for _, item := range items {
+		switch value := item.(type) {
+		case string:
+			converted = append(converted, map[string]any{"type": "text", "text": value})
+		case map[string]any:
+			multimodal = true
+			converted = append(converted, value)
+		default:
+			return nil, false
+		}
+	}
+
+	return converted, multimodal
+}
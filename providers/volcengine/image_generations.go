@@ -0,0 +1,110 @@
+package volcengine
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"czloapi/common/config"
+	"czloapi/providers/openai"
+	"czloapi/types"
+)
+
+// CreateImageGenerations Seedream 图片生成，n 大于 1 时使用组图生成
+func (p *VolcengineProvider) CreateImageGenerations(request *types.ImageRequest) (*types.ImageResponse, *types.OpenAIErrorWithStatusCode) {
+	req, errWithCode := p.GetRequestTextBody(config.RelayModeImagesGenerations, request.Model, p.newImageRequest(request))
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	response := &ImageResponse{}
+	_, errWithCode = p.Requester.SendRequest(req, response, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	openaiErr := openai.ErrorHandle(&response.OpenAIErrorResponse)
+	if openaiErr != nil {
+		return nil, &types.OpenAIErrorWithStatusCode{
+			OpenAIError: *openaiErr,
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+
+	imageResponse := &types.ImageResponse{
+		Created: response.Created,
+		Data:    make([]types.ImageResponseDataInner, 0, len(response.Data)),
+	}
+	var imageErr *types.OpenAIError
+	for _, item := range response.Data {
+		if item.Error != nil {
+			imageErr = item.Error
+			continue
+		}
+		imageResponse.Data = append(imageResponse.Data, types.ImageResponseDataInner{
+			URL:     item.URL,
+			B64JSON: item.B64JSON,
+		})
+	}
+	if len(imageResponse.Data) == 0 {
+		if imageErr == nil {
+			imageErr = &types.OpenAIError{Message: "no image generated", Type: "volcengine_error"}
+		}
+		return nil, &types.OpenAIErrorWithStatusCode{
+			OpenAIError: *imageErr,
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+
+	// 按实际生成的图片数量计费，与预扣时每张图片 1000 token 的计算方式一致
+	generated := len(imageResponse.Data)
+	if response.Usage != nil && response.Usage.GeneratedImages > 0 {
+		generated = response.Usage.GeneratedImages
+	}
+	p.Usage.PromptTokens = generated * 1000
+	p.Usage.TotalTokens = p.Usage.PromptTokens
+
+	return imageResponse, nil
+}
+
+// newImageRequest 转换为 Seedream 的请求格式，seed、guidance_scale、watermark、image 从原始请求中读取
+func (p *VolcengineProvider) newImageRequest(request *types.ImageRequest) *ImageRequest {
+	imageRequest := &ImageRequest{
+		Model:          request.Model,
+		Prompt:         request.Prompt,
+		Size:           request.Size,
+		ResponseFormat: request.ResponseFormat,
+	}
+
+	if request.N > 1 {
+		imageRequest.SequentialImageGeneration = "auto"
+		imageRequest.SequentialImageGenerationOptions = &SequentialImageGenerationOptions{
+			MaxImages: request.N,
+		}
+	}
+
+	if p.Context == nil {
+		return imageRequest
+	}
+	rawBody, ok := p.GetRawBody()
+	if !ok {
+		return imageRequest
+	}
+
+	var rawRequest struct {
+		Image         any      `json:"image"`
+		Seed          *int     `json:"seed"`
+		GuidanceScale *float64 `json:"guidance_scale"`
+		Watermark     *bool    `json:"watermark"`
+	}
+	if err := json.Unmarshal(rawBody, &rawRequest); err != nil {
+		return imageRequest
+	}
+
+	imageRequest.Image = rawRequest.Image
+	imageRequest.Seed = rawRequest.Seed
+	imageRequest.GuidanceScale = rawRequest.GuidanceScale
+	imageRequest.Watermark = rawRequest.Watermark
+
+	return imageRequest
+}
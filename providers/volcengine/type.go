@@ -0,0 +1,61 @@
+package volcengine
+
+import (
+	"czloapi/types"
+)
+
+type MultimodalEmbeddingRequest struct {
+	Model          string `json:"model"`
+	Input          []any  `json:"input"`
+	EncodingFormat string `json:"encoding_format,omitempty"`
+	Dimensions     int    `json:"dimensions,omitempty"`
+}
+
+// MultimodalEmbeddingResponse 图文向量化将所有输入融合为一个向量
+type MultimodalEmbeddingResponse struct {
+	Model string          `json:"model"`
+	Data  types.Embedding `json:"data"`
+	Usage *types.Usage    `json:"usage,omitempty"`
+	types.OpenAIErrorResponse
+}
+
+// ImageRequest Seedream 图片生成请求
+// https://www.volcengine.com/docs/82379/1541523
+type ImageRequest struct {
+	Model                            string                            `json:"model"`
+	Prompt                           string                            `json:"prompt"`
+	Image                            any                               `json:"image,omitempty"`
+	Size                             string                            `json:"size,omitempty"`
+	Seed                             *int                              `json:"seed,omitempty"`
+	GuidanceScale                    *float64                          `json:"guidance_scale,omitempty"`
+	Watermark                        *bool                             `json:"watermark,omitempty"`
+	ResponseFormat                   string                            `json:"response_format,omitempty"`
+	SequentialImageGeneration        string                            `json:"sequential_image_generation,omitempty"`
+	SequentialImageGenerationOptions *SequentialImageGenerationOptions `json:"sequential_image_generation_options,omitempty"`
+}
+
+type SequentialImageGenerationOptions struct {
+	MaxImages int `json:"max_images"`
+}
+
+type ImageResponse struct {
+	Model   string      `json:"model"`
+	Created int64       `json:"created"`
+	Data    []ImageData `json:"data"`
+	Usage   *ImageUsage `json:"usage,omitempty"`
+	types.OpenAIErrorResponse
+}
+
+// ImageData 组图中单张图片生成失败时 error 不为空
+type ImageData struct {
+	URL     string             `json:"url,omitempty"`
+	B64JSON string             `json:"b64_json,omitempty"`
+	Size    string             `json:"size,omitempty"`
+	Error   *types.OpenAIError `json:"error,omitempty"`
+}
+
+type ImageUsage struct {
+	GeneratedImages int `json:"generated_images"`
+	OutputTokens    int `json:"output_tokens"`
+	TotalTokens     int `json:"total_tokens"`
+}
@@ -0,0 +1,127 @@
+package volcengine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"czloapi/common/config"
+	"czloapi/common/requester"
+	"czloapi/model"
+	"czloapi/providers/base"
+	"czloapi/providers/openai"
+	"czloapi/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func newTestProvider() *VolcengineProvider {
+	plugin := datatypes.NewJSONType(model.PluginType{
+		"batch": {"models": "ep-bi-001, ep-bi-002"},
+	})
+	provider := &VolcengineProvider{
+		OpenAIProvider: openai.OpenAIProvider{
+			BaseProvider: base.BaseProvider{
+				Config:  getConfig(),
+				Channel: &model.Channel{Plugin: &plugin},
+			},
+		},
+	}
+	provider.loadPlugin()
+	return provider
+}
+
+func TestConvertThinking(t *testing.T) {
+	enabled := true
+	request := &types.ChatCompletionRequest{EnableThinking: &enabled}
+	convertThinking(request)
+	data, _ := json.Marshal(request)
+	assert.Contains(t, string(data), `"thinking":{"type":"enabled"}`)
+	assert.Nil(t, request.EnableThinking)
+
+	var thinking interface{} = "auto"
+	request = &types.ChatCompletionRequest{Thinking: &thinking}
+	convertThinking(request)
+	assert.Equal(t, map[string]string{"type": "auto"}, *request.Thinking)
+
+	// 已经是对象格式时原样传递
+	thinking = map[string]any{"type": "disabled"}
+	request = &types.ChatCompletionRequest{Thinking: &thinking}
+	convertThinking(request)
+	assert.Equal(t, map[string]any{"type": "disabled"}, *request.Thinking)
+}
+
+func TestRequestHandlerEndpoint(t *testing.T) {
+	provider := newTestProvider()
+	assert.Nil(t, provider.requestHandler(&types.ChatCompletionRequest{Model: "doubao-seed-1-6-250615"}))
+	assert.Equal(t, "/api/v3/chat/completions", provider.Config.ChatCompletions)
+
+	provider = newTestProvider()
+	assert.Nil(t, provider.requestHandler(&types.ChatCompletionRequest{Model: "ep-bi-002"}))
+	assert.Equal(t, batchChatCompletions, provider.Config.ChatCompletions)
+
+	provider = newTestProvider()
+	errWithCode := provider.requestHandler(&types.ChatCompletionRequest{Model: "ep-bi-001", Stream: true})
+	assert.NotNil(t, errWithCode)
+
+	provider = newTestProvider()
+	provider.Context = newTestContext(`{"model":"ep-bi-001","context_id":"ctx-123"}`)
+	assert.Nil(t, provider.requestHandler(&types.ChatCompletionRequest{Model: "ep-bi-001"}))
+	assert.Equal(t, contextChatCompletions, provider.Config.ChatCompletions)
+}
+
+func TestContextIDOnlySentToVolcengine(t *testing.T) {
+	provider := newTestProvider()
+	provider.Requester = requester.NewHTTPRequester("", openai.RequestErrorHandle)
+	provider.Context = newTestContext(`{"model":"doubao-seed-1-6-250615","context_id":"ctx-123"}`)
+
+	request := &types.ChatCompletionRequest{Model: "doubao-seed-1-6-250615"}
+	assert.Nil(t, provider.requestHandler(request))
+	req, errWithCode := provider.GetRequestTextBody(config.RelayModeChatCompletions, request.Model, request)
+	assert.Nil(t, errWithCode)
+
+	var body map[string]any
+	assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+	assert.Equal(t, "ctx-123", body["context_id"])
+
+	// 通用请求不带 context_id，其他 OpenAI 兼容上游不会收到
+	raw, err := json.Marshal(request)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(raw), "context_id")
+}
+
+func newTestContext(body string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	c.Set(config.GinRequestBodyKey, []byte(body))
+	return c
+}
+
+func TestConvertMultimodalInput(t *testing.T) {
+	input, multimodal := convertMultimodalInput(&types.EmbeddingRequest{Model: "doubao-embedding-text-240715", Input: []any{"a", "b"}})
+	assert.False(t, multimodal)
+
+	input, multimodal = convertMultimodalInput(&types.EmbeddingRequest{Model: "doubao-embedding-vision-250615", Input: "hello"})
+	assert.True(t, multimodal)
+	assert.Equal(t, []any{map[string]any{"type": "text", "text": "hello"}}, input)
+
+	image := map[string]any{"type": "image_url", "image_url": map[string]any{"url": "https://example.com/a.png"}}
+	input, multimodal = convertMultimodalInput(&types.EmbeddingRequest{Model: "custom-ep", Input: []any{"cat", image}})
+	assert.True(t, multimodal)
+	assert.Equal(t, image, input[1])
+}
+
+func TestUsageHandlerCachedTokens(t *testing.T) {
+	var usage types.Usage
+	err := json.Unmarshal([]byte(`{"prompt_tokens":120,"completion_tokens":30,"prompt_tokens_details":{"cached_tokens":100},"completion_tokens_details":{"reasoning_tokens":20}}`), &usage)
+	assert.NoError(t, err)
+
+	usageHandler(&usage)
+	assert.Equal(t, 150, usage.TotalTokens)
+	assert.Equal(t, 100, usage.PromptTokensDetails.CachedTokens)
+	assert.Equal(t, 20, usage.CompletionTokensDetails.ReasoningTokens)
+}
@@ -0,0 +1,79 @@
+package voyage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"czloapi/common/requester"
+	"czloapi/model"
+	"czloapi/providers/base"
+	"czloapi/types"
+)
+
+type VoyageProviderFactory struct{}
+
+// 创建 VoyageProvider
+// https://docs.voyageai.com/reference/embeddings-api
+func (f VoyageProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
+	return &VoyageProvider{
+		BaseProvider: base.BaseProvider{
+			Config:    getConfig(),
+			Channel:   channel,
+			Requester: requester.NewHTTPRequester(*channel.Proxy, requestErrorHandle),
+		},
+	}
+}
+
+type VoyageProvider struct {
+	base.BaseProvider
+}
+
+func getConfig() base.ProviderConfig {
+	return base.ProviderConfig{
+		BaseURL:    "https://api.voyageai.com",
+		Embeddings: "/v1/embeddings",
+		Rerank:     "/v1/rerank",
+	}
+}
+
+// 请求错误处理
+func requestErrorHandle(resp *http.Response) *types.OpenAIError {
+	voyageError := &VoyageError{}
+	err := json.NewDecoder(resp.Body).Decode(voyageError)
+	if err != nil {
+		return nil
+	}
+
+	return errorHandle(voyageError)
+}
+
+// 错误处理
+func errorHandle(voyageError *VoyageError) *types.OpenAIError {
+	if voyageError.Detail == nil {
+		return nil
+	}
+
+	message, ok := voyageError.Detail.(string)
+	if !ok {
+		detail, _ := json.Marshal(voyageError.Detail)
+		message = string(detail)
+	}
+	if message == "" {
+		return nil
+	}
+
+	return &types.OpenAIError{
+		Message: message,
+		Type:    "voyage_error",
+	}
+}
+
+func (p *VoyageProvider) GetRequestHeaders() (headers map[string]string) {
+	headers = make(map[string]string)
+	p.CommonRequestHeaders(headers)
+	headers["Authorization"] = fmt.Sprintf("Bearer %s", p.Channel.Key)
+	headers["Accept"] = "application/json"
+
+	return headers
+}
@@ -0,0 +1,79 @@
+package voyage
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/types"
+)
+
+func (p *VoyageProvider) CreateEmbeddings(request *types.EmbeddingRequest) (*types.EmbeddingResponse, *types.OpenAIErrorWithStatusCode) {
+	url, errWithCode := p.GetSupportedAPIUri(config.RelayModeEmbeddings)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	fullRequestURL := p.GetFullRequestURL(url, request.Model)
+	headers := p.GetRequestHeaders()
+
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(convertEmbeddingRequest(request, p.getEmbeddingOptions())), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	defer req.Body.Close()
+
+	voyageResponse := &EmbeddingResponse{}
+	_, errWithCode = p.Requester.SendRequest(req, voyageResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	response := &types.EmbeddingResponse{
+		Object: "list",
+		Model:  request.Model,
+		Data:   voyageResponse.Data,
+	}
+	if voyageResponse.Usage != nil {
+		*p.Usage = *voyageResponse.Usage.ToOpenAIUsage()
+	} else {
+		p.Usage.TotalTokens = p.Usage.PromptTokens
+	}
+	response.Usage = p.Usage
+
+	return response, nil
+}
+
+// getEmbeddingOptions 从原始请求中读取 input_type、truncation 等扩展参数
+func (p *VoyageProvider) getEmbeddingOptions() *EmbeddingOptions {
+	options := &EmbeddingOptions{}
+	if p.Context == nil {
+		return options
+	}
+	if rawBody, ok := p.GetRawBody(); ok {
+		_ = json.Unmarshal(rawBody, options)
+	}
+	return options
+}
+
+// convertEmbeddingRequest dimensions 作为 output_dimension 的别名，encoding_format 只支持 base64
+// https://docs.voyageai.com/reference/embeddings-api
+func convertEmbeddingRequest(request *types.EmbeddingRequest, options *EmbeddingOptions) *EmbeddingRequest {
+	voyageRequest := &EmbeddingRequest{
+		Model:           request.Model,
+		Input:           request.Input,
+		InputType:       options.InputType,
+		Truncation:      options.Truncation,
+		OutputDimension: options.OutputDimension,
+	}
+
+	if voyageRequest.OutputDimension == 0 {
+		voyageRequest.OutputDimension = request.Dimensions
+	}
+
+	if request.EncodingFormat == "base64" {
+		voyageRequest.EncodingFormat = request.EncodingFormat
+	}
+
+	return voyageRequest
+}
@@ -0,0 +1,68 @@
+package voyage
+
+import (
+	"net/http"
+
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/types"
+)
+
+// CreateRerank Voyage 使用 top_k 表示返回的结果数量，文档只支持字符串
+func (p *VoyageProvider) CreateRerank(request *types.RerankRequest) (*types.RerankResponse, *types.OpenAIErrorWithStatusCode) {
+	documents, err := request.GetDocumentsList()
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "invalid_documents", http.StatusBadRequest)
+	}
+
+	url, errWithCode := p.GetSupportedAPIUri(config.RelayModeRerank)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	fullRequestURL := p.GetFullRequestURL(url, request.Model)
+	headers := p.GetRequestHeaders()
+
+	rerankRequest := &RerankRequest{
+		Model:           request.Model,
+		Query:           request.Query,
+		Documents:       documents,
+		TopK:            request.TopN,
+		ReturnDocuments: request.ReturnDocuments == nil || *request.ReturnDocuments,
+		Truncation:      request.Truncation,
+	}
+
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(rerankRequest), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+	defer req.Body.Close()
+
+	voyageResponse := &RerankResponse{}
+	_, errWithCode = p.Requester.SendRequest(req, voyageResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	response := &types.RerankResponse{
+		Model:   request.Model,
+		Results: make([]types.RerankResult, 0, len(voyageResponse.Data)),
+	}
+	for _, result := range voyageResponse.Data {
+		response.Results = append(response.Results, types.RerankResult{
+			Index:          result.Index,
+			RelevanceScore: result.RelevanceScore,
+			Document: types.RerankResultDocument{
+				Text: result.Document,
+			},
+		})
+	}
+
+	if voyageResponse.Usage != nil {
+		*p.Usage = *voyageResponse.Usage.ToOpenAIUsage()
+	} else {
+		p.Usage.TotalTokens = p.Usage.PromptTokens
+	}
+	response.Usage = p.Usage
+
+	return response, nil
+}
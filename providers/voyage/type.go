@@ -0,0 +1,63 @@
+package voyage
+
+import "czloapi/types"
+
+type VoyageError struct {
+	Detail any `json:"detail"`
+}
+
+type EmbeddingRequest struct {
+	Model           string `json:"model"`
+	Input           any    `json:"input"`
+	InputType       string `json:"input_type,omitempty"`
+	Truncation      *bool  `json:"truncation,omitempty"`
+	OutputDimension int    `json:"output_dimension,omitempty"`
+	EncodingFormat  string `json:"encoding_format,omitempty"`
+}
+
+// EmbeddingOptions 从原始请求中读取的扩展参数，不放在通用请求中以免转发给 OpenAI 兼容的上游
+type EmbeddingOptions struct {
+	InputType       string `json:"input_type"`
+	Truncation      *bool  `json:"truncation"`
+	OutputDimension int    `json:"output_dimension"`
+}
+
+type EmbeddingResponse struct {
+	Model string            `json:"model"`
+	Data  []types.Embedding `json:"data"`
+	Usage *Usage            `json:"usage,omitempty"`
+}
+
+type RerankRequest struct {
+	Model           string   `json:"model"`
+	Query           string   `json:"query"`
+	Documents       []string `json:"documents"`
+	TopK            int      `json:"top_k,omitempty"`
+	ReturnDocuments bool     `json:"return_documents"`
+	Truncation      *bool    `json:"truncation,omitempty"`
+}
+
+// RerankResponse 结果在 data 中
+type RerankResponse struct {
+	Model string         `json:"model"`
+	Data  []RerankResult `json:"data"`
+	Usage *Usage         `json:"usage,omitempty"`
+}
+
+type RerankResult struct {
+	Index          int     `json:"index"`
+	RelevanceScore float64 `json:"relevance_score"`
+	Document       string  `json:"document,omitempty"`
+}
+
+type Usage struct {
+	TotalTokens int `json:"total_tokens"`
+}
+
+// ToOpenAIUsage Voyage 只返回输入 token 数
+func (u *Usage) ToOpenAIUsage() *types.Usage {
+	return &types.Usage{
+		PromptTokens: u.TotalTokens,
+		TotalTokens:  u.TotalTokens,
+	}
+}
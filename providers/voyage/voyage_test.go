@@ -0,0 +1,38 @@
+package voyage
+
+import (
+	"testing"
+
+	"czloapi/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertEmbeddingRequest(t *testing.T) {
+	voyageRequest := convertEmbeddingRequest(&types.EmbeddingRequest{
+		Model:          "voyage-3.5",
+		Input:          "hello",
+		Dimensions:     512,
+		EncodingFormat: "float",
+	}, &EmbeddingOptions{InputType: "document"})
+	assert.Equal(t, "document", voyageRequest.InputType)
+	assert.Equal(t, 512, voyageRequest.OutputDimension)
+	// Voyage 的 encoding_format 只接受 base64
+	assert.Empty(t, voyageRequest.EncodingFormat)
+	assert.Nil(t, voyageRequest.Truncation)
+
+	voyageRequest = convertEmbeddingRequest(&types.EmbeddingRequest{
+		Model:          "voyage-3.5",
+		Input:          "hello",
+		Dimensions:     512,
+		EncodingFormat: "base64",
+	}, &EmbeddingOptions{OutputDimension: 1024})
+	assert.Equal(t, 1024, voyageRequest.OutputDimension)
+	assert.Equal(t, "base64", voyageRequest.EncodingFormat)
+}
+
+func TestUsage(t *testing.T) {
+	usage := (&Usage{TotalTokens: 42}).ToOpenAIUsage()
+	assert.Equal(t, 42, usage.PromptTokens)
+	assert.Equal(t, 42, usage.TotalTokens)
+}
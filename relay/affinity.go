@@ -0,0 +1,200 @@
+package relay
+
+import (
+	"crypto/sha256"
+	"czloapi/common/cache"
+	"czloapi/common/config"
+	"czloapi/common/logger"
+	"czloapi/model"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	affinityCacheKey    = "channel_affinity:%s:%s:%s"
+	affinityDefaultTTL  = time.Hour
+	affinityPromptCount = 2 // 参与哈希的前几条消息
+)
+
+// affinityRequest 从不同格式的请求体中提取会话标识所需的字段
+type affinityRequest struct {
+	PromptCacheKey string `json:"prompt_cache_key"`
+	User           string `json:"user"`
+	Metadata       struct {
+		UserId string `json:"user_id"`
+	} `json:"metadata"`
+
+	System            json.RawMessage   `json:"system"`
+	Instructions      json.RawMessage   `json:"instructions"`
+	SystemInstruction json.RawMessage   `json:"systemInstruction"`
+	Messages          []json.RawMessage `json:"messages"`
+	Contents          []json.RawMessage `json:"contents"`
+	Input             json.RawMessage   `json:"input"`
+}
+
+func getAffinitySetting(c *gin.Context) *model.AffinitySetting {
+	setting, exists := c.Get("key_setting")
+	if !exists {
+		return nil
+	}
+
+	keySetting, ok := setting.(*model.KeySetting)
+	if !ok || keySetting == nil || !keySetting.Affinity.Enabled {
+		return nil
+	}
+
+	return &keySetting.Affinity
+}
+
+// affinityIdentity 根据粘性模式获取会话标识，无法获取时返回空字符串
+func affinityIdentity(c *gin.Context, mode string) string {
+	switch mode {
+	case model.AffinityModeUser:
+		if userId := c.GetInt("id"); userId > 0 {
+			return "user:" + strconv.Itoa(userId)
+		}
+	case model.AffinityModeKey:
+		if keyId := c.GetInt("key_id"); keyId > 0 {
+			return "key:" + strconv.Itoa(keyId)
+		}
+	case model.AffinityModeRequest, model.AffinityModePrompt:
+		request := parseAffinityRequest(c)
+		if request == nil {
+			return ""
+		}
+		if mode == model.AffinityModeRequest {
+			return request.sessionId()
+		}
+		return request.promptHash()
+	}
+
+	return ""
+}
+
+func parseAffinityRequest(c *gin.Context) *affinityRequest {
+	rawBody, exists := c.Get(config.GinRequestBodyKey)
+	if !exists {
+		return nil
+	}
+
+	body, ok := rawBody.([]byte)
+	if !ok || len(body) == 0 {
+		return nil
+	}
+
+	var request affinityRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil
+	}
+
+	return &request
+}
+
+func (r *affinityRequest) sessionId() string {
+	switch {
+	case r.PromptCacheKey != "":
+		return "cache:" + r.PromptCacheKey
+	case r.User != "":
+		return "user_field:" + r.User
+	case r.Metadata.UserId != "":
+		return "user_field:" + r.Metadata.UserId
+	}
+
+	return ""
+}
+
+func (r *affinityRequest) promptHash() string {
+	hash := sha256.New()
+	written := false
+	write := func(data json.RawMessage) {
+		if len(data) == 0 || string(data) == "null" {
+			return
+		}
+		hash.Write(data)
+		written = true
+	}
+
+	write(r.System)
+	write(r.Instructions)
+	write(r.SystemInstruction)
+
+	messages := r.Messages
+	if len(messages) == 0 {
+		messages = r.Contents
+	}
+	if len(messages) == 0 && len(r.Input) > 0 && r.Input[0] == '[' {
+		_ = json.Unmarshal(r.Input, &messages)
+	}
+	if len(messages) == 0 {
+		write(r.Input)
+	}
+
+	for i, message := range messages {
+		if i >= affinityPromptCount {
+			break
+		}
+		write(message)
+	}
+
+	if !written {
+		return ""
+	}
+
+	return "prompt:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// affinityKey 获取会话粘性缓存的 key，未启用或无法识别会话时返回空字符串
+func affinityKey(c *gin.Context, group, modelName string) (string, time.Duration) {
+	setting := getAffinitySetting(c)
+	if setting == nil {
+		return "", 0
+	}
+
+	identity := affinityIdentity(c, setting.Mode)
+	if identity == "" {
+		return "", 0
+	}
+
+	ttl := affinityDefaultTTL
+	if setting.TTLSeconds > 0 {
+		ttl = time.Duration(setting.TTLSeconds) * time.Second
+	}
+
+	sum := sha256.Sum256([]byte(identity))
+	return fmt.Sprintf(affinityCacheKey, group, modelName, hex.EncodeToString(sum[:16])), ttl
+}
+
+// nextChannelWithAffinity 优先使用会话绑定的渠道，不可用时回退到正常的负载均衡并重新绑定
+// 返回的 release 用于释放选择渠道时占用的限制名额，probe 为熔断器半开时占用的探测名额
+func nextChannelWithAffinity(c *gin.Context, group, modelName string, filters []model.ChannelsFilterFunc) (*model.Channel, func(), *model.BreakerProbe, error) {
+	key, ttl := affinityKey(c, group, modelName)
+	// 对冲请求不影响会话绑定
+	if key == "" || c.GetBool(hedgeRequestKey) {
+		return model.ChannelGroup.Next(group, modelName, filters...)
+	}
+
+	if channelId, err := cache.GetCache[int](key); err == nil && channelId > 0 {
+		if channel, release, probe := model.ChannelGroup.Pick(group, modelName, channelId, filters...); channel != nil {
+			// 刷新过期时间
+			cache.SetCache(key, channelId, ttl)
+			return channel, release, probe, nil
+		}
+		logger.LogInfo(c.Request.Context(), fmt.Sprintf("affinity channel #%d unavailable, fallback to balancer", channelId))
+	}
+
+	channel, release, probe, err := model.ChannelGroup.Next(group, modelName, filters...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err := cache.SetCache(key, channel.Id, ttl); err != nil {
+		logger.LogError(c.Request.Context(), "failed to set channel affinity: "+err.Error())
+	}
+
+	return channel, release, probe, nil
+}
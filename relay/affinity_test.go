@@ -0,0 +1,56 @@
+package relay
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"czloapi/common/config"
+	"czloapi/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAffinityTestContext(body string, mode string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	c.Set(config.GinRequestBodyKey, []byte(body))
+	c.Set("id", 7)
+	c.Set("key_id", 9)
+	c.Set("key_setting", &model.KeySetting{
+		Affinity: model.AffinitySetting{Enabled: true, Mode: mode},
+	})
+	return c
+}
+
+func TestAffinityIdentityRequestFields(t *testing.T) {
+	c := newAffinityTestContext(`{"prompt_cache_key":"abc","user":"u1"}`, model.AffinityModeRequest)
+	assert.Equal(t, "cache:abc", affinityIdentity(c, model.AffinityModeRequest))
+
+	c = newAffinityTestContext(`{"metadata":{"user_id":"claude-user"}}`, model.AffinityModeRequest)
+	assert.Equal(t, "user_field:claude-user", affinityIdentity(c, model.AffinityModeRequest))
+
+	c = newAffinityTestContext(`{"messages":[]}`, model.AffinityModeRequest)
+	assert.Equal(t, "", affinityIdentity(c, model.AffinityModeRequest))
+}
+
+func TestAffinityIdentityPromptHashIgnoresLaterMessages(t *testing.T) {
+	first := newAffinityTestContext(`{"messages":[{"role":"system","content":"sys"},{"role":"user","content":"hi"},{"role":"assistant","content":"a"}]}`, model.AffinityModePrompt)
+	second := newAffinityTestContext(`{"messages":[{"role":"system","content":"sys"},{"role":"user","content":"hi"},{"role":"assistant","content":"b"},{"role":"user","content":"more"}]}`, model.AffinityModePrompt)
+	other := newAffinityTestContext(`{"messages":[{"role":"system","content":"other"},{"role":"user","content":"hi"}]}`, model.AffinityModePrompt)
+
+	firstHash := affinityIdentity(first, model.AffinityModePrompt)
+	assert.NotEmpty(t, firstHash)
+	assert.Equal(t, firstHash, affinityIdentity(second, model.AffinityModePrompt))
+	assert.NotEqual(t, firstHash, affinityIdentity(other, model.AffinityModePrompt))
+}
+
+func TestAffinityKeyUsesUserAndKey(t *testing.T) {
+	c := newAffinityTestContext(`{}`, model.AffinityModeUser)
+	assert.Equal(t, "user:7", affinityIdentity(c, model.AffinityModeUser))
+	assert.Equal(t, "key:9", affinityIdentity(c, model.AffinityModeKey))
+
+	key, ttl := affinityKey(c, "default", "gpt-4o")
+	assert.Contains(t, key, "channel_affinity:default:gpt-4o:")
+	assert.Equal(t, affinityDefaultTTL, ttl)
+}
@@ -2,9 +2,11 @@ package relay
 
 import (
 	"czloapi/model"
+	"czloapi/providers"
 	"czloapi/relay/relay_util"
 	"czloapi/types"
 	"encoding/json"
+	"errors"
 	"strings"
 	"time"
 
@@ -21,6 +23,10 @@ type relayBase struct {
 	otherArg       string
 	allowHeartbeat bool
 	heartbeat      *relay_util.Heartbeat
+	// limitRelease 选择渠道时占用的渠道限制名额，由 RelayHandler 取走或在请求结束时释放
+	limitRelease func()
+	// breakerProbe 选择渠道时占用的熔断探测名额，由 RelayHandler 取走回报结果或在请求结束时释放
+	breakerProbe *model.BreakerProbe
 
 	firstResponseTime time.Time
 }
@@ -32,10 +38,16 @@ type RelayBaseInterface interface {
 	setRequest() error
 	getRequest() any
 	setProvider(modelName string) error
+	setChannel(channel *model.Channel) error
+	takeChannelLimit() (release func(), ok bool)
+	takeBreakerProbe() *model.BreakerProbe
+	releaseChannelLimit()
 	getProvider() providersBase.ProviderInterface
 	getOriginalModel() string
+	setOriginalModel(modelName string)
 	getModelName() string
 	getContext() *gin.Context
+	setContext(c *gin.Context)
 	IsStream() bool
 	// HandleError(err *types.OpenAIErrorWithStatusCode)
 	GetFirstResponseTime() time.Time
@@ -58,10 +70,13 @@ func (r *relayBase) getBillingContext(promptTokens int) model.BillingContext {
 }
 
 func (r *relayBase) setProvider(modelName string) error {
-	provider, modelName, fail := GetProvider(r.c, modelName)
+	r.releaseChannelLimit()
+	provider, modelName, release, probe, fail := getProvider(r.c, modelName)
 	if fail != nil {
 		return fail
 	}
+	r.limitRelease = release
+	r.breakerProbe = probe
 	r.provider = provider
 	r.modelName = modelName
 	r.c.Set("channel_type", provider.GetChannel().Type)
@@ -73,6 +88,41 @@ func (r *relayBase) setProvider(modelName string) error {
 	return nil
 }
 
+// setChannel 换用同一渠道的另一份配置（如账号池中的另一个 key）重建 provider，模型映射不变
+func (r *relayBase) setChannel(channel *model.Channel) error {
+	provider := providers.GetProvider(channel, r.c)
+	if provider == nil {
+		return errors.New("channel not found")
+	}
+	provider.SetOriginalModel(r.originalModel)
+	provider.SetOtherArg(r.otherArg)
+	r.provider = provider
+
+	return nil
+}
+
+// takeChannelLimit 取走选择渠道时占用的限制名额，之后由调用方负责释放
+func (r *relayBase) takeChannelLimit() (func(), bool) {
+	release := r.limitRelease
+	r.limitRelease = nil
+	return release, release != nil
+}
+
+// takeBreakerProbe 取走选择渠道时占用的熔断探测名额，同一次选择只有第一次发送持有名额
+func (r *relayBase) takeBreakerProbe() *model.BreakerProbe {
+	probe := r.breakerProbe
+	r.breakerProbe = nil
+	return probe
+}
+
+// releaseChannelLimit 释放尚未取走的限制名额和熔断探测名额
+func (r *relayBase) releaseChannelLimit() {
+	if release, ok := r.takeChannelLimit(); ok {
+		release()
+	}
+	model.ChannelGroup.ReleaseBreakerProbe(r.takeBreakerProbe())
+}
+
 func (r *relayBase) getOtherArg() string {
 	return r.otherArg
 }
@@ -91,6 +141,14 @@ func (r *relayBase) getContext() *gin.Context {
 	return r.c
 }
 
+// setContext 切换请求上下文，provider 一并切换
+func (r *relayBase) setContext(c *gin.Context) {
+	r.c = c
+	if r.provider != nil {
+		r.provider.SetContext(c)
+	}
+}
+
 func (r *relayBase) getProvider() providersBase.ProviderInterface {
 	return r.provider
 }
@@ -125,6 +183,11 @@ func (r *relayBase) GetError(err *types.OpenAIErrorWithStatusCode) (int, any) {
 
 func (r *relayBase) HandleJsonError(err *types.OpenAIErrorWithStatusCode) {
 	statusCode, response := r.GetError(err)
+	// 流在输出内容前失败时已设置了 SSE 头部但尚未发送，改为 JSON 响应
+	if !r.c.Writer.Written() {
+		r.c.Writer.Header().Del("Content-Type")
+		r.c.Writer.Header().Del("Transfer-Encoding")
+	}
 	r.c.JSON(statusCode, response)
 }
 
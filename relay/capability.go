@@ -0,0 +1,60 @@
+package relay
+
+import (
+	"czloapi/common/utils"
+	"czloapi/model"
+	"czloapi/providers/claude"
+	"czloapi/types"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setRequiredCapabilities 记录请求需要的渠道能力，选择渠道时跳过探测结果不支持的渠道
+func setRequiredCapabilities(c *gin.Context, capabilities []string) {
+	if len(capabilities) > 0 {
+		c.Set("required_capabilities", capabilities)
+	}
+}
+
+func getRequiredCapabilities(c *gin.Context) []string {
+	capabilities, _ := utils.GetGinValue[[]string](c, "required_capabilities")
+	if c.GetBool("is_stream") {
+		capabilities = append(slices.Clip(capabilities), model.CapabilityStreaming)
+	}
+	return capabilities
+}
+
+func chatRequiredCapabilities(request *types.ChatCompletionRequest) []string {
+	var capabilities []string
+	if len(request.Tools) > 0 || len(request.Functions) > 0 {
+		capabilities = append(capabilities, model.CapabilityTools)
+	}
+	if request.ResponseFormat != nil && request.ResponseFormat.Type == "json_schema" {
+		capabilities = append(capabilities, model.CapabilityJsonSchema)
+	}
+	if request.Reasoning != nil || request.ReasoningEffort != nil {
+		capabilities = append(capabilities, model.CapabilityReasoning)
+	}
+
+	hasImage := slices.ContainsFunc(request.Messages, func(message types.ChatCompletionMessage) bool {
+		return slices.ContainsFunc(message.ParseContent(), func(part types.ChatMessagePart) bool {
+			return part.Type == types.ContentTypeImageURL
+		})
+	})
+	if hasImage {
+		capabilities = append(capabilities, model.CapabilityVision)
+	}
+	return capabilities
+}
+
+func claudeRequiredCapabilities(request *claude.ClaudeRequest) []string {
+	var capabilities []string
+	if len(request.Tools) > 0 {
+		capabilities = append(capabilities, model.CapabilityTools)
+	}
+	if request.Thinking != nil && request.Thinking.Type == "enabled" {
+		capabilities = append(capabilities, model.CapabilityReasoning)
+	}
+	return capabilities
+}
@@ -54,6 +54,7 @@ func (r *relayChat) setRequest() error {
 
 	r.setOriginalModel(r.chatRequest.Model)
 	setLogReasoningMetadata(r.c, extractChatReasoningMetadata(&r.chatRequest, r.getOtherArg()))
+	setRequiredCapabilities(r.c, chatRequiredCapabilities(&r.chatRequest))
 
 	otherArg := r.getOtherArg()
 
@@ -124,17 +125,17 @@ func (r *relayChat) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
 			return
 		}
 
-		if r.heartbeat != nil {
-			r.heartbeat.Stop()
-		}
-
 		doneStr := func() string {
 			return r.getUsageResponse()
 		}
 
 		var firstResponseTime time.Time
-		firstResponseTime, err = responseStreamClient(r.c, response, doneStr)
+		firstResponseTime, err = responseStreamClient(r.c, response, doneStr, r.heartbeat)
 		r.SetFirstResponseTime(firstResponseTime)
+		if err != nil && firstResponseTime.IsZero() {
+			// 尚未向客户端输出内容，允许切换渠道重试
+			return
+		}
 	} else {
 		var response *types.ChatCompletionResponse
 		response, err = chatProvider.CreateChatCompletion(&r.chatRequest)
@@ -50,17 +50,17 @@ func (r *relayChat) compatibleSend(resProvider providersBase.ResponsesInterface)
 			return
 		}
 
-		if r.heartbeat != nil {
-			r.heartbeat.Stop()
-		}
-
 		doneStr := func() string {
 			return r.getUsageResponse()
 		}
 
 		var firstResponseTime time.Time
-		firstResponseTime, err = responseStreamClient(r.c, response, doneStr)
+		firstResponseTime, err = responseStreamClient(r.c, response, doneStr, r.heartbeat)
 		r.SetFirstResponseTime(firstResponseTime)
+		if err != nil && firstResponseTime.IsZero() {
+			// 尚未向客户端输出内容，允许切换渠道重试
+			return
+		}
 	} else {
 		var response *types.OpenAIResponsesResponses
 		response, err = resProvider.CreateResponses(resRequest)
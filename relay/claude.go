@@ -37,6 +37,7 @@ func (r *relayClaudeMessages) setRequest() error {
 	}
 	r.setOriginalModel(r.claudeRequest.Model)
 	setLogReasoningMetadata(r.c, extractClaudeReasoningMetadata(r.claudeRequest))
+	setRequiredCapabilities(r.c, claudeRequiredCapabilities(r.claudeRequest))
 	return nil
 }
 
@@ -104,14 +105,10 @@ func (r *relayClaudeMessages) sendClaudeDirect(chatProvider claude.ClaudeChatInt
 			return
 		}
 
-		if r.heartbeat != nil {
-			r.heartbeat.Stop()
-		}
-
 		doneStr := func() string {
 			return ""
 		}
-		firstResponseTime, streamErr := responseGeneralStreamClient(r.c, response, doneStr)
+		firstResponseTime, streamErr := responseGeneralStreamClient(r.c, response, doneStr, r.heartbeat)
 		r.SetFirstResponseTime(firstResponseTime)
 		if streamErr != nil {
 			err = streamErr
@@ -172,12 +169,8 @@ func (r *relayClaudeMessages) sendOpenAICompatible(chatProvider providersBase.Ch
 			return streamErr, false
 		}
 
-		if r.heartbeat != nil {
-			r.heartbeat.Stop()
-		}
-
 		claudeStream := newOpenAIToClaudeStreamWrapper(response, r.provider.GetUsage(), chatRequest.Model, true)
-		firstResponseTime, streamErr := responseGeneralStreamClient(r.c, claudeStream, nil)
+		firstResponseTime, streamErr := responseGeneralStreamClient(r.c, claudeStream, nil, r.heartbeat)
 		r.SetFirstResponseTime(firstResponseTime)
 		if streamErr != nil {
 			return streamErr, false
@@ -230,12 +223,8 @@ func (r *relayClaudeMessages) sendResponsesCompatible(responsesProvider provider
 			return streamErr, false
 		}
 
-		if r.heartbeat != nil {
-			r.heartbeat.Stop()
-		}
-
 		claudeStream := newOpenAIToClaudeStreamWrapper(response, r.provider.GetUsage(), responsesRequest.Model, false)
-		firstResponseTime, streamErr := responseGeneralStreamClient(r.c, claudeStream, nil)
+		firstResponseTime, streamErr := responseGeneralStreamClient(r.c, claudeStream, nil, r.heartbeat)
 		r.SetFirstResponseTime(firstResponseTime)
 		if streamErr != nil {
 			return streamErr, false
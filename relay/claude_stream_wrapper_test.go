@@ -8,9 +8,11 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	commonlogger "czloapi/common/logger"
 	"czloapi/providers/claude"
+	"czloapi/relay/relay_util"
 	"czloapi/types"
 
 	"github.com/gin-gonic/gin"
@@ -364,11 +366,11 @@ func TestResponseGeneralStreamClientReturnsErrorBeforeFirstChunk(t *testing.T) {
 	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
 
 	expectedErr := errors.New("pre-first-chunk failure")
-	stream := &fakeErrorStringStream{
+	stream := &fakeUpstreamStringStream{
 		err: expectedErr,
 	}
 
-	firstResponseTime, streamErr := responseGeneralStreamClient(c, stream, nil)
+	firstResponseTime, streamErr := responseGeneralStreamClient(c, stream, nil, nil)
 	assert.True(t, firstResponseTime.IsZero())
 	require.NotNil(t, streamErr)
 	assert.Contains(t, streamErr.Error(), "pre-first-chunk failure")
@@ -384,11 +386,118 @@ func TestResponseGeneralStreamClientSuppressesRetryAfterFirstChunk(t *testing.T)
 		err:  errors.New("mid-stream failure"),
 	}
 
-	firstResponseTime, streamErr := responseGeneralStreamClient(c, stream, nil)
+	firstResponseTime, streamErr := responseGeneralStreamClient(c, stream, nil, nil)
 	assert.False(t, firstResponseTime.IsZero())
 	assert.Nil(t, streamErr)
 }
 
+// fakeUpstreamStringStream 与真实的 stream reader 一样只通过 errChan 结束，不关闭 dataChan
+type fakeUpstreamStringStream struct {
+	data []string
+	err  error
+}
+
+func (f *fakeUpstreamStringStream) Recv() (<-chan string, <-chan error) {
+	dataChan := make(chan string)
+	errChan := make(chan error)
+
+	go func() {
+		for _, item := range f.data {
+			dataChan <- item
+		}
+		errChan <- f.err
+	}()
+
+	return dataChan, errChan
+}
+
+func (f *fakeUpstreamStringStream) Close() {}
+
+func TestResponseStreamClientReturnsErrorBeforeFirstChunk(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	stream := &fakeUpstreamStringStream{
+		err: io.EOF,
+	}
+
+	firstResponseTime, streamErr := responseStreamClient(c, stream, nil, nil)
+	assert.True(t, firstResponseTime.IsZero())
+	require.NotNil(t, streamErr)
+	assert.Contains(t, streamErr.Error(), "before sending any content")
+	assert.False(t, c.Writer.Written())
+	assert.Empty(t, recorder.Body.String())
+}
+
+func TestResponseStreamClientWritesErrorAfterFirstChunk(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	stream := &fakeUpstreamStringStream{
+		data: []string{`{"id":"1"}`},
+		err:  errors.New("mid-stream failure"),
+	}
+
+	firstResponseTime, streamErr := responseStreamClient(c, stream, nil, nil)
+	assert.False(t, firstResponseTime.IsZero())
+	require.NotNil(t, streamErr)
+	assert.Contains(t, recorder.Body.String(), `data: {"id":"1"}`)
+	assert.Contains(t, recorder.Body.String(), "mid-stream failure")
+}
+
+func TestResponseGeneralStreamClientReturnsErrorOnEOFBeforeFirstChunk(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	stream := &fakeUpstreamStringStream{
+		err: io.EOF,
+	}
+
+	firstResponseTime, streamErr := responseGeneralStreamClient(c, stream, nil, nil)
+	assert.True(t, firstResponseTime.IsZero())
+	require.NotNil(t, streamErr)
+	assert.Equal(t, 900, streamErr.StatusCode)
+	assert.Contains(t, streamErr.Error(), "before sending any content")
+	assert.Empty(t, recorder.Body.String())
+}
+
+// 心跳协程与流输出同时设置响应头，需配合 -race 运行
+func TestStreamClientsSetHeadersWithRunningHeartbeat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	clients := map[string]func(*gin.Context, *relay_util.Heartbeat) (time.Time, *types.OpenAIErrorWithStatusCode){
+		"stream": func(c *gin.Context, heartbeat *relay_util.Heartbeat) (time.Time, *types.OpenAIErrorWithStatusCode) {
+			return responseStreamClient(c, &fakeUpstreamStringStream{data: []string{`{"id":"1"}`}, err: io.EOF}, nil, heartbeat)
+		},
+		"general": func(c *gin.Context, heartbeat *relay_util.Heartbeat) (time.Time, *types.OpenAIErrorWithStatusCode) {
+			return responseGeneralStreamClient(c, &fakeUpstreamStringStream{data: []string{"data: {}\n\n"}, err: io.EOF}, nil, heartbeat)
+		},
+	}
+
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+			heartbeat := relay_util.NewHeartbeat(true, relay_util.HeartbeatConfig{TimeoutSeconds: 0}, c)
+			heartbeat.Start()
+			defer heartbeat.Close()
+
+			firstResponseTime, streamErr := client(c, heartbeat)
+			assert.False(t, firstResponseTime.IsZero())
+			assert.Nil(t, streamErr)
+			assert.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+		})
+	}
+}
+
 func unmarshalSSEPayload(raw string, target any) error {
 	lines := strings.Split(raw, "\n")
 	for _, line := range lines {
@@ -12,6 +12,7 @@ import (
 	"czloapi/model"
 	"czloapi/providers"
 	providersBase "czloapi/providers/base"
+	"czloapi/relay/relay_util"
 	"czloapi/types"
 	"encoding/json"
 	"errors"
@@ -47,6 +48,8 @@ func Path2Relay(c *gin.Context, path string) RelayBaseInterface {
 		relay = NewRelayTranscriptions(c)
 	} else if strings.HasPrefix(path, "/v1/audio/translations") {
 		relay = NewRelayTranslations(c)
+	} else if strings.HasPrefix(path, "/v1/ocr") {
+		relay = NewRelayOCR(c)
 	} else if strings.HasPrefix(path, "/v1/messages") || strings.HasPrefix(path, "/claude") {
 		relay = NewRelayClaudeMessages(c)
 	} else if isGeminiRelayPath(path) || strings.HasPrefix(path, "/gemini") {
@@ -107,18 +110,40 @@ func checkLimitModel(c *gin.Context, modelName string) (error error) {
 	return fmt.Errorf("Model %s is not supported for current token", modelName)
 }
 
+// GetProvider 获取渠道对应的 provider，资源接口等不经过 RelayHandler 的请求使用，不占用渠道限制名额和熔断探测名额
 func GetProvider(c *gin.Context, modelName string) (provider providersBase.ProviderInterface, newModelName string, fail error) {
+	provider, newModelName, release, probe, fail := getProvider(c, modelName)
+	release()
+	model.ChannelGroup.ReleaseBreakerProbe(probe)
+	return
+}
+
+// getProvider 选择渠道时会占用渠道限制名额和熔断探测名额 probe，调用方需在请求结束后调用 release 并回报或释放 probe，失败时已释放
+func getProvider(c *gin.Context, modelName string) (provider providersBase.ProviderInterface, newModelName string, release func(), probe *model.BreakerProbe, fail error) {
+	release = func() {}
 	// 检查模型限制
 	if modelName != "" {
 		if err := checkLimitModel(c, modelName); err != nil {
 			c.AbortWithStatus(http.StatusNotFound)
-			return nil, "", err
+			return nil, "", release, nil, err
 		}
 	}
-	channel, fail := fetchChannel(c, modelName)
+	channel, channelRelease, channelProbe, fail := fetchChannel(c, modelName)
 	if fail != nil {
 		return
 	}
+	defer func() {
+		if fail != nil {
+			channelRelease()
+			model.ChannelGroup.ReleaseBreakerProbe(channelProbe)
+		}
+	}()
+	release = channelRelease
+	probe = channelProbe
+	// 启用账号池时选择其中一个 key
+	if key, ok := model.GlobalKeyPool.Pick(channel, nil); ok {
+		channel = channel.WithKey(key)
+	}
 	c.Set("channel_id", channel.Id)
 	c.Set("channel_type", channel.Type)
 
@@ -171,11 +196,14 @@ func resolveGlobalAlias(modelName string, channel *model.Channel) string {
 	return targets[0]
 }
 
-func fetchChannel(c *gin.Context, modelName string) (channel *model.Channel, fail error) {
+// fetchChannel 返回的 release 用于释放选择渠道时占用的限制名额，probe 为熔断器半开时占用的探测名额
+// 指定渠道时不经过负载均衡，由 RelayHandler 发送前再占用限制名额，不占用探测名额
+func fetchChannel(c *gin.Context, modelName string) (channel *model.Channel, release func(), probe *model.BreakerProbe, fail error) {
 	channelId := c.GetInt("specific_channel_id")
 	ignore := c.GetBool("specific_channel_id_ignore")
 	if channelId > 0 && !ignore {
-		return fetchChannelById(channelId)
+		channel, fail = fetchChannelById(channelId)
+		return channel, func() {}, nil, fail
 	}
 
 	return fetchChannelByModel(c, modelName)
@@ -274,7 +302,7 @@ func (gm *GroupManager) createGroupError(group string, modelName string, channel
 	return fmt.Errorf("当前分组 %s 下对于模型 %s 无可用渠道", group, modelName)
 }
 
-func fetchChannelByModel(c *gin.Context, modelName string) (*model.Channel, error) {
+func fetchChannelByModel(c *gin.Context, modelName string) (*model.Channel, func(), *model.BreakerProbe, error) {
 	skipOnlyChat := c.GetBool("skip_only_chat")
 	isStream := c.GetBool("is_stream")
 
@@ -298,11 +326,29 @@ func fetchChannelByModel(c *gin.Context, modelName string) (*model.Channel, erro
 		filters = append(filters, model.FilterDisabledStream(modelName))
 	}
 
+	if capabilities := getRequiredCapabilities(c); len(capabilities) > 0 {
+		filters = append(filters, model.FilterCapabilities(modelName, capabilities))
+	}
+
 	// 使用统一的分组管理器
 	groupManager := NewGroupManager(c)
-	return groupManager.TryWithGroups(modelName, filters, func(group string) (*model.Channel, error) {
-		return model.ChannelGroup.Next(group, modelName, filters...)
+	release := func() {}
+	var probe *model.BreakerProbe
+	channel, err := groupManager.TryWithGroups(modelName, filters, func(group string) (*model.Channel, error) {
+		channel, groupRelease, groupProbe, err := nextChannelWithAffinity(c, group, modelName, filters)
+		if err == nil {
+			release = groupRelease
+			probe = groupProbe
+		}
+		return channel, err
 	})
+	if err != nil {
+		release()
+		model.ChannelGroup.ReleaseBreakerProbe(probe)
+		return nil, func() {}, nil, err
+	}
+
+	return channel, release, probe, nil
 
 }
 
@@ -413,8 +459,19 @@ func responseJsonToChatStreamClient(c *gin.Context, response *types.ChatCompleti
 
 type StreamEndHandler func() string
 
-func responseStreamClient(c *gin.Context, stream requester.StreamReaderInterface[string], endHandler StreamEndHandler) (firstResponseTime time.Time, errWithOP *types.OpenAIErrorWithStatusCode) {
+// setEventStreamHeaders 设置流式响应头，心跳协程可能同时写 header，有心跳时交给心跳在锁内处理
+func setEventStreamHeaders(c *gin.Context, heartbeat *relay_util.Heartbeat) {
+	if heartbeat != nil {
+		heartbeat.SetEventStreamHeaders()
+		return
+	}
 	requester.SetEventStreamHeaders(c)
+}
+
+// responseStreamClient 输出上游流，heartbeat 不为空时在写入第一条数据前才停止心跳，
+// 输出内容前失败并切换渠道重试期间客户端仍能收到心跳
+func responseStreamClient(c *gin.Context, stream requester.StreamReaderInterface[string], endHandler StreamEndHandler, heartbeat *relay_util.Heartbeat) (firstResponseTime time.Time, errWithOP *types.OpenAIErrorWithStatusCode) {
+	setEventStreamHeaders(c, heartbeat)
 	dataChan, errChan := stream.Recv()
 
 	// 创建一个done channel用于通知处理完成
@@ -440,6 +497,9 @@ func responseStreamClient(c *gin.Context, stream requester.StreamReaderInterface
 				if !isFirstResponse {
 					firstResponseTime = time.Now()
 					isFirstResponse = true
+					if heartbeat != nil {
+						heartbeat.Stop()
+					}
 				}
 
 				// 尝试写入数据，如果客户端断开也继续处理
@@ -453,6 +513,16 @@ func responseStreamClient(c *gin.Context, stream requester.StreamReaderInterface
 				}
 
 			case err := <-errChan:
+				if !isFirstResponse {
+					// 尚未向客户端输出任何内容，返回错误由上层切换渠道重试，客户端看到的仍是同一个流
+					if errors.Is(err, io.EOF) {
+						err = errors.New("upstream stream closed before sending any content")
+					}
+					finalErr = common.StringErrorWrapper(err.Error(), "stream_error", 900)
+					logger.LogError(c.Request.Context(), "Stream err before first response:"+err.Error())
+					return
+				}
+
 				if !errors.Is(err, io.EOF) {
 					// 处理错误情况
 					errMsg := "data: " + err.Error() + "\n\n"
@@ -503,8 +573,9 @@ func responseStreamClient(c *gin.Context, stream requester.StreamReaderInterface
 	return firstResponseTime, finalErr
 }
 
-func responseGeneralStreamClient(c *gin.Context, stream requester.StreamReaderInterface[string], endHandler StreamEndHandler) (firstResponseTime time.Time, errWithOP *types.OpenAIErrorWithStatusCode) {
-	requester.SetEventStreamHeaders(c)
+// responseGeneralStreamClient 原样输出上游流，心跳的处理同 responseStreamClient
+func responseGeneralStreamClient(c *gin.Context, stream requester.StreamReaderInterface[string], endHandler StreamEndHandler, heartbeat *relay_util.Heartbeat) (firstResponseTime time.Time, errWithOP *types.OpenAIErrorWithStatusCode) {
+	setEventStreamHeaders(c, heartbeat)
 	dataChan, errChan := stream.Recv()
 
 	// 创建一个done channel用于通知处理完成
@@ -527,6 +598,9 @@ func responseGeneralStreamClient(c *gin.Context, stream requester.StreamReaderIn
 				if !isFirstResponse {
 					firstResponseTime = time.Now()
 					isFirstResponse = true
+					if heartbeat != nil {
+						heartbeat.Stop()
+					}
 				}
 				// 尝试写入数据，如果客户端断开也继续处理
 				select {
@@ -539,14 +613,20 @@ func responseGeneralStreamClient(c *gin.Context, stream requester.StreamReaderIn
 				}
 
 			case err := <-errChan:
+				if !isFirstResponse {
+					// 尚未向客户端输出任何内容，返回错误由上层切换渠道重试
+					if errors.Is(err, io.EOF) {
+						err = errors.New("upstream stream closed before sending any content")
+					}
+					finalErr = common.StringErrorWrapper(err.Error(), "stream_error", 900)
+					logger.LogError(c.Request.Context(), "Stream err before first response:"+err.Error())
+					return
+				}
+
 				if !errors.Is(err, io.EOF) {
+					// 对已开始输出的流，不再向上返回错误，避免触发整体重试。
+					// 此类错误是否已写入客户端由具体 stream wrapper 决定。
 					logger.LogError(c.Request.Context(), "Stream err:"+err.Error())
-					if !isFirstResponse {
-						finalErr = common.StringErrorWrapper(err.Error(), "stream_error", 900)
-					} else {
-						// 对已开始输出的流，不再向上返回错误，避免触发整体重试。
-						// 此类错误是否已写入客户端由具体 stream wrapper 决定。
-					}
 				} else {
 					// 正常结束，处理endHandler
 					if endHandler != nil {
@@ -628,11 +708,25 @@ func shouldRetry(c *gin.Context, apiErr *types.OpenAIErrorWithStatusCode, channe
 
 	metrics.RecordProvider(c, apiErr.StatusCode)
 
-	if apiErr.LocalError ||
-		(channelId > 0 && !ignore) {
+	if apiErr.LocalError {
+		return false
+	}
+
+	// 匹配到错误策略时由策略决定，原样返回的错误不再经过错误信息处理
+	policy := model.GlobalErrorPolicy.Match(channelType, apiErr)
+	if policy != nil && policy.Action == model.ErrorPolicyActionPassthrough {
+		c.Set(errorPolicyPassthroughKey, true)
 		return false
 	}
 
+	if channelId > 0 && !ignore {
+		return false
+	}
+
+	if policy != nil {
+		return true
+	}
+
 	switch apiErr.StatusCode {
 	case http.StatusTooManyRequests, http.StatusTemporaryRedirect:
 		return true
@@ -667,8 +761,16 @@ func shouldRetryBadRequest(channelType int, apiErr *types.OpenAIErrorWithStatusC
 	}
 }
 
-func processChannelRelayError(ctx context.Context, channelId int, channelName string, err *types.OpenAIErrorWithStatusCode, channelType int) {
-	logger.LogError(ctx, fmt.Sprintf("relay error (channel #%d(%s)): %s", channelId, channelName, err.Message))
+func processChannelRelayError(ctx context.Context, channelId int, channelName string, err *types.OpenAIErrorWithStatusCode, channelType int, policy *model.ErrorPolicy) {
+	if policy != nil {
+		logger.LogError(ctx, fmt.Sprintf("relay error (channel #%d(%s)): %s, matched error policy %s, action %s", channelId, channelName, err.Message, policy.Label(), policy.Action))
+	} else {
+		logger.LogError(ctx, fmt.Sprintf("relay error (channel #%d(%s)): %s", channelId, channelName, err.Message))
+	}
+	// 账号池中有多个 key 时由账号池冷却或移除出错的 key，不禁用整个渠道
+	if channel := model.ChannelGroup.GetChannel(channelId); channel != nil && channel.GetKeyPool() != nil && len(channel.PoolKeys()) > 1 {
+		return
+	}
 	if controller.ShouldDisableChannel(channelType, err) {
 		controller.DisableChannel(channelId, channelName, err.Message, true)
 	}
@@ -683,6 +785,9 @@ func FilterOpenAIErr(c *gin.Context, err *types.OpenAIErrorWithStatusCode) (errW
 	newErr := types.OpenAIErrorWithStatusCode{}
 	if err != nil {
 		newErr = *err
+		if c.GetBool(errorPolicyPassthroughKey) {
+			return newErr
+		}
 	}
 
 	if newErr.StatusCode == http.StatusTooManyRequests {
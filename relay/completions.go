@@ -98,8 +98,12 @@ func (r *relayCompletions) send() (err *types.OpenAIErrorWithStatusCode, done bo
 		}
 
 		var firstResponseTime time.Time
-		firstResponseTime, err = responseStreamClient(r.c, response, doneStr)
+		firstResponseTime, err = responseStreamClient(r.c, response, doneStr, r.heartbeat)
 		r.SetFirstResponseTime(firstResponseTime)
+		if err != nil && firstResponseTime.IsZero() {
+			// 尚未向客户端输出内容，允许切换渠道重试
+			return
+		}
 	} else {
 		var response *types.CompletionResponse
 		response, err = provider.CreateCompletion(&r.request)
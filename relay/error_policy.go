@@ -0,0 +1,33 @@
+package relay
+
+import (
+	"czloapi/common/utils"
+	"czloapi/model"
+	"czloapi/relay/relay_util"
+	"czloapi/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+const errorPolicyPassthroughKey = "error_policy_passthrough"
+
+// handleChannelRelayError 匹配错误策略并记录到日志，按策略冷却渠道，异步处理渠道禁用
+func handleChannelRelayError(c *gin.Context, channel *model.Channel, apiErr *types.OpenAIErrorWithStatusCode) {
+	policy := model.GlobalErrorPolicy.Match(channel.Type, apiErr)
+	if policy != nil {
+		matches, _ := utils.GetGinValue[[]*relay_util.ErrorPolicyMatch](c, relay_util.ErrorPolicyContextKey)
+		c.Set(relay_util.ErrorPolicyContextKey, append(matches, &relay_util.ErrorPolicyMatch{
+			ChannelId:  channel.Id,
+			StatusCode: apiErr.StatusCode,
+			PolicyId:   policy.Id,
+			PolicyName: policy.Name,
+			Action:     policy.Action,
+		}))
+
+		if policy.Action == model.ErrorPolicyActionCooldown {
+			model.ChannelGroup.SetCooldownsFor(channel.Id, c.GetString("new_model"), policy.CooldownSeconds)
+		}
+	}
+
+	go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, apiErr, channel.Type, policy)
+}
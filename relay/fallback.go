@@ -0,0 +1,105 @@
+package relay
+
+import (
+	"czloapi/common/logger"
+	"czloapi/common/utils"
+	"czloapi/model"
+	"czloapi/relay/relay_util"
+	"czloapi/types"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// servedModelHeader 发生模型回退时告知客户端实际使用的模型
+const servedModelHeader = "X-Served-Model"
+
+// getModelFallback 获取模型的回退链，令牌设置优先于全局设置
+func getModelFallback(c *gin.Context, modelName string) *model.ModelFallback {
+	if setting, ok := utils.GetGinValue[*model.KeySetting](c, "key_setting"); ok && setting != nil {
+		if fallback, ok := setting.ModelFallbacks[modelName]; ok {
+			return &fallback
+		}
+	}
+
+	return model.GlobalModelFallback.Get(modelName)
+}
+
+// fallbackErrorClass 错误对应的回退错误类型，apiErr 为空表示没有可用渠道
+func fallbackErrorClass(apiErr *types.OpenAIErrorWithStatusCode) string {
+	if apiErr == nil {
+		return model.FallbackOnNoChannel
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests:
+		return model.FallbackOnRateLimit
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout, 524:
+		return model.FallbackOnTimeout
+	}
+
+	// 900 为流式传输中的错误
+	if apiErr.StatusCode/100 == 5 || apiErr.StatusCode == 900 {
+		return model.FallbackOnServerError
+	}
+
+	return model.FallbackOnOther
+}
+
+// switchFallbackModel 当前模型的渠道全部失败后，按请求模型的回退链切换到下一个有可用渠道的模型
+// 切换成功时 relay 已选好新模型的渠道
+func switchFallbackModel(c *gin.Context, relay RelayBaseInterface, apiErr *types.OpenAIErrorWithStatusCode) bool {
+	// 指定渠道、本地错误和请求参数错误不回退
+	if c.GetInt("specific_channel_id") > 0 && !c.GetBool("specific_channel_id_ignore") {
+		return false
+	}
+	if apiErr != nil && (apiErr.LocalError || apiErr.StatusCode == http.StatusBadRequest) {
+		return false
+	}
+
+	fallbackLog, _ := utils.GetGinValue[*relay_util.ModelFallbackLog](c, relay_util.ModelFallbackContextKey)
+	requestedModel := relay.getOriginalModel()
+	if fallbackLog != nil {
+		requestedModel = fallbackLog.RequestedModel
+	}
+
+	fallback := getModelFallback(c, requestedModel)
+	if fallback == nil || !fallback.Allow(fallbackErrorClass(apiErr)) {
+		return false
+	}
+
+	if fallbackLog == nil {
+		fallbackLog = &relay_util.ModelFallbackLog{RequestedModel: requestedModel}
+		c.Set(relay_util.ModelFallbackContextKey, fallbackLog)
+	}
+	fallbackLog.FailedModels = append(fallbackLog.FailedModels, relay.getOriginalModel())
+
+	for _, modelName := range fallback.Models {
+		if modelName == requestedModel || slices.Contains(fallbackLog.FailedModels, modelName) {
+			continue
+		}
+
+		// 令牌不允许使用的模型直接跳过，避免 GetProvider 中断请求
+		if err := checkLimitModel(c, modelName); err != nil {
+			continue
+		}
+
+		// 冷却与跳过的渠道都是针对上一个模型的
+		c.Set("skip_channel_ids", []int{})
+		relay.setOriginalModel(modelName)
+		if err := relay.setProvider(modelName); err != nil {
+			logger.LogError(c.Request.Context(), fmt.Sprintf("fallback model %s unavailable: %s", modelName, err.Error()))
+			fallbackLog.FailedModels = append(fallbackLog.FailedModels, modelName)
+			continue
+		}
+
+		logger.LogInfo(c.Request.Context(), fmt.Sprintf("model %s failed, fallback to %s", requestedModel, modelName))
+		fallbackLog.ServedModel = modelName
+		c.Header(servedModelHeader, modelName)
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,39 @@
+package relay
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"czloapi/common"
+	"czloapi/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallbackErrorClass(t *testing.T) {
+	assert.Equal(t, model.FallbackOnNoChannel, fallbackErrorClass(nil))
+	assert.Equal(t, model.FallbackOnRateLimit, fallbackErrorClass(common.StringErrorWrapper("", "", http.StatusTooManyRequests)))
+	assert.Equal(t, model.FallbackOnTimeout, fallbackErrorClass(common.StringErrorWrapper("", "", http.StatusGatewayTimeout)))
+	assert.Equal(t, model.FallbackOnServerError, fallbackErrorClass(common.StringErrorWrapper("", "", http.StatusBadGateway)))
+	assert.Equal(t, model.FallbackOnServerError, fallbackErrorClass(common.StringErrorWrapper("", "stream_error", 900)))
+	assert.Equal(t, model.FallbackOnOther, fallbackErrorClass(common.StringErrorWrapper("", "", http.StatusUnauthorized)))
+}
+
+func TestGetModelFallbackKeyOverridesGlobal(t *testing.T) {
+	require.NoError(t, model.GlobalModelFallback.Load(`{"claude-sonnet":{"models":["gpt-4.1"]},"gpt-4.1":{"models":["gemini-2.5-pro"]}}`))
+	defer model.GlobalModelFallback.Load("")
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("key_setting", &model.KeySetting{
+		ModelFallbacks: map[string]model.ModelFallback{
+			"claude-sonnet": {Models: []string{"claude-haiku"}},
+		},
+	})
+
+	assert.Equal(t, []string{"claude-haiku"}, getModelFallback(c, "claude-sonnet").Models)
+	assert.Equal(t, []string{"gemini-2.5-pro"}, getModelFallback(c, "gpt-4.1").Models)
+	assert.Nil(t, getModelFallback(c, "unknown"))
+}
@@ -115,14 +115,10 @@ func (r *relayGeminiOnly) send() (err *types.OpenAIErrorWithStatusCode, done boo
 			return
 		}
 
-		if r.heartbeat != nil {
-			r.heartbeat.Stop()
-		}
-
 		doneStr := func() string {
 			return ""
 		}
-		firstResponseTime, streamErr := responseGeneralStreamClient(r.c, response, doneStr)
+		firstResponseTime, streamErr := responseGeneralStreamClient(r.c, response, doneStr, r.heartbeat)
 		r.SetFirstResponseTime(firstResponseTime)
 		if streamErr != nil {
 			err = streamErr
@@ -0,0 +1,424 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"czloapi/common"
+	"czloapi/common/config"
+	"czloapi/common/logger"
+	"czloapi/common/utils"
+	"czloapi/model"
+	"czloapi/relay/relay_util"
+	"czloapi/types"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	hedgeAttemptKey     = "hedge_attempt"
+	hedgeRequestKey     = "is_hedge_request"
+	hedgingDefaultDelay = 2 * time.Second
+)
+
+const (
+	hedgeStatePending int32 = iota
+	hedgeStateWon
+	hedgeStateLost
+)
+
+// hedgeSyncKeys 对冲结束后需要从胜者同步回原始上下文的字段
+var hedgeSyncKeys = []string{"channel_id", "channel_type", "original_model", "new_model", "billing_original_model"}
+
+// hedgeRace 一次对冲请求，多个尝试分别在复制的上下文中执行
+// 最先向客户端写入数据（或成功完成）的尝试成为胜者，其余尝试落选
+type hedgeRace struct {
+	sync.Mutex
+	writer     gin.ResponseWriter // 客户端的 writer
+	chargeBoth bool
+	heartbeat  *relay_util.Heartbeat
+	log        *relay_util.HedgeLog
+	winner     *hedgeAttempt
+	attempts   []*hedgeAttempt
+}
+
+type hedgeAttempt struct {
+	race    *hedgeRace
+	index   int
+	relay   RelayBaseInterface
+	channel *model.Channel
+	c       *gin.Context
+	cancel  context.CancelFunc
+	writer  *hedgeWriter
+	state   atomic.Int32
+}
+
+type hedgeResult struct {
+	attempt *hedgeAttempt
+	err     *types.OpenAIErrorWithStatusCode
+	done    bool
+}
+
+func getHedgingSetting(c *gin.Context) *model.HedgingSetting {
+	// 指定了渠道时没有其他渠道可用于对冲
+	if c.GetInt("specific_channel_id") > 0 && !c.GetBool("specific_channel_id_ignore") {
+		return nil
+	}
+
+	setting, exists := c.Get("key_setting")
+	if !exists {
+		return nil
+	}
+
+	keySetting, ok := setting.(*model.KeySetting)
+	if !ok || keySetting == nil || !keySetting.Hedging.Enabled {
+		return nil
+	}
+
+	return &keySetting.Hedging
+}
+
+func getHedgeAttempt(c *gin.Context) *hedgeAttempt {
+	attempt, ok := utils.GetGinValue[*hedgeAttempt](c, hedgeAttemptKey)
+	if !ok {
+		return nil
+	}
+	return attempt
+}
+
+// hedgeBillable 对冲请求中只有胜者计费，配置了 ChargeBoth 时落选者也计费
+func hedgeBillable(c *gin.Context) bool {
+	attempt := getHedgeAttempt(c)
+	if attempt == nil {
+		return true
+	}
+
+	return attempt.claim() || attempt.race.chargeBoth
+}
+
+// hedgeCancelled 落选后被取消的尝试，其错误与渠道无关
+func hedgeCancelled(c *gin.Context) bool {
+	attempt := getHedgeAttempt(c)
+	if attempt == nil {
+		return false
+	}
+
+	return attempt.state.Load() == hedgeStateLost && !attempt.race.chargeBoth
+}
+
+// newHedgeContext 复制请求上下文，使用可单独取消的 context 和可重新读取的请求体
+func newHedgeContext(c *gin.Context) (*gin.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	hedgeCtx := c.Copy()
+	hedgeCtx.Request = c.Request.WithContext(ctx)
+
+	if body, ok := utils.GetGinValue[[]byte](c, config.GinRequestBodyKey); ok {
+		hedgeCtx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	return hedgeCtx, cancel
+}
+
+// relayWithHedging 发送请求，超过延迟仍未返回首字节时向另一个渠道发送相同的请求
+// 返回最终采用的 relay，其上下文已切换回原始上下文
+func relayWithHedging(c *gin.Context, relay RelayBaseInterface, setting *model.HedgingSetting, heartbeat *relay_util.Heartbeat) (RelayBaseInterface, *types.OpenAIErrorWithStatusCode, bool) {
+	delay := hedgingDefaultDelay
+	if setting.DelayMs > 0 {
+		delay = time.Duration(setting.DelayMs) * time.Millisecond
+	}
+
+	race := &hedgeRace{
+		writer:     c.Writer,
+		chargeBoth: setting.ChargeBoth,
+		heartbeat:  heartbeat,
+		log:        relay_util.NewHedgeLog(delay, setting.ChargeBoth),
+	}
+	results := make(chan *hedgeResult, 2)
+
+	primaryCtx, cancel := newHedgeContext(c)
+	relay.setContext(primaryCtx)
+	primary := race.add(relay, primaryCtx, cancel)
+	go primary.run(results)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var primaryResult *hedgeResult
+	for running := 1; running > 0; {
+		select {
+		case <-timer.C:
+			if hedge := race.startHedge(c, relay); hedge != nil {
+				logger.LogInfo(c.Request.Context(), fmt.Sprintf("channel #%d no response in %dms, hedging to channel #%d", primary.channel.Id, delay.Milliseconds(), hedge.channel.Id))
+				running++
+				go hedge.run(results)
+			}
+		case result := <-results:
+			running--
+			attempt := result.attempt
+			if attempt.state.Load() == hedgeStateWon {
+				return race.finish(c, result)
+			}
+			if result.err == nil || attempt.state.Load() == hedgeStateLost {
+				continue
+			}
+
+			race.log.SetStatus(attempt.index, relay_util.HedgeStatusFailed, result.err.Message)
+			if attempt == primary {
+				primaryResult = result
+				continue
+			}
+			// 对冲请求失败，后续重试跳过该渠道
+			handleChannelRelayError(c, attempt.channel, result.err)
+			shouldCooldowns(attempt.c, attempt.channel, result.err)
+			addSkipChannel(c, attempt.channel.Id)
+		}
+	}
+
+	return race.finish(c, primaryResult)
+}
+
+// add 添加一次尝试，已产生胜者时返回 nil
+func (r *hedgeRace) add(relay RelayBaseInterface, c *gin.Context, cancel context.CancelFunc) *hedgeAttempt {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.winner != nil {
+		cancel()
+		return nil
+	}
+
+	attempt := &hedgeAttempt{
+		race:    r,
+		relay:   relay,
+		channel: relay.getProvider().GetChannel(),
+		c:       c,
+		cancel:  cancel,
+	}
+	attempt.index = r.log.AddAttempt(attempt.channel.Id)
+	attempt.writer = &hedgeWriter{ResponseWriter: r.writer, attempt: attempt, header: make(http.Header)}
+	c.Writer = attempt.writer
+	c.Set(hedgeAttemptKey, attempt)
+	c.Set(relay_util.HedgeLogContextKey, r.log)
+
+	// 上游请求默认不跟随客户端取消，落选时需要能够中断
+	if requester := relay.getProvider().GetRequester(); requester != nil {
+		requester.Context = c.Request.Context()
+	}
+
+	r.attempts = append(r.attempts, attempt)
+	return attempt
+}
+
+// startHedge 选择另一个渠道发送对冲请求，已产生胜者或没有可用渠道时返回 nil
+func (r *hedgeRace) startHedge(c *gin.Context, primary RelayBaseInterface) *hedgeAttempt {
+	if r.claimed() {
+		return nil
+	}
+
+	hedgeCtx, cancel := newHedgeContext(c)
+	skipChannelIds, _ := utils.GetGinValue[[]int](c, "skip_channel_ids")
+	hedgeCtx.Set("skip_channel_ids", append(slices.Clone(skipChannelIds), primary.getProvider().GetChannel().Id))
+	hedgeCtx.Set(hedgeRequestKey, true)
+
+	hedgeRelay := Path2Relay(hedgeCtx, c.Request.URL.Path)
+	if err := hedgeRelay.setRequest(); err != nil {
+		cancel()
+		return nil
+	}
+
+	// 发生模型回退时请求体中的模型不是当前模型
+	hedgeRelay.setOriginalModel(primary.getOriginalModel())
+	if err := hedgeRelay.setProvider(primary.getOriginalModel()); err != nil {
+		logger.LogInfo(c.Request.Context(), "no channel available for hedging: "+err.Error())
+		cancel()
+		return nil
+	}
+
+	attempt := r.add(hedgeRelay, hedgeCtx, cancel)
+	if attempt == nil {
+		hedgeRelay.releaseChannelLimit()
+	}
+
+	return attempt
+}
+
+func (r *hedgeRace) claimed() bool {
+	r.Lock()
+	defer r.Unlock()
+	return r.winner != nil
+}
+
+// claim 尝试成为胜者，将缓存的响应头写入客户端并让其他尝试落选
+func (r *hedgeRace) claim(attempt *hedgeAttempt) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.winner != nil {
+		return r.winner == attempt
+	}
+
+	r.winner = attempt
+	if r.heartbeat != nil {
+		r.heartbeat.Stop()
+	}
+
+	header := r.writer.Header()
+	for key, values := range attempt.writer.header {
+		header[key] = values
+	}
+	if attempt.writer.status != 0 {
+		r.writer.WriteHeader(attempt.writer.status)
+	}
+
+	attempt.state.Store(hedgeStateWon)
+	r.log.SetFirstByte(attempt.index)
+	r.log.SetStatus(attempt.index, relay_util.HedgeStatusWon, "")
+
+	for _, other := range r.attempts {
+		if other != attempt {
+			other.lose(r.chargeBoth)
+		}
+	}
+
+	return true
+}
+
+// finish 将结果对应的 relay 切换回原始上下文
+func (r *hedgeRace) finish(c *gin.Context, result *hedgeResult) (RelayBaseInterface, *types.OpenAIErrorWithStatusCode, bool) {
+	attempt := result.attempt
+	for _, key := range hedgeSyncKeys {
+		if value, ok := attempt.c.Get(key); ok {
+			c.Set(key, value)
+		}
+	}
+	attempt.relay.setContext(c)
+
+	// 已经向客户端写入了数据，不能再重试
+	if result.err != nil && attempt.state.Load() == hedgeStateWon && r.writer.Written() {
+		result.done = true
+	}
+
+	return attempt.relay, result.err, result.done
+}
+
+func (a *hedgeAttempt) run(results chan<- *hedgeResult) {
+	defer func() {
+		if err := recover(); err != nil {
+			logger.LogError(a.c.Request.Context(), fmt.Sprintf("hedge attempt panic: %v", err))
+			results <- &hedgeResult{
+				attempt: a,
+				err:     common.StringErrorWrapperLocal("hedge attempt failed", "system_error", http.StatusInternalServerError),
+				done:    true,
+			}
+		}
+	}()
+
+	err, done := RelayHandler(a.relay)
+	results <- &hedgeResult{attempt: a, err: err, done: done}
+}
+
+func (a *hedgeAttempt) claim() bool {
+	switch a.state.Load() {
+	case hedgeStateWon:
+		return true
+	case hedgeStateLost:
+		return false
+	}
+
+	return a.race.claim(a)
+}
+
+// lose 落选，未配置 chargeBoth 时取消请求，调用方需持有 race 的锁
+func (a *hedgeAttempt) lose(chargeBoth bool) {
+	a.state.Store(hedgeStateLost)
+	if chargeBoth {
+		a.race.log.SetStatus(a.index, relay_util.HedgeStatusDiscarded, "")
+		return
+	}
+
+	a.race.log.SetStatus(a.index, relay_util.HedgeStatusCancelled, "")
+	a.cancel()
+}
+
+// hedgeWriter 产生胜者前缓存响应头，胜者的写入直接转发给客户端，落选者的写入被丢弃
+type hedgeWriter struct {
+	gin.ResponseWriter
+	attempt *hedgeAttempt
+	header  http.Header
+	status  int
+}
+
+func (w *hedgeWriter) won() bool {
+	return w.attempt.state.Load() == hedgeStateWon
+}
+
+func (w *hedgeWriter) Header() http.Header {
+	if w.won() {
+		return w.ResponseWriter.Header()
+	}
+	return w.header
+}
+
+func (w *hedgeWriter) WriteHeader(code int) {
+	if w.won() {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *hedgeWriter) WriteHeaderNow() {
+	if w.won() {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *hedgeWriter) Write(data []byte) (int, error) {
+	if !w.attempt.claim() {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *hedgeWriter) WriteString(s string) (int, error) {
+	if !w.attempt.claim() {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *hedgeWriter) Flush() {
+	if w.won() {
+		w.ResponseWriter.Flush()
+	}
+}
+
+func (w *hedgeWriter) Status() int {
+	if w.won() {
+		return w.ResponseWriter.Status()
+	}
+	if w.status != 0 {
+		return w.status
+	}
+	return http.StatusOK
+}
+
+func (w *hedgeWriter) Size() int {
+	if w.won() {
+		return w.ResponseWriter.Size()
+	}
+	return -1
+}
+
+func (w *hedgeWriter) Written() bool {
+	if w.won() {
+		return w.ResponseWriter.Written()
+	}
+	return false
+}
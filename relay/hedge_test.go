@@ -0,0 +1,98 @@
+package relay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"czloapi/model"
+	"czloapi/relay/relay_util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newHedgeTestRace(chargeBoth bool) (*hedgeRace, *httptest.ResponseRecorder, *gin.Context) {
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+
+	race := &hedgeRace{
+		writer:     c.Writer,
+		chargeBoth: chargeBoth,
+		log:        relay_util.NewHedgeLog(time.Second, chargeBoth),
+	}
+	return race, recorder, c
+}
+
+// addTestAttempt 与 hedgeRace.add 相同，但不需要 provider
+func addTestAttempt(race *hedgeRace, c *gin.Context, channelId int) *hedgeAttempt {
+	attemptCtx, cancel := newHedgeContext(c)
+	attempt := &hedgeAttempt{
+		race:    race,
+		channel: &model.Channel{Id: channelId},
+		c:       attemptCtx,
+		cancel:  cancel,
+	}
+	attempt.index = race.log.AddAttempt(channelId)
+	attempt.writer = &hedgeWriter{ResponseWriter: race.writer, attempt: attempt, header: make(http.Header)}
+	attemptCtx.Writer = attempt.writer
+	attemptCtx.Set(hedgeAttemptKey, attempt)
+	race.attempts = append(race.attempts, attempt)
+	return attempt
+}
+
+func TestHedgeFirstWriterWins(t *testing.T) {
+	race, recorder, c := newHedgeTestRace(false)
+	primary := addTestAttempt(race, c, 1)
+	hedge := addTestAttempt(race, c, 2)
+
+	primary.c.Writer.Header().Set("X-Attempt", "primary")
+	hedge.c.Writer.Header().Set("X-Attempt", "hedge")
+	hedge.c.Writer.WriteHeader(http.StatusCreated)
+	hedge.c.Writer.Write([]byte("hedge"))
+	primary.c.Writer.Write([]byte("primary"))
+
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "hedge", recorder.Header().Get("X-Attempt"))
+	assert.Equal(t, "hedge", recorder.Body.String())
+
+	assert.ErrorIs(t, primary.c.Request.Context().Err(), context.Canceled)
+	assert.NoError(t, hedge.c.Request.Context().Err())
+	assert.False(t, hedgeBillable(primary.c))
+	assert.True(t, hedgeBillable(hedge.c))
+	assert.True(t, hedgeCancelled(primary.c))
+
+	attempts := race.log.Snapshot()["attempts"].([]relay_util.HedgeAttemptLog)
+	assert.Equal(t, relay_util.HedgeStatusCancelled, attempts[0].Status)
+	assert.Equal(t, relay_util.HedgeStatusWon, attempts[1].Status)
+}
+
+func TestHedgeChargeBothKeepsLoserRunning(t *testing.T) {
+	race, recorder, c := newHedgeTestRace(true)
+	primary := addTestAttempt(race, c, 1)
+	hedge := addTestAttempt(race, c, 2)
+
+	primary.c.Writer.Write([]byte("primary"))
+	hedge.c.Writer.Write([]byte("hedge"))
+
+	assert.Equal(t, "primary", recorder.Body.String())
+	assert.NoError(t, hedge.c.Request.Context().Err())
+	assert.True(t, hedgeBillable(hedge.c))
+	assert.False(t, hedgeCancelled(hedge.c))
+
+	attempts := race.log.Snapshot()["attempts"].([]relay_util.HedgeAttemptLog)
+	assert.Equal(t, relay_util.HedgeStatusWon, attempts[0].Status)
+	assert.Equal(t, relay_util.HedgeStatusDiscarded, attempts[1].Status)
+}
+
+func TestHedgeSuccessWithoutOutputClaims(t *testing.T) {
+	race, _, c := newHedgeTestRace(false)
+	primary := addTestAttempt(race, c, 1)
+	hedge := addTestAttempt(race, c, 2)
+
+	assert.True(t, hedgeBillable(primary.c))
+	assert.False(t, hedgeBillable(hedge.c))
+}
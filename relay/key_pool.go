@@ -0,0 +1,39 @@
+package relay
+
+import (
+	"czloapi/common/logger"
+	"czloapi/common/utils"
+	"czloapi/model"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+const poolTriedKeysKey = "pool_tried_keys"
+
+// poolChannel 获取当前渠道启用了账号池的完整配置，未启用时返回 nil
+func poolChannel(relay RelayBaseInterface) *model.Channel {
+	channel := model.ChannelGroup.GetChannel(relay.getProvider().GetChannel().Id)
+	if channel == nil || channel.GetKeyPool() == nil {
+		return nil
+	}
+	return channel
+}
+
+// switchPoolKey 同渠道重试前换用账号池中未尝试过的 key，没有可用 key 时返回 false
+func switchPoolKey(c *gin.Context, relay RelayBaseInterface, channel *model.Channel) bool {
+	triedKeys, _ := utils.GetGinValue[[]string](c, poolTriedKeysKey)
+	triedKeys = append(triedKeys, relay.getProvider().GetChannel().Key)
+	c.Set(poolTriedKeysKey, triedKeys)
+
+	key, ok := model.GlobalKeyPool.Pick(channel, triedKeys)
+	if !ok {
+		return false
+	}
+	if err := relay.setChannel(channel.WithKey(key)); err != nil {
+		return false
+	}
+
+	logger.LogInfo(c.Request.Context(), fmt.Sprintf("channel #%d switch to pool key %s", channel.Id, model.MaskKey(key)))
+	return true
+}
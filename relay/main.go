@@ -31,24 +31,61 @@ func Relay(c *gin.Context) {
 
 	c.Set("is_stream", relay.IsStream())
 	if err := relay.setProvider(relay.getOriginalModel()); err != nil {
-		openaiErr := common.StringErrorWrapperLocal(err.Error(), "one_hub_error", http.StatusServiceUnavailable)
-		relay.HandleJsonError(openaiErr)
-		return
+		// 请求的模型没有可用渠道时尝试回退链中的模型
+		if c.IsAborted() || !switchFallbackModel(c, relay, nil) {
+			openaiErr := common.StringErrorWrapperLocal(err.Error(), "one_hub_error", http.StatusServiceUnavailable)
+			relay.HandleJsonError(openaiErr)
+			return
+		}
 	}
 
+	// 按实际使用的分组和模型将请求镜像到影子渠道
+	finishShadow := startShadowMirror(c, relay)
+	defer finishShadow()
+
 	heartbeat := relay.SetHeartbeat(relay.IsStream())
 	if heartbeat != nil {
 		defer heartbeat.Close()
 	}
 
-	apiErr, done := RelayHandler(relay)
+	// 兜底释放最终 relay 上未被 RelayHandler 取走的限制名额
+	defer func() {
+		relay.releaseChannelLimit()
+	}()
+
+	relay, apiErr, done := relayWithRetry(c, relay, heartbeat)
+	for apiErr != nil && !done && switchFallbackModel(c, relay, apiErr) {
+		relay, apiErr, done = relayWithRetry(c, relay, heartbeat)
+	}
+
+	if apiErr != nil {
+		// 失败的请求不会写入消费日志，单独记录匹配到的错误策略（包括原样返回）
+		relay_util.RecordErrorPolicyLog(c, relay.getOriginalModel(), apiErr)
+		if heartbeat != nil && heartbeat.IsSafeWriteStream() {
+			relay.HandleStreamError(apiErr)
+			return
+		}
+
+		relay.HandleJsonError(apiErr)
+	}
+}
+
+// relayWithRetry 使用已选择的渠道发送请求，失败时按配置在同渠道和其他渠道上重试
+func relayWithRetry(c *gin.Context, relay RelayBaseInterface, heartbeat *relay_util.Heartbeat) (RelayBaseInterface, *types.OpenAIErrorWithStatusCode, bool) {
+	var apiErr *types.OpenAIErrorWithStatusCode
+	var done bool
+	if setting := getHedgingSetting(c); setting != nil {
+		relay, apiErr, done = relayWithHedging(c, relay, setting, heartbeat)
+	} else {
+		apiErr, done = RelayHandler(relay)
+	}
 	if apiErr == nil {
 		metrics.RecordProvider(c, 200)
-		return
+		return relay, nil, false
 	}
 
 	channel := relay.getProvider().GetChannel()
-	go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, apiErr, channel.Type)
+	handleChannelRelayError(c, channel, apiErr)
 
 	retryTimes := config.RetryTimes
 	if done || !shouldRetry(c, apiErr, channel.Type) {
@@ -64,7 +101,7 @@ func Relay(c *gin.Context) {
 		apiErr, done = retrySameChannel(c, relay, channel, apiErr, startTime, timeout)
 		if apiErr == nil {
 			metrics.RecordProvider(c, 200)
-			return
+			return relay, nil, false
 		}
 		if done || !shouldRetry(c, apiErr, channel.Type) {
 			retryTimes = 0
@@ -89,9 +126,9 @@ func Relay(c *gin.Context) {
 		apiErr, done = RelayHandler(relay)
 		if apiErr == nil {
 			metrics.RecordProvider(c, 200)
-			return
+			return relay, nil, false
 		}
-		go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, apiErr, channel.Type)
+		handleChannelRelayError(c, channel, apiErr)
 		if done || !shouldRetry(c, apiErr, channel.Type) {
 			break
 		}
@@ -100,24 +137,38 @@ func Relay(c *gin.Context) {
 		apiErr, done = retrySameChannel(c, relay, channel, apiErr, startTime, timeout)
 		if apiErr == nil {
 			metrics.RecordProvider(c, 200)
-			return
+			return relay, nil, false
 		}
 		if done || !shouldRetry(c, apiErr, channel.Type) {
 			break
 		}
 	}
 
-	if apiErr != nil {
-		if heartbeat != nil && heartbeat.IsSafeWriteStream() {
-			relay.HandleStreamError(apiErr)
-			return
+	return relay, apiErr, done
+}
+
+func RelayHandler(relay RelayBaseInterface) (err *types.OpenAIErrorWithStatusCode, done bool) {
+	channel := relay.getProvider().GetChannel()
+	// 同渠道重试时不再持有探测名额，结果不计入半开状态的探测
+	probe := relay.takeBreakerProbe()
+	sent := false
+	defer func() {
+		// 发送前失败的请求不计入熔断，释放选择渠道时占用的探测名额
+		if !sent {
+			model.ChannelGroup.ReleaseBreakerProbe(probe)
 		}
+	}()
 
-		relay.HandleJsonError(apiErr)
+	// 选择渠道时已占用限制名额；同渠道重试或指定渠道时在这里占用
+	releaseLimit, ok := relay.takeChannelLimit()
+	if !ok {
+		if releaseLimit, ok = model.GlobalChannelLimiter.TryAcquire(channel, relay.getOriginalModel()); !ok {
+			err = common.StringErrorWrapperLocal("当前渠道负载已饱和，请稍后再试", "channel_saturated", http.StatusTooManyRequests)
+			return
+		}
 	}
-}
+	defer releaseLimit()
 
-func RelayHandler(relay RelayBaseInterface) (err *types.OpenAIErrorWithStatusCode, done bool) {
 	promptTokens, tonkeErr := relay.getPromptTokens()
 	if tonkeErr != nil {
 		err = common.ErrorWrapperLocal(tonkeErr, "token_error", http.StatusBadRequest)
@@ -138,13 +189,17 @@ func RelayHandler(relay RelayBaseInterface) (err *types.OpenAIErrorWithStatusCod
 		return
 	}
 
+	sendStartTime := time.Now()
+	sent = true
 	err, done = relay.send()
+	releaseLimit()
+	recordChannelResult(relay, probe, sendStartTime, err)
 	// 最后处理流式中断时计算tokens
 	if usage.CompletionTokens == 0 && usage.TextBuilder.Len() > 0 {
 		usage.CompletionTokens = common.CountTokenText(usage.TextBuilder.String(), relay.getModelName())
 		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
 	}
-	if err != nil {
+	if err != nil || !hedgeBillable(relay.getContext()) {
 		quota.Undo(relay.getContext())
 		return
 	}
@@ -152,13 +207,41 @@ func RelayHandler(relay RelayBaseInterface) (err *types.OpenAIErrorWithStatusCod
 	quota.SetFirstResponseTime(relay.GetFirstResponseTime())
 
 	quota.Consume(relay.getContext(), usage, relay.IsStream())
+	model.GlobalChannelLimiter.RecordTokens(channel, relay.getOriginalModel(), usage.TotalTokens)
+	model.ChannelGroup.ObserveContext(channel.Id, relay.getOriginalModel(), usage.PromptTokens)
 
 	return
 }
 
+// recordChannelResult 将本次请求结果反馈给负载均衡，用于自适应权重计算和熔断
+// 本地错误、请求参数错误和对冲落选被取消的请求与渠道无关，不计入，但需要释放熔断器的探测名额
+func recordChannelResult(relay RelayBaseInterface, probe *model.BreakerProbe, startTime time.Time, apiErr *types.OpenAIErrorWithStatusCode) {
+	channel := relay.getProvider().GetChannel()
+	if (apiErr != nil && (apiErr.LocalError || apiErr.StatusCode == http.StatusBadRequest)) || hedgeCancelled(relay.getContext()) {
+		model.ChannelGroup.ReleaseBreakerProbe(probe)
+		return
+	}
+
+	var ttft time.Duration
+	if firstResponseTime := relay.GetFirstResponseTime(); firstResponseTime.After(startTime) {
+		ttft = firstResponseTime.Sub(startTime)
+	}
+
+	model.ChannelGroup.RecordResult(channel.Id, relay.getOriginalModel(), time.Since(startTime), ttft, apiErr == nil)
+	model.ChannelGroup.RecordBreakerResult(channel.Id, relay.getOriginalModel(), apiErr == nil, probe)
+
+	statusCode := http.StatusOK
+	if apiErr != nil {
+		statusCode = apiErr.StatusCode
+	}
+	model.GlobalKeyPool.RecordResult(channel, statusCode)
+}
+
 // retrySameChannel 同渠道重试（账号池模式）
 // 当渠道配置了 retry_times > 0 时，在同一渠道上重试指定次数
-// 429 错误不进行同渠道重试，因为整个渠道被限流
+// 启用账号池时每次重试换用未尝试过的 key，未设置重试次数时最多尝试完所有 key
+// 未启用账号池时 429 错误不进行同渠道重试，因为整个渠道被限流
+// 匹配到错误策略时，retry_same 至少重试一次，其他处理方式在未启用账号池时直接换渠道
 func retrySameChannel(
 	c *gin.Context,
 	relay RelayBaseInterface,
@@ -168,14 +251,34 @@ func retrySameChannel(
 	timeout time.Duration,
 ) (*types.OpenAIErrorWithStatusCode, bool) {
 	sameChannelRetries := channel.GetRetryTimes()
+	pool := poolChannel(relay)
+	if pool != nil && sameChannelRetries <= 0 {
+		sameChannelRetries = len(pool.PoolKeys()) - 1
+	}
+	if policy := model.GlobalErrorPolicy.Match(channel.Type, lastErr); policy != nil {
+		if policy.Action == model.ErrorPolicyActionRetrySame {
+			sameChannelRetries = max(sameChannelRetries, 1)
+		} else if pool == nil {
+			return lastErr, false
+		}
+	}
 	if sameChannelRetries <= 0 {
 		return lastErr, false
 	}
 
 	apiErr := lastErr
 	for j := 0; j < sameChannelRetries; j++ {
-		// 429 表示整个渠道被限流，不再同渠道重试
-		if apiErr.StatusCode == http.StatusTooManyRequests {
+		if pool != nil {
+			if !switchPoolKey(c, relay, pool) {
+				break
+			}
+		} else if policy := model.GlobalErrorPolicy.Match(channel.Type, apiErr); policy != nil {
+			// 错误策略要求换渠道时不再同渠道重试
+			if policy.Action != model.ErrorPolicyActionRetrySame {
+				break
+			}
+		} else if apiErr.StatusCode == http.StatusTooManyRequests {
+			// 429 表示整个渠道被限流，不再同渠道重试
 			break
 		}
 
@@ -196,7 +299,7 @@ func retrySameChannel(
 		if apiErr == nil {
 			return nil, false
 		}
-		go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, apiErr, channel.Type)
+		handleChannelRelayError(c, channel, apiErr)
 		if done || !shouldRetry(c, apiErr, channel.Type) {
 			return apiErr, done
 		}
@@ -209,11 +312,16 @@ func shouldCooldowns(c *gin.Context, channel *model.Channel, apiErr *types.OpenA
 	modelName := c.GetString("new_model")
 	channelId := channel.Id
 
-	// 如果是频率限制，冻结通道
-	if apiErr.StatusCode == http.StatusTooManyRequests {
+	// 如果是频率限制，冻结通道（启用熔断器时由熔断器接管，匹配到错误策略时由策略决定）
+	if apiErr.StatusCode == http.StatusTooManyRequests && !config.CircuitBreakerEnabled && model.GlobalErrorPolicy.Match(channel.Type, apiErr) == nil {
 		model.ChannelGroup.SetCooldowns(channelId, modelName)
 	}
 
+	addSkipChannel(c, channelId)
+}
+
+// addSkipChannel 后续选择渠道时跳过该渠道
+func addSkipChannel(c *gin.Context, channelId int) {
 	skipChannelIds, ok := utils.GetGinValue[[]int](c, "skip_channel_ids")
 	if !ok {
 		skipChannelIds = make([]int, 0)
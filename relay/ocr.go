@@ -0,0 +1,71 @@
+package relay
+
+import (
+	"czloapi/common"
+	providersBase "czloapi/providers/base"
+	"czloapi/types"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type relayOCR struct {
+	relayBase
+	request types.OCRRequest
+}
+
+func NewRelayOCR(c *gin.Context) *relayOCR {
+	relay := &relayOCR{}
+	relay.c = c
+	return relay
+}
+
+func (r *relayOCR) setRequest() error {
+	if err := common.UnmarshalBodyReusable(r.c, &r.request); err != nil {
+		return err
+	}
+
+	if r.request.Document.Type == "" {
+		return errors.New("document is required")
+	}
+
+	r.setOriginalModel(r.request.Model)
+
+	return nil
+}
+
+func (r *relayOCR) getRequest() interface{} {
+	return &r.request
+}
+
+// getPromptTokens OCR 按页计费，预扣时按指定的页数估算，未指定时按一页计算
+func (r *relayOCR) getPromptTokens() (int, error) {
+	if len(r.request.Pages) > 0 {
+		return len(r.request.Pages), nil
+	}
+	return 1, nil
+}
+
+func (r *relayOCR) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
+	provider, ok := r.provider.(providersBase.OCRInterface)
+	if !ok {
+		err = common.StringErrorWrapperLocal("channel not implemented", "channel_error", http.StatusServiceUnavailable)
+		done = true
+		return
+	}
+
+	r.request.Model = r.modelName
+
+	response, err := provider.CreateOCR(&r.request)
+	if err != nil {
+		return
+	}
+	err = responseJsonClient(r.c, response)
+
+	if err != nil {
+		done = true
+	}
+
+	return
+}
@@ -54,6 +54,8 @@ func ChatRealtime(c *gin.Context) {
 		},
 		userConn: userConn,
 	}
+	// 整个会话期间占用渠道限制名额
+	defer relay.releaseChannelLimit()
 	relay.setOriginalModel(modelName)
 
 	if !relay.getProvider() {
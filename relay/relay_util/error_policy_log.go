@@ -0,0 +1,64 @@
+package relay_util
+
+import (
+	"czloapi/common"
+	"czloapi/common/utils"
+	"czloapi/model"
+	"czloapi/types"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const ErrorPolicyContextKey = "error_policy"
+
+// ErrorPolicyMatch 上游错误匹配到的错误策略，写入日志的 metadata
+type ErrorPolicyMatch struct {
+	ChannelId  int    `json:"channel_id"`
+	StatusCode int    `json:"status_code"`
+	PolicyId   int    `json:"policy_id"`
+	PolicyName string `json:"policy_name"`
+	Action     string `json:"action"`
+}
+
+// RecordErrorPolicyLog 请求最终失败且匹配过错误策略时记录一条不计费的日志
+// 成功的请求由 Quota 写入消费日志的 metadata
+func RecordErrorPolicyLog(c *gin.Context, modelName string, apiErr *types.OpenAIErrorWithStatusCode) {
+	if apiErr == nil {
+		return
+	}
+	matches, ok := utils.GetGinValue[[]*ErrorPolicyMatch](c, ErrorPolicyContextKey)
+	if !ok || len(matches) == 0 {
+		return
+	}
+
+	last := matches[len(matches)-1]
+	content := fmt.Sprintf("请求失败（状态码 %d）：%s", apiErr.StatusCode, apiErr.Message)
+	metadata := map[string]any{
+		"group_name":     c.GetString("key_group"),
+		"request_path":   c.Request.URL.Path,
+		"error_policies": append([]*ErrorPolicyMatch(nil), matches...),
+	}
+	requestTime := 0
+	if startTime := c.GetTime("requestStartTime"); !startTime.IsZero() {
+		requestTime = int(time.Since(startTime).Milliseconds())
+	}
+
+	model.RecordConsumeLog(
+		c.Request.Context(),
+		c.GetInt("id"),
+		last.ChannelId,
+		c.GetInt("key_id"),
+		0,
+		0,
+		modelName,
+		c.GetString("key_name"),
+		0,
+		content,
+		requestTime,
+		c.GetBool("is_stream"),
+		metadata,
+		common.GetClientIP(c),
+	)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"czloapi/common/requester"
 	"net/http"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -22,6 +23,8 @@ type HeartbeatConfig struct {
 
 // Heartbeat 心跳处理器
 type Heartbeat struct {
+	// mu 保证 Stop 返回后心跳不会再写入，调用方可以安全地接管输出
+	mu         sync.Mutex
 	isStream   bool
 	config     HeartbeatConfig
 	ctx        context.Context
@@ -57,6 +60,9 @@ func (h *Heartbeat) HasWrittenHeader() bool {
 // writeHeader 立即写入头部，确保只写入一次
 // 返回是否成功写入头部
 func (h *Heartbeat) writeHeader() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	// 如果已停止，不允许写入
 	if atomic.LoadInt32(&h.stopped) == 1 {
 		return false
@@ -77,6 +83,17 @@ func (h *Heartbeat) writeHeader() bool {
 	return true
 }
 
+// SetEventStreamHeaders 在心跳锁内设置流式响应头，避免与心跳协程并发写 header；心跳已写入头部时跳过
+func (h *Heartbeat) SetEventStreamHeaders() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.HasWrittenHeader() {
+		return
+	}
+	requester.SetEventStreamHeaders(h.c)
+}
+
 // Start 启动心跳机制，在指定的超时时间后开始发送心跳
 func (h *Heartbeat) Start() {
 	// 如果已停止，不允许启动
@@ -117,24 +134,9 @@ func (h *Heartbeat) Start() {
 						h.Stop() // 确保停止
 						return
 					case <-ticker.C:
-						// 检查是否已停止
-						if atomic.LoadInt32(&h.stopped) == 1 {
+						if !h.ping() {
 							return
 						}
-
-						var err error
-						// 发送心跳并处理错误
-						if h.isStream {
-							_, err = h.c.Writer.Write([]byte(HeartbeatStreamText))
-						} else {
-							_, err = h.c.Writer.Write([]byte(HeartbeatJsonText))
-						}
-						if err != nil {
-							// 发生错误，停止心跳
-							h.Stop()
-							return
-						}
-						h.c.Writer.Flush()
 					}
 				}
 			case <-h.ctx.Done():
@@ -147,13 +149,45 @@ func (h *Heartbeat) Start() {
 		})
 }
 
+// ping 发送一次心跳，已停止或写入失败时返回 false
+func (h *Heartbeat) ping() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// 检查是否已停止
+	if atomic.LoadInt32(&h.stopped) == 1 {
+		return false
+	}
+
+	var err error
+	// 发送心跳并处理错误
+	if h.isStream {
+		_, err = h.c.Writer.Write([]byte(HeartbeatStreamText))
+	} else {
+		_, err = h.c.Writer.Write([]byte(HeartbeatJsonText))
+	}
+	if err != nil {
+		// 发生错误，停止心跳
+		h.stopLocked()
+		return false
+	}
+	h.c.Writer.Flush()
+	return true
+}
+
 // Close 关闭心跳（可以在defer中使用）
 func (h *Heartbeat) Close() {
 	h.Stop()
 }
 
-// Stop 停止心跳
+// Stop 停止心跳，会等待正在进行的心跳写入完成
 func (h *Heartbeat) Stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopLocked()
+}
+
+func (h *Heartbeat) stopLocked() {
 	// 如果已经停止，不再处理
 	if !atomic.CompareAndSwapInt32(&h.stopped, 0, 1) {
 		return
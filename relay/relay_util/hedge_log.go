@@ -0,0 +1,89 @@
+package relay_util
+
+import (
+	"sync"
+	"time"
+)
+
+const HedgeLogContextKey = "hedge_log"
+
+const (
+	HedgeStatusPending   = "pending"
+	HedgeStatusWon       = "won"
+	HedgeStatusCancelled = "cancelled" // 落选后被取消，不计费
+	HedgeStatusDiscarded = "discarded" // 落选但继续执行完成，响应被丢弃，照常计费
+	HedgeStatusFailed    = "failed"
+)
+
+type HedgeAttemptLog struct {
+	ChannelId   int    `json:"channel_id"`
+	StartedAt   int64  `json:"started_at"` // 相对对冲开始的毫秒数
+	FirstByteAt int64  `json:"first_byte_at,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+// HedgeLog 记录对冲请求中每次尝试的情况，写入日志的 metadata
+type HedgeLog struct {
+	sync.Mutex
+	startTime  time.Time
+	delay      int64
+	chargeBoth bool
+	attempts   []*HedgeAttemptLog
+}
+
+func NewHedgeLog(delay time.Duration, chargeBoth bool) *HedgeLog {
+	return &HedgeLog{
+		startTime:  time.Now(),
+		delay:      delay.Milliseconds(),
+		chargeBoth: chargeBoth,
+	}
+}
+
+// AddAttempt 添加一次尝试，返回其序号
+func (h *HedgeLog) AddAttempt(channelId int) int {
+	h.Lock()
+	defer h.Unlock()
+
+	h.attempts = append(h.attempts, &HedgeAttemptLog{
+		ChannelId: channelId,
+		StartedAt: time.Since(h.startTime).Milliseconds(),
+		Status:    HedgeStatusPending,
+	})
+	return len(h.attempts) - 1
+}
+
+func (h *HedgeLog) SetFirstByte(index int) {
+	h.Lock()
+	defer h.Unlock()
+
+	if index < len(h.attempts) && h.attempts[index].FirstByteAt == 0 {
+		h.attempts[index].FirstByteAt = time.Since(h.startTime).Milliseconds()
+	}
+}
+
+func (h *HedgeLog) SetStatus(index int, status string, errMsg string) {
+	h.Lock()
+	defer h.Unlock()
+
+	if index < len(h.attempts) {
+		h.attempts[index].Status = status
+		h.attempts[index].Error = errMsg
+	}
+}
+
+func (h *HedgeLog) Snapshot() map[string]any {
+	h.Lock()
+	defer h.Unlock()
+
+	attempts := make([]HedgeAttemptLog, 0, len(h.attempts))
+	for _, attempt := range h.attempts {
+		attempts = append(attempts, *attempt)
+	}
+
+	return map[string]any{
+		"delay":       h.delay,
+		"charge_both": h.chargeBoth,
+		"attempts":    attempts,
+	}
+}
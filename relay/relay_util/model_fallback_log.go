@@ -0,0 +1,16 @@
+package relay_util
+
+const ModelFallbackContextKey = "model_fallback"
+
+// ModelFallbackLog 模型回退记录，写入日志的 metadata
+type ModelFallbackLog struct {
+	RequestedModel string   `json:"requested_model"`
+	ServedModel    string   `json:"served_model"`
+	FailedModels   []string `json:"failed_models"`
+}
+
+func (l *ModelFallbackLog) Clone() *ModelFallbackLog {
+	cloned := *l
+	cloned.FailedModels = append([]string(nil), l.FailedModels...)
+	return &cloned
+}
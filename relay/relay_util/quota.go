@@ -51,6 +51,9 @@ type Quota struct {
 	requestTransport  string
 	userAgent         string
 	reasoningMetadata *types.LogReasoningMetadata
+	hedgeLog          *HedgeLog
+	modelFallback     *ModelFallbackLog
+	errorPolicies     []*ErrorPolicyMatch
 	channelType       int
 }
 
@@ -85,6 +88,18 @@ func NewQuota(c *gin.Context, modelName string, promptTokens int, billingContext
 		quota.reasoningMetadata = &clonedMetadata
 	}
 
+	if hedgeLog, ok := utils.GetGinValue[*HedgeLog](c, HedgeLogContextKey); ok && hedgeLog != nil {
+		quota.hedgeLog = hedgeLog
+	}
+
+	if modelFallback, ok := utils.GetGinValue[*ModelFallbackLog](c, ModelFallbackContextKey); ok && modelFallback != nil {
+		quota.modelFallback = modelFallback.Clone()
+	}
+
+	if errorPolicies, ok := utils.GetGinValue[[]*ErrorPolicyMatch](c, ErrorPolicyContextKey); ok && len(errorPolicies) > 0 {
+		quota.errorPolicies = append([]*ErrorPolicyMatch(nil), errorPolicies...)
+	}
+
 	quota.price = *model.PricingInstance.GetPrice(quota.modelName)
 	quota.billingResolution = model.PricingInstance.GetBillingResolution(quota.modelName, billingContext)
 	quota.groupName = c.GetString("key_group")
@@ -551,6 +566,22 @@ func (q *Quota) GetLogMeta(usage *types.Usage, requestPath ...string) map[string
 		meta["reasoning"] = q.reasoningMetadata
 	}
 
+	if q.hedgeLog != nil {
+		meta["hedge"] = q.hedgeLog.Snapshot()
+	}
+
+	if q.modelFallback != nil {
+		meta["model_fallback"] = q.modelFallback
+	}
+
+	if len(q.errorPolicies) > 0 {
+		meta["error_policies"] = q.errorPolicies
+	}
+
+	if usage != nil && usage.UpstreamCost > 0 {
+		meta["upstream_cost"] = usage.UpstreamCost
+	}
+
 	return meta
 }
 
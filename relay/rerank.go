@@ -5,6 +5,7 @@ import (
 	"czloapi/common/config"
 	"czloapi/common/logger"
 	providersBase "czloapi/providers/base"
+	"czloapi/relay/relay_util"
 	"czloapi/types"
 	"fmt"
 	"net/http"
@@ -24,6 +25,7 @@ func RelayRerank(c *gin.Context) {
 		common.AbortWithErr(c, http.StatusServiceUnavailable, &types.RerankError{Detail: err.Error()})
 		return
 	}
+	defer relay.releaseChannelLimit()
 
 	apiErr, done := RelayHandler(relay)
 	if apiErr == nil {
@@ -31,7 +33,7 @@ func RelayRerank(c *gin.Context) {
 	}
 
 	channel := relay.getProvider().GetChannel()
-	go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, apiErr, channel.Type)
+	handleChannelRelayError(c, channel, apiErr)
 
 	retryTimes := config.RetryTimes
 	if done || !shouldRetry(c, apiErr, channel.Type) {
@@ -52,13 +54,14 @@ func RelayRerank(c *gin.Context) {
 		if apiErr == nil {
 			return
 		}
-		go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, apiErr, channel.Type)
+		handleChannelRelayError(c, channel, apiErr)
 		if done || !shouldRetry(c, apiErr, channel.Type) {
 			break
 		}
 	}
 
 	if apiErr != nil {
+		relay_util.RecordErrorPolicyLog(c, relay.getOriginalModel(), apiErr)
 		if apiErr.StatusCode == http.StatusTooManyRequests {
 			apiErr.OpenAIError.Message = "当前分组上游负载已饱和，请稍后再试"
 		}
@@ -66,6 +66,8 @@ func ResponsesWS(c *gin.Context) {
 	}
 
 	relay.setOriginalModel(modelName)
+	// 整个会话期间占用渠道限制名额
+	defer relay.releaseChannelLimit()
 
 	if !relay.getProvider() {
 		return
@@ -0,0 +1,425 @@
+package relay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"czloapi/common/config"
+	"czloapi/common/logger"
+	"czloapi/common/utils"
+	"czloapi/model"
+	"czloapi/types"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// 影子请求的最长执行时间，不受客户端断开影响
+	shadowTimeout = 5 * time.Minute
+	// 用于比较的响应内容上限
+	shadowCaptureLimit = 1 << 20
+	// 相似度只比较输出文本的前 N 个字符
+	shadowSimilarityLimit = 4000
+)
+
+// shadowPrimary 线上请求的结果，请求结束后关闭 done
+type shadowPrimary struct {
+	startTime time.Time
+	capture   *shadowCapture
+	done      chan struct{}
+	status    int
+	latency   time.Duration
+	body      []byte
+}
+
+// startShadowMirror 将请求异步复制给范围内的影子渠道，返回的函数需在线上请求结束后调用
+func startShadowMirror(c *gin.Context, relay RelayBaseInterface) func() {
+	if c.GetInt("specific_channel_id") > 0 && !c.GetBool("specific_channel_id_ignore") {
+		return func() {}
+	}
+
+	modelName := relay.getOriginalModel()
+	channels := model.ChannelGroup.ShadowChannels(c.GetString("key_group"), modelName)
+	if len(channels) == 0 {
+		return func() {}
+	}
+
+	primary := &shadowPrimary{
+		startTime: time.Now(),
+		capture:   &shadowCapture{ResponseWriter: c.Writer},
+		done:      make(chan struct{}),
+	}
+	c.Writer = primary.capture
+
+	for _, channel := range channels {
+		shadowCtx, cancel := newShadowContext(c, channel.Id)
+		go runShadow(shadowCtx, cancel, channel, modelName, primary)
+	}
+
+	return func() {
+		primary.status = c.Writer.Status()
+		primary.latency = time.Since(primary.startTime)
+		primary.body = primary.capture.bytes()
+		close(primary.done)
+	}
+}
+
+// newShadowContext 复制请求上下文并指定影子渠道，响应写入 shadowWriter 不会发给客户端
+func newShadowContext(c *gin.Context, channelId int) (*gin.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), shadowTimeout)
+	shadowCtx := c.Copy()
+	shadowCtx.Request = c.Request.WithContext(ctx)
+	shadowCtx.Writer = &shadowWriter{header: make(http.Header)}
+
+	if body, ok := utils.GetGinValue[[]byte](c, config.GinRequestBodyKey); ok {
+		shadowCtx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	shadowCtx.Set("specific_channel_id", channelId)
+	shadowCtx.Set("specific_channel_id_ignore", false)
+
+	return shadowCtx, cancel
+}
+
+// runShadow 发送影子请求，不计费也不计入负载均衡，等待线上请求结束后记录比较结果
+func runShadow(c *gin.Context, cancel context.CancelFunc, channel *model.Channel, modelName string, primary *shadowPrimary) {
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			logger.LogError(c.Request.Context(), fmt.Sprintf("shadow channel #%d panic: %v", channel.Id, r))
+		}
+	}()
+
+	relay := Path2Relay(c, c.Request.URL.Path)
+	if relay == nil || relay.setRequest() != nil {
+		return
+	}
+
+	relay.setOriginalModel(modelName)
+	if err := relay.setProvider(modelName); err != nil {
+		logger.LogError(c.Request.Context(), fmt.Sprintf("shadow channel #%d unavailable: %s", channel.Id, err.Error()))
+		return
+	}
+	defer relay.releaseChannelLimit()
+
+	// 上游请求默认不跟随上下文取消，影子请求需要在超时后中断
+	if requester := relay.getProvider().GetRequester(); requester != nil {
+		requester.Context = c.Request.Context()
+	}
+
+	promptTokens, err := relay.getPromptTokens()
+	if err != nil {
+		return
+	}
+	relay.getProvider().SetUsage(&types.Usage{PromptTokens: promptTokens})
+
+	startTime := time.Now()
+	apiErr, _ := relay.send()
+	latency := time.Since(startTime)
+
+	writer := c.Writer.(*shadowWriter)
+	status := writer.Status()
+	if apiErr != nil {
+		status = apiErr.StatusCode
+	}
+
+	select {
+	case <-primary.done:
+	case <-c.Request.Context().Done():
+		return
+	}
+
+	rollout := channel.GetRollout()
+	result := compareShadow(primary, status, latency, writer.bytes(), rollout != nil && rollout.Similarity)
+	result.ChannelId = channel.Id
+	result.Model = modelName
+	model.ChannelGroup.RecordShadowResult(result)
+}
+
+// compareShadow 比较线上请求与影子请求的状态码、延迟、输出长度，similarity 为 true 时比较输出文本相似度
+func compareShadow(primary *shadowPrimary, status int, latency time.Duration, body []byte, similarity bool) *model.ShadowResult {
+	primaryText := extractShadowText(primary.body)
+	shadowText := extractShadowText(body)
+
+	result := &model.ShadowResult{
+		PrimaryStatus:  primary.status,
+		ShadowStatus:   status,
+		PrimaryLatency: primary.latency,
+		ShadowLatency:  latency,
+		PrimaryLength:  outputLength(primary.body, primaryText),
+		ShadowLength:   outputLength(body, shadowText),
+		Similarity:     -1,
+	}
+
+	if similarity && primary.status < http.StatusBadRequest && status < http.StatusBadRequest && (primaryText != "" || shadowText != "") {
+		result.Similarity = textSimilarity(primaryText, shadowText)
+	}
+
+	return result
+}
+
+func outputLength(body []byte, text string) int {
+	if text != "" {
+		return utf8.RuneCountInString(text)
+	}
+	return len(body)
+}
+
+// shadowTextPayload 兼容 OpenAI、Claude、Gemini 和 Responses 格式的输出文本字段
+type shadowTextPayload struct {
+	Choices []struct {
+		Text    string `json:"text"`
+		Message *struct {
+			Content any `json:"content"`
+		} `json:"message"`
+		Delta *struct {
+			Content any `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Content []struct {
+		Text string `json:"text"`
+	} `json:"content"`
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+	Output []struct {
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"output"`
+	Delta json.RawMessage `json:"delta"`
+}
+
+func (p *shadowTextPayload) writeText(builder *strings.Builder) {
+	for _, choice := range p.Choices {
+		builder.WriteString(choice.Text)
+		if choice.Message != nil {
+			if content, ok := choice.Message.Content.(string); ok {
+				builder.WriteString(content)
+			}
+		}
+		if choice.Delta != nil {
+			if content, ok := choice.Delta.Content.(string); ok {
+				builder.WriteString(content)
+			}
+		}
+	}
+	for _, content := range p.Content {
+		builder.WriteString(content.Text)
+	}
+	for _, candidate := range p.Candidates {
+		for _, part := range candidate.Content.Parts {
+			builder.WriteString(part.Text)
+		}
+	}
+	for _, output := range p.Output {
+		for _, content := range output.Content {
+			builder.WriteString(content.Text)
+		}
+	}
+
+	if len(p.Delta) == 0 {
+		return
+	}
+	// Responses 流式的 delta 为字符串，Claude 流式的 delta 为对象
+	var deltaText string
+	if json.Unmarshal(p.Delta, &deltaText) == nil {
+		builder.WriteString(deltaText)
+		return
+	}
+	var delta struct {
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(p.Delta, &delta) == nil {
+		builder.WriteString(delta.Text)
+	}
+}
+
+// extractShadowText 尽量从 JSON 或 SSE 响应中提取输出文本，无法识别时返回空
+func extractShadowText(body []byte) string {
+	var builder strings.Builder
+	var payload shadowTextPayload
+	if json.Unmarshal(body, &payload) == nil {
+		payload.writeText(&builder)
+		return builder.String()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), shadowCaptureLimit)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "" || data == "[DONE]" {
+			continue
+		}
+
+		payload = shadowTextPayload{}
+		if json.Unmarshal([]byte(data), &payload) == nil {
+			payload.writeText(&builder)
+		}
+	}
+
+	return builder.String()
+}
+
+func similarityBigrams(text string) map[string]int {
+	runes := []rune(text)
+	if len(runes) > shadowSimilarityLimit {
+		runes = runes[:shadowSimilarityLimit]
+	}
+
+	bigrams := make(map[string]int)
+	if len(runes) == 1 {
+		bigrams[string(runes)]++
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		bigrams[string(runes[i:i+2])]++
+	}
+	return bigrams
+}
+
+// textSimilarity 基于字符二元组的 Dice 系数，范围 0-1
+func textSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	bigramsA := similarityBigrams(a)
+	bigramsB := similarityBigrams(b)
+	total := 0
+	for _, count := range bigramsA {
+		total += count
+	}
+	for _, count := range bigramsB {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	overlap := 0
+	for bigram, countA := range bigramsA {
+		overlap += min(countA, bigramsB[bigram])
+	}
+	return float64(2*overlap) / float64(total)
+}
+
+// shadowCapture 转发线上请求的响应，同时记录用于比较的内容
+type shadowCapture struct {
+	gin.ResponseWriter
+	mu   sync.Mutex
+	body bytes.Buffer
+}
+
+func (w *shadowCapture) capture(data []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if remain := shadowCaptureLimit - w.body.Len(); remain > 0 {
+		w.body.Write(data[:min(len(data), remain)])
+	}
+}
+
+func (w *shadowCapture) bytes() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return bytes.Clone(w.body.Bytes())
+}
+
+func (w *shadowCapture) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *shadowCapture) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// shadowWriter 影子请求的响应只记录不发送
+type shadowWriter struct {
+	header http.Header
+	status int
+	size   int
+	body   bytes.Buffer
+}
+
+func (w *shadowWriter) bytes() []byte {
+	return w.body.Bytes()
+}
+
+func (w *shadowWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *shadowWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *shadowWriter) WriteHeaderNow() {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (w *shadowWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	if remain := shadowCaptureLimit - w.body.Len(); remain > 0 {
+		w.body.Write(data[:min(len(data), remain)])
+	}
+	w.size += len(data)
+	return len(data), nil
+}
+
+func (w *shadowWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *shadowWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *shadowWriter) Size() int {
+	if w.status == 0 {
+		return -1
+	}
+	return w.size
+}
+
+func (w *shadowWriter) Written() bool {
+	return w.status != 0
+}
+
+func (w *shadowWriter) Flush() {}
+
+func (w *shadowWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("shadow writer does not support hijack")
+}
+
+func (w *shadowWriter) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
+func (w *shadowWriter) Pusher() http.Pusher {
+	return nil
+}
@@ -0,0 +1,55 @@
+package relay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractShadowText(t *testing.T) {
+	chat := `{"choices":[{"message":{"role":"assistant","content":"hello world"}}]}`
+	assert.Equal(t, "hello world", extractShadowText([]byte(chat)))
+
+	claude := `{"content":[{"type":"text","text":"hi"}]}`
+	assert.Equal(t, "hi", extractShadowText([]byte(claude)))
+
+	stream := "data: {\"choices\":[{\"delta\":{\"content\":\"hel\"}}]}\n\n" +
+		"data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n" +
+		"data: [DONE]\n\n"
+	assert.Equal(t, "hello", extractShadowText([]byte(stream)))
+
+	claudeStream := "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"ok\"}}\n\n"
+	assert.Equal(t, "ok", extractShadowText([]byte(claudeStream)))
+
+	responsesStream := "data: {\"type\":\"response.output_text.delta\",\"delta\":\"yes\"}\n\n"
+	assert.Equal(t, "yes", extractShadowText([]byte(responsesStream)))
+
+	assert.Empty(t, extractShadowText([]byte(`{"data":[{"embedding":[0.1]}]}`)))
+}
+
+func TestTextSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, textSimilarity("hello", "hello"))
+	assert.Equal(t, 0.0, textSimilarity("abc", "xyz"))
+	assert.InDelta(t, 0.25, textSimilarity("night", "nacht"), 0.001)
+	assert.Equal(t, 0.0, textSimilarity("", "hello"))
+}
+
+func TestCompareShadow(t *testing.T) {
+	primary := &shadowPrimary{
+		status:  200,
+		latency: time.Second,
+		body:    []byte(`{"choices":[{"message":{"content":"hello world"}}]}`),
+	}
+
+	result := compareShadow(primary, 200, 2*time.Second, []byte(`{"choices":[{"message":{"content":"hello there"}}]}`), true)
+	assert.Equal(t, 11, result.PrimaryLength)
+	assert.Equal(t, 11, result.ShadowLength)
+	assert.Equal(t, 2*time.Second, result.ShadowLatency)
+	assert.Greater(t, result.Similarity, 0.0)
+	assert.Less(t, result.Similarity, 1.0)
+
+	result = compareShadow(primary, 500, time.Second, []byte(`{"error":{"message":"boom"}}`), true)
+	assert.Equal(t, 500, result.ShadowStatus)
+	assert.Equal(t, -1.0, result.Similarity)
+}
@@ -147,6 +147,16 @@ func SetApiRouter(router *gin.Engine) {
 			modelMappingRoute.DELETE("/:id", controller.DeleteModelMapping)
 		}
 
+		errorPolicyRoute := apiRouter.Group("/error_policy")
+		errorPolicyRoute.Use(middleware.AdminAuth())
+		{
+			errorPolicyRoute.GET("/", controller.GetAllErrorPolicies)
+			errorPolicyRoute.GET("/:id", controller.GetErrorPolicy)
+			errorPolicyRoute.POST("/", controller.CreateErrorPolicy)
+			errorPolicyRoute.PUT("/", controller.UpdateErrorPolicy)
+			errorPolicyRoute.DELETE("/:id", controller.DeleteErrorPolicy)
+		}
+
 		userGroup := apiRouter.Group("/user_group")
 		userGroup.Use(middleware.AdminAuth())
 		{
@@ -186,7 +196,27 @@ func SetApiRouter(router *gin.Engine) {
 			channelRoute.GET("/", controller.GetChannelsList)
 			channelRoute.GET("/models", relay.ListModelsForAdmin)
 			channelRoute.POST("/provider_models_list", controller.GetModelList)
+			channelRoute.GET("/health", controller.GetChannelsHealth)
+			channelRoute.GET("/breaker", controller.GetChannelsBreaker)
+			channelRoute.GET("/shadow", controller.GetChannelsShadow)
+			channelRoute.GET("/secrets", controller.GetChannelsSecretErrors)
+			channelRoute.GET("/capabilities", controller.GetChannelsCapabilities)
+			channelRoute.GET("/authenticity", controller.GetAuthenticityList)
+			channelRoute.POST("/authenticity/check", controller.CheckAllAuthenticity)
+			channelRoute.GET("/model_drift", controller.GetModelDriftList)
+			channelRoute.POST("/model_drift/detect", controller.DetectAllModelDrift)
+			channelRoute.POST("/model_drift/:id/apply", controller.ApplyModelDrift)
+			channelRoute.DELETE("/model_drift/:id", controller.DismissModelDrift)
 			channelRoute.GET("/:id/statistics", controller.GetChannelStatistics)
+			channelRoute.GET("/:id/health", controller.GetChannelHealth)
+			channelRoute.GET("/:id/breaker", controller.GetChannelBreaker)
+			channelRoute.GET("/:id/shadow", controller.GetChannelShadow)
+			channelRoute.GET("/:id/keys", controller.GetChannelKeyPool)
+			channelRoute.POST("/:id/model_drift", controller.DetectChannelModelDrift)
+			channelRoute.GET("/:id/capabilities", controller.GetChannelCapabilities)
+			channelRoute.POST("/:id/capabilities/probe", controller.ProbeChannelCapabilities)
+			channelRoute.POST("/:id/authenticity", controller.CheckChannelAuthenticity)
+			channelRoute.POST("/:id/override/dry_run", controller.DryRunChannelOverride)
 			channelRoute.GET("/:id", controller.GetChannel)
 			channelRoute.GET("/test", controller.TestAllChannels)
 			channelRoute.GET("/test/:id", controller.TestChannel)
@@ -198,6 +228,8 @@ func SetApiRouter(router *gin.Engine) {
 			channelRoute.PUT("/batch/del_model", controller.BatchDelModelChannels)
 			channelRoute.DELETE("/disabled", controller.DeleteDisabledChannel)
 			channelRoute.DELETE("/:id/tag", controller.DeleteChannelTag)
+			channelRoute.DELETE("/:id/breaker", controller.ResetChannelBreaker)
+			channelRoute.DELETE("/:id/shadow", controller.ResetChannelShadow)
 			channelRoute.DELETE("/:id", controller.DeleteChannel)
 			channelRoute.DELETE("/batch", controller.BatchDeleteChannel)
 		}
@@ -18,6 +18,9 @@ type Usage struct {
 	ExtraBilling     map[string]ExtraBilling    `json:"-"`
 	extraBillingKeys map[string]map[string]bool `json:"-"` // dedup: serviceType -> set of dedupeIDs
 	TextBuilder      strings.Builder            `json:"-"`
+
+	// 上游返回的实际费用（美元），如 OpenRouter 的 usage.cost，仅用于记录
+	UpstreamCost float64 `json:"-"`
 }
 
 type ExtraBilling struct {
@@ -0,0 +1,35 @@
+package types
+
+// OCRRequest 文档 OCR 请求，目前对应 Mistral 的 /v1/ocr
+type OCRRequest struct {
+	Model    string      `json:"model" binding:"required"`
+	Document OCRDocument `json:"document" binding:"required"`
+
+	ID                       string `json:"id,omitempty"`
+	Pages                    []int  `json:"pages,omitempty"`
+	IncludeImageBase64       *bool  `json:"include_image_base64,omitempty"`
+	ImageLimit               *int   `json:"image_limit,omitempty"`
+	ImageMinSize             *int   `json:"image_min_size,omitempty"`
+	BboxAnnotationFormat     any    `json:"bbox_annotation_format,omitempty"`
+	DocumentAnnotationFormat any    `json:"document_annotation_format,omitempty"`
+}
+
+// OCRDocument type 为 document_url 或 image_url，对应的地址可以是 URL 或 base64 data URI
+type OCRDocument struct {
+	Type         string `json:"type"`
+	DocumentURL  string `json:"document_url,omitempty"`
+	DocumentName string `json:"document_name,omitempty"`
+	ImageURL     any    `json:"image_url,omitempty"`
+}
+
+type OCRResponse struct {
+	Model              string       `json:"model"`
+	Pages              []any        `json:"pages"`
+	DocumentAnnotation any          `json:"document_annotation,omitempty"`
+	UsageInfo          OCRUsageInfo `json:"usage_info"`
+}
+
+type OCRUsageInfo struct {
+	PagesProcessed int  `json:"pages_processed"`
+	DocSizeBytes   *int `json:"doc_size_bytes,omitempty"`
+}
@@ -10,6 +10,9 @@ type RerankRequest struct {
 	Query     string `json:"query" binding:"required"`
 	TopN      int    `json:"top_n"`
 	Documents []any  `json:"documents" binding:"required"`
+
+	ReturnDocuments *bool `json:"return_documents,omitempty"`
+	Truncation      *bool `json:"truncation,omitempty"` // 超长时是否截断
 }
 
 func (r *RerankRequest) GetDocumentsList() ([]string, error) {
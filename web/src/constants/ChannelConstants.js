@@ -27,6 +27,20 @@ export const CHANNEL_OPTIONS = {
     color: 'secondary',
     url: 'https://oai.azure.com/'
   },
+  20: {
+    key: 20,
+    text: 'OpenRouter',
+    value: 20,
+    color: 'primary',
+    url: 'https://openrouter.ai/settings/credits'
+  },
+  23: {
+    key: 23,
+    text: '腾讯混元',
+    value: 23,
+    color: 'primary',
+    url: 'https://console.cloud.tencent.com/cam/capi'
+  },
   25: {
     key: 25,
     text: 'Google Gemini',
@@ -69,6 +83,13 @@ export const CHANNEL_OPTIONS = {
     color: 'default',
     url: 'https://platform.moonshot.cn/console/info'
   },
+  30: {
+    key: 30,
+    text: 'Mistral',
+    value: 30,
+    color: 'orange',
+    url: 'https://console.mistral.ai/'
+  },
   31: {
     key: 31,
     text: 'Groq',
@@ -104,6 +125,13 @@ export const CHANNEL_OPTIONS = {
     color: 'orange',
     url: 'https://ollama.com'
   },
+  40: {
+    key: 40,
+    text: '火山方舟',
+    value: 40,
+    color: 'primary',
+    url: 'https://console.volcengine.com/ark'
+  },
   42: {
     key: 42,
     text: 'VertexAI',
@@ -111,6 +139,34 @@ export const CHANNEL_OPTIONS = {
     color: 'orange',
     url: 'https://console.cloud.google.com/'
   },
+  44: {
+    key: 44,
+    text: 'Ideogram',
+    value: 44,
+    color: 'primary',
+    url: 'https://ideogram.ai/manage-api'
+  },
+  46: {
+    key: 46,
+    text: 'Flux',
+    value: 46,
+    color: 'primary',
+    url: 'https://dashboard.bfl.ai/'
+  },
+  47: {
+    key: 47,
+    text: 'Jina',
+    value: 47,
+    color: 'primary',
+    url: 'https://jina.ai/api-dashboard'
+  },
+  49: {
+    key: 49,
+    text: 'Voyage',
+    value: 49,
+    color: 'primary',
+    url: 'https://dashboard.voyageai.com/'
+  },
   54: {
     key: 54,
     text: 'Azure Databricks',
@@ -125,6 +181,20 @@ export const CHANNEL_OPTIONS = {
     color: 'orange',
     url: 'https://x.ai'
   },
+  57: {
+    key: 57,
+    text: 'ElevenLabs',
+    value: 57,
+    color: 'primary',
+    url: 'https://elevenlabs.io/app/settings/api-keys'
+  },
+  58: {
+    key: 58,
+    text: 'Stability',
+    value: 58,
+    color: 'primary',
+    url: 'https://platform.stability.ai/account/keys'
+  },
   8: {
     key: 8,
     text: '自定义渠道',
@@ -173,6 +173,81 @@ const typeConfig = {
       key: '按照如下格式输入：APIKey-AppId，例如：fastgpt-0sp2gtvfdgyi4k30jwlgwf1i-64f335d84283f05518e9e041'
     }
   },
+  20: {
+    input: {
+      models: ['openai/gpt-4o-mini', 'anthropic/claude-sonnet-4', 'google/gemini-2.5-flash', 'deepseek/deepseek-chat'],
+      test_model: 'openai/gpt-4o-mini'
+    },
+    inputLabel: {
+      provider_models_list: '从OpenRouter获取模型列表'
+    },
+    prompt: {
+      key: '请输入OpenRouter的API Key，路由偏好可在插件中设置，请求中的 provider、transforms 参数优先'
+    }
+  },
+  23: {
+    input: {
+      models: ['hunyuan-turbos-latest', 'hunyuan-t1-latest', 'hunyuan-vision', 'hunyuan-embedding', 'hunyuan-image'],
+      test_model: 'hunyuan-turbos-latest'
+    },
+    prompt: {
+      key: '按照如下格式输入：SecretId|SecretKey|Region，地域可以省略'
+    },
+    modelGroup: 'Hunyuan'
+  },
+  47: {
+    input: {
+      models: ['jina-embeddings-v3', 'jina-embeddings-v4', 'jina-clip-v2', 'jina-reranker-v2-base-multilingual', 'jina-reranker-m0'],
+      test_model: 'jina-embeddings-v3'
+    },
+    modelGroup: 'Jina'
+  },
+  49: {
+    input: {
+      models: ['voyage-3.5', 'voyage-3.5-lite', 'voyage-3-large', 'voyage-code-3', 'rerank-2.5', 'rerank-2.5-lite'],
+      test_model: 'voyage-3.5'
+    },
+    modelGroup: 'Voyage'
+  },
+  57: {
+    input: {
+      models: ['eleven_multilingual_v2', 'eleven_flash_v2_5', 'eleven_turbo_v2_5', 'eleven_v3'],
+      test_model: ''
+    },
+    modelGroup: 'ElevenLabs'
+  },
+  58: {
+    input: {
+      models: ['stable-image-ultra', 'stable-image-core', 'sd3.5-large', 'sd3.5-large-turbo', 'sd3.5-medium', 'sd3.5-flash'],
+      test_model: ''
+    },
+    modelGroup: 'Stability'
+  },
+  46: {
+    input: {
+      models: ['flux-pro-1.1', 'flux-pro-1.1-ultra', 'flux-dev', 'flux-kontext-pro', 'flux-kontext-max', 'flux-pro-1.0-fill'],
+      test_model: ''
+    },
+    modelGroup: 'Black Forest Labs'
+  },
+  44: {
+    input: {
+      models: ['ideogram-v3', 'ideogram-v3-turbo', 'ideogram-v3-quality', 'ideogram-v3-flash'],
+      test_model: ''
+    },
+    modelGroup: 'Ideogram'
+  },
+  40: {
+    input: {
+      models: ['doubao-seed-1-6-250615', 'doubao-seed-1-6-thinking-250715', 'doubao-embedding-vision-250615', 'doubao-seedream-4-0-250828'],
+      test_model: 'doubao-seed-1-6-250615'
+    },
+    prompt: {
+      key: '请输入火山方舟的API Key',
+      models: '请填写模型ID或推理接入点ID（ep-开头）'
+    },
+    modelGroup: 'Doubao'
+  },
   25: {
     inputLabel: {
       other: '版本号',
@@ -214,6 +289,24 @@ const typeConfig = {
     },
     modelGroup: 'Moonshot'
   },
+  30: {
+    input: {
+      models: [
+        'mistral-large-latest',
+        'mistral-medium-latest',
+        'mistral-small-latest',
+        'magistral-medium-latest',
+        'codestral-latest',
+        'mistral-embed',
+        'mistral-moderation-latest'
+      ],
+      test_model: 'mistral-small-latest'
+    },
+    inputLabel: {
+      provider_models_list: '从Mistral获取模型列表'
+    },
+    modelGroup: 'Mistral'
+  },
   31: {
     input: {
       models: ['llama2-7b-2048', 'llama2-70b-4096', 'mixtral-8x7b-32768', 'gemma-7b-it'],
@@ -430,6 +430,34 @@ export const apiSections = [
       ]
     }
   },
+  {
+    id: 'api-ocr',
+    title: 'Document OCR',
+    group: 'OpenAI Compatible',
+    method: 'POST',
+    endpoint: '/v1/ocr',
+    description: '文档 OCR 接口。识别 PDF 或图片中的文字、表格和图片，返回按页划分的 Markdown 内容。',
+    detail: '目前支持 Mistral 渠道（如 mistral-ocr-latest），文档可以是 URL 或 base64 data URI。按实际处理的页数计费。',
+    headers: {
+      Authorization: 'Bearer sk-your-api-key',
+      'Content-Type': 'application/json'
+    },
+    parameters: [
+      { name: 'model', type: 'string', required: true, desc: 'OCR 模型，如 mistral-ocr-latest' },
+      { name: 'document', type: 'object', required: true, desc: '文档，type 为 document_url 或 image_url' },
+      { name: 'pages', type: 'array', required: false, desc: '需要识别的页码，从 0 开始' },
+      { name: 'include_image_base64', type: 'boolean', required: false, desc: '是否返回页面中图片的 base64' }
+    ],
+    requestExample: {
+      model: 'mistral-ocr-latest',
+      document: { type: 'document_url', document_url: 'https://example.com/sample.pdf' }
+    },
+    responseExample: {
+      model: 'mistral-ocr-2505',
+      pages: [{ index: 0, markdown: '# 标题\n\n正文内容', images: [], dimensions: { dpi: 200, height: 2200, width: 1700 } }],
+      usage_info: { pages_processed: 1, doc_size_bytes: 102400 }
+    }
+  },
   {
     id: 'api-rerank',
     title: 'Rerank',